@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Admin package serves the operator API used to inspect and purge the AUSF state.
+ * It runs on its own listener and every request must carry the configured bearer token.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/producer"
+	"github.com/omec-project/openapi/v2/models"
+	"github.com/omec-project/openapi/v2/utils"
+	utilLogger "github.com/omec-project/util/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	apiPrefix             = "/admin/v1"
+	defaultBindingAddress = "127.0.0.1"
+	defaultPort           = 9090
+	shutdownTimeout       = 5 * time.Second
+)
+
+// AuthContextSummary describes an authentication context without exposing the subscriber identity
+type AuthContextSummary struct {
+	Ref                string            `json:"ref"`
+	AuthCtxId          string            `json:"authCtxId"`
+	Supi               string            `json:"supi"`
+	ServingNetworkName string            `json:"servingNetworkName,omitempty"`
+	AuthType           models.AuthType   `json:"authType,omitempty"`
+	AuthResult         models.AuthResult `json:"authResult,omitempty"`
+}
+
+// AuthContextDetail adds the per-UE state of an authentication context. Key material is never returned.
+type AuthContextDetail struct {
+	AuthContextSummary
+	UdmUeauUrl      string   `json:"udmUeauUrl,omitempty"`
+	SiblingContexts []string `json:"siblingContexts,omitempty"`
+}
+
+type UdmCache struct {
+	UdmUeauUrl string `json:"udmUeauUrl"`
+}
+
+// LogLevel is the level of a logger category. A level set through the API is reported as
+// overridden and reverts to the configured level at expiresAt, when set.
+type LogLevel struct {
+	Level      string     `json:"level"`
+	Overridden bool       `json:"overridden"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// LogLevelUpdate sets the level of a logger category, for ttl (e.g. "15m") when given
+type LogLevelUpdate struct {
+	Level string `json:"level"`
+	Ttl   string `json:"ttl,omitempty"`
+}
+
+// refKey keys the refs of the process. It is random so that a ref cannot be reversed to the
+// SUPI or SUCI by hashing the subscriber identities; the refs therefore change on restart.
+var refKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("cannot generate the admin ref key: %v", err))
+	}
+	return key
+}()
+
+// ref is an opaque handle on an authentication context, so that operators can address
+// a context listed by the API without knowing the unmasked SUPI or SUCI
+func ref(authCtxID string) string {
+	mac := hmac.New(sha256.New, refKey)
+	mac.Write([]byte(authCtxID))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func summarize(authCtxID, supi string) AuthContextSummary {
+	summary := AuthContextSummary{
+		Ref:       ref(authCtxID),
+		AuthCtxId: ausfContext.MaskSupi(authCtxID),
+		Supi:      ausfContext.MaskSupi(supi),
+	}
+	if ueContext := ausfContext.GetAusfUeContext(supi); ueContext != nil {
+		summary.ServingNetworkName = ueContext.ServingNetworkName
+		summary.AuthType = producer.AuthContextType(ueContext)
+		summary.AuthResult = ueContext.GetAuthStatus()
+	}
+	return summary
+}
+
+// resolveAuthCtxID accepts either the authentication context ID or its ref
+func resolveAuthCtxID(id string) (authCtxID, supi string, found bool) {
+	if ausfContext.CheckIfSuciSupiPairExists(id) {
+		return id, ausfContext.GetSupiFromSuciSupiMap(id), true
+	}
+	ausfContext.RangeSuciSupiPairs(func(candidate, candidateSupi string) bool {
+		if ref(candidate) == id {
+			authCtxID, supi, found = candidate, candidateSupi, true
+			return false
+		}
+		return true
+	})
+	return authCtxID, supi, found
+}
+
+func authenticate(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			logger.AdminLog.Warnf("unauthorized admin request %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized,
+				utils.ProblemDetails("Unauthorized", http.StatusUnauthorized, "missing or invalid bearer token"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// NewRouter returns the admin API router protected by the bearer token
+func NewRouter(token string) *gin.Engine {
+	router := utilLogger.NewGinWithZap(logger.GinLog)
+	group := router.Group(apiPrefix, authenticate(token))
+	group.GET("/auth-contexts", HTTPListAuthContexts)
+	group.GET("/auth-contexts/:authCtxId", HTTPGetAuthContext)
+	group.DELETE("/auth-contexts/:authCtxId", HTTPDeleteAuthContext)
+	group.GET("/nrf-subscriptions", HTTPListNrfSubscriptions)
+	group.GET("/udm-cache", HTTPGetUdmCache)
+	group.DELETE("/udm-cache", HTTPDeleteUdmCache)
+	group.GET("/log-levels", HTTPListLogLevels)
+	group.GET("/log-levels/:category", HTTPGetLogLevel)
+	group.PUT("/log-levels/:category", HTTPPutLogLevel)
+	group.DELETE("/log-levels/:category", HTTPDeleteLogLevel)
+	return router
+}
+
+// Get /admin/v1/auth-contexts
+func HTTPListAuthContexts(c *gin.Context) {
+	summaries := make([]AuthContextSummary, 0)
+	ausfContext.RangeSuciSupiPairs(func(authCtxID, supi string) bool {
+		summaries = append(summaries, summarize(authCtxID, supi))
+		return true
+	})
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Ref < summaries[j].Ref })
+	c.JSON(http.StatusOK, summaries)
+}
+
+// Get /admin/v1/auth-contexts/:authCtxId
+func HTTPGetAuthContext(c *gin.Context) {
+	authCtxID, supi, found := resolveAuthCtxID(c.Param("authCtxId"))
+	if !found {
+		c.JSON(http.StatusNotFound, utils.ProblemDetailsContextNotFound("auth context not found"))
+		return
+	}
+	detail := AuthContextDetail{AuthContextSummary: summarize(authCtxID, supi)}
+	if ueContext := ausfContext.GetAusfUeContext(supi); ueContext != nil {
+		detail.UdmUeauUrl = ueContext.UdmUeauUrl
+	}
+	for _, sibling := range ausfContext.ListSuciSupiPairsForSupi(supi) {
+		if sibling != authCtxID {
+			detail.SiblingContexts = append(detail.SiblingContexts, ref(sibling))
+		}
+	}
+	sort.Strings(detail.SiblingContexts)
+	c.JSON(http.StatusOK, detail)
+}
+
+// Delete /admin/v1/auth-contexts/:authCtxId
+func HTTPDeleteAuthContext(c *gin.Context) {
+	authCtxID, _, found := resolveAuthCtxID(c.Param("authCtxId"))
+	if !found || !producer.ForceDeleteAuthContext(authCtxID) {
+		c.JSON(http.StatusNotFound, utils.ProblemDetailsContextNotFound("auth context not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Get /admin/v1/nrf-subscriptions
+func HTTPListNrfSubscriptions(c *gin.Context) {
+	c.JSON(http.StatusOK, ausfContext.ListNfStatusSubscriptions())
+}
+
+// Get /admin/v1/udm-cache
+func HTTPGetUdmCache(c *gin.Context) {
+	c.JSON(http.StatusOK, UdmCache{UdmUeauUrl: producer.CachedUdmUeauUrl()})
+}
+
+// Delete /admin/v1/udm-cache
+func HTTPDeleteUdmCache(c *gin.Context) {
+	producer.InvalidateUdmCache()
+	c.Status(http.StatusNoContent)
+}
+
+func toLogLevel(current logger.CategoryLevel) LogLevel {
+	level := LogLevel{Level: current.Level.String(), Overridden: current.Overridden}
+	if !current.ExpiresAt.IsZero() {
+		expiresAt := current.ExpiresAt.UTC()
+		level.ExpiresAt = &expiresAt
+	}
+	return level
+}
+
+func categoryNotFound(c *gin.Context, category string) {
+	c.JSON(http.StatusNotFound, utils.ProblemDetailsContextNotFound(
+		fmt.Sprintf("unknown logger category %q, expected one of %v", category, logger.Categories())))
+}
+
+// Get /admin/v1/log-levels
+func HTTPListLogLevels(c *gin.Context) {
+	levels := make(map[string]LogLevel)
+	for _, category := range logger.Categories() {
+		if current, err := logger.GetCategoryLevel(category); err == nil {
+			levels[category] = toLogLevel(current)
+		}
+	}
+	c.JSON(http.StatusOK, levels)
+}
+
+// Get /admin/v1/log-levels/:category
+func HTTPGetLogLevel(c *gin.Context) {
+	current, err := logger.GetCategoryLevel(c.Param("category"))
+	if err != nil {
+		categoryNotFound(c, c.Param("category"))
+		return
+	}
+	c.JSON(http.StatusOK, toLogLevel(current))
+}
+
+// Put /admin/v1/log-levels/:category
+func HTTPPutLogLevel(c *gin.Context) {
+	category := c.Param("category")
+	var update LogLevelUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ProblemDetailsMalformedRequestSyntax(err.Error()))
+		return
+	}
+	level, err := zapcore.ParseLevel(update.Level)
+	if err != nil || update.Level == "" {
+		c.JSON(http.StatusBadRequest, utils.ProblemDetailsMalformedRequestSyntax(
+			fmt.Sprintf("invalid level %q, expected debug, info, warn, error, dpanic, panic or fatal", update.Level)))
+		return
+	}
+	var ttl time.Duration
+	if update.Ttl != "" {
+		if ttl, err = time.ParseDuration(update.Ttl); err != nil || ttl <= 0 {
+			c.JSON(http.StatusBadRequest, utils.ProblemDetailsMalformedRequestSyntax(
+				fmt.Sprintf("invalid ttl %q, expected a positive duration such as 15m", update.Ttl)))
+			return
+		}
+	}
+	if err := logger.SetCategoryLevel(category, level, ttl); err != nil {
+		categoryNotFound(c, category)
+		return
+	}
+	if ttl > 0 {
+		logger.AdminLog.Infof("log level of %s set to %s for %s", category, level, ttl)
+	} else {
+		logger.AdminLog.Infof("log level of %s set to %s", category, level)
+	}
+	current, _ := logger.GetCategoryLevel(category)
+	c.JSON(http.StatusOK, toLogLevel(current))
+}
+
+// Delete /admin/v1/log-levels/:category
+func HTTPDeleteLogLevel(c *gin.Context) {
+	category := c.Param("category")
+	if err := logger.ResetCategoryLevel(category); err != nil {
+		categoryNotFound(c, category)
+		return
+	}
+	logger.AdminLog.Infof("log level of %s reverted to the configured level", category)
+	c.Status(http.StatusNoContent)
+}
+
+func loadToken(cfg *factory.Admin) (string, error) {
+	if cfg.Token != "" {
+		return cfg.Token, nil
+	}
+	if cfg.TokenFile == "" {
+		return "", errors.New("admin API requires a token or tokenFile")
+	}
+	content, err := os.ReadFile(cfg.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("read admin token file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("admin token file %s is empty", cfg.TokenFile)
+	}
+	return token, nil
+}
+
+// Start serves the admin API until the context is cancelled
+func Start(ctx context.Context, cfg *factory.Admin) error {
+	token, err := loadToken(cfg)
+	if err != nil {
+		return err
+	}
+	bindingAddress := cfg.BindingAddress
+	if bindingAddress == "" {
+		bindingAddress = defaultBindingAddress
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	addr := net.JoinHostPort(bindingAddress, strconv.Itoa(port))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewRouter(token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.AdminLog.Warnf("admin API shutdown: %v", err)
+		}
+	}()
+
+	logger.AdminLog.Infof("admin API listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.AdminLog.Infoln("admin API stopped")
+	return nil
+}
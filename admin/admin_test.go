@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF Admin API
+ */
+
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+const (
+	testToken = "s3cr3t-admin-token"
+	testSuci  = "suci-0-208-93-0000-0-0-0000000001"
+	testSupi  = "imsi-208930000000001"
+)
+
+func addTestAuthContext(t *testing.T) {
+	t.Helper()
+	ueContext := ausfContext.NewAusfUeContext(testSupi)
+	ueContext.ServingNetworkName = "5G:mnc093.mcc208.3gppnetwork.org"
+	ueContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_ONGOING
+	ueContext.UdmUeauUrl = "https://udm:29503"
+	ueContext.Kausf = "kausf-secret"
+	ueContext.XresStar = "xres-star-secret"
+	ausfContext.AddAusfUeContextToPool(ueContext)
+	ausfContext.AddSuciSupiPairToMap(testSuci, testSupi)
+	t.Cleanup(func() {
+		ausfContext.RemoveSuciSupiPairFromMap(testSuci)
+		ausfContext.RemoveAusfUeContextFromPool(testSupi)
+	})
+}
+
+func doRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+/*
+ * Admin API Unit Tests
+ */
+
+func TestAdminAPI_RejectsMissingOrWrongToken(t *testing.T) {
+	router := NewRouter(testToken)
+	for _, token := range []string{"", "wrong-token"} {
+		rec := doRequest(router, http.MethodGet, apiPrefix+"/auth-contexts", token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 with token %q, got %d", token, rec.Code)
+		}
+	}
+}
+
+func TestAdminAPI_ListAuthContextsMasksIdentities(t *testing.T) {
+	addTestAuthContext(t)
+	router := NewRouter(testToken)
+
+	rec := doRequest(router, http.MethodGet, apiPrefix+"/auth-contexts", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, secret := range []string{testSuci, testSupi, "kausf-secret", "xres-star-secret"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("response exposes %q: %s", secret, body)
+		}
+	}
+	var summaries []AuthContextSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summaries); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("expected 1 auth context, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.Supi != "imsi-20893********01" {
+		t.Errorf("unexpected masked SUPI %s", summary.Supi)
+	}
+	if summary.Ref != ref(testSuci) || summary.AuthType != models.AUTHTYPE__5_G_AKA || summary.AuthResult != models.AUTHRESULT_AUTHENTICATION_ONGOING {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestRef_IsNotAnUnkeyedHashOfTheIdentity(t *testing.T) {
+	sum := sha256.Sum256([]byte(testSuci))
+	if unkeyed := hex.EncodeToString(sum[:8]); ref(testSuci) == unkeyed || ref(testSuci) != ref(testSuci) {
+		t.Errorf("expected a stable keyed ref, got %s", ref(testSuci))
+	}
+}
+
+func TestAdminAPI_GetAuthContextByRef(t *testing.T) {
+	addTestAuthContext(t)
+	router := NewRouter(testToken)
+
+	rec := doRequest(router, http.MethodGet, apiPrefix+"/auth-contexts/"+ref(testSuci), testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail AuthContextDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if detail.UdmUeauUrl != "https://udm:29503" || detail.ServingNetworkName != "5G:mnc093.mcc208.3gppnetwork.org" {
+		t.Errorf("unexpected detail %+v", detail)
+	}
+
+	rec = doRequest(router, http.MethodGet, apiPrefix+"/auth-contexts/unknown", testToken)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown context, got %d", rec.Code)
+	}
+}
+
+func TestAdminAPI_DeleteAuthContext(t *testing.T) {
+	addTestAuthContext(t)
+	router := NewRouter(testToken)
+
+	rec := doRequest(router, http.MethodDelete, apiPrefix+"/auth-contexts/"+testSuci, testToken)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ausfContext.CheckIfSuciSupiPairExists(testSuci) || ausfContext.CheckIfAusfUeContextExists(testSupi) {
+		t.Error("expected the auth context and UE context to be removed")
+	}
+
+	rec = doRequest(router, http.MethodDelete, apiPrefix+"/auth-contexts/"+testSuci, testToken)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 on a second delete, got %d", rec.Code)
+	}
+}
+
+func TestAdminAPI_NrfSubscriptions(t *testing.T) {
+	self := ausfContext.GetSelf()
+	self.NfStatusSubscriptions.Store("udm-instance-1", "subscription-1")
+	defer self.NfStatusSubscriptions.Delete("udm-instance-1")
+
+	rec := doRequest(NewRouter(testToken), http.MethodGet, apiPrefix+"/nrf-subscriptions", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var subscriptions map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &subscriptions); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if subscriptions["udm-instance-1"] != "subscription-1" {
+		t.Errorf("unexpected subscriptions %v", subscriptions)
+	}
+}
+
+func TestAdminAPI_UdmCache(t *testing.T) {
+	self := ausfContext.GetSelf()
+	self.UdmUeauUrl = "https://udm:29503"
+	defer func() { self.UdmUeauUrl = "" }()
+	router := NewRouter(testToken)
+
+	rec := doRequest(router, http.MethodGet, apiPrefix+"/udm-cache", testToken)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "https://udm:29503") {
+		t.Fatalf("expected the cached UDM URL, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(router, http.MethodDelete, apiPrefix+"/udm-cache", testToken)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if self.UdmUeauUrl != "" {
+		t.Errorf("expected the cached UDM URL to be cleared, got %s", self.UdmUeauUrl)
+	}
+}
+
+func TestAdminAPI_LogLevels(t *testing.T) {
+	router := NewRouter(testToken)
+	defer func() {
+		if err := logger.ResetCategoryLevel("EapAkaAuth"); err != nil {
+			t.Errorf("failed to reset the log level: %v", err)
+		}
+	}()
+
+	put := func(category, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, apiPrefix+"/log-levels/"+category, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := put("EapAkaAuth", `{"level":"debug","ttl":"15m"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var level LogLevel
+	if err := json.Unmarshal(rec.Body.Bytes(), &level); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if level.Level != "debug" || !level.Overridden || level.ExpiresAt == nil {
+		t.Errorf("unexpected level %+v", level)
+	}
+
+	rec = doRequest(router, http.MethodGet, apiPrefix+"/log-levels", testToken)
+	var levels map[string]LogLevel
+	if err := json.Unmarshal(rec.Body.Bytes(), &levels); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if levels["EapAkaAuth"].Level != "debug" || levels["5gAkaAuth"].Overridden {
+		t.Errorf("expected only EapAkaAuth to be overridden, got %+v", levels)
+	}
+
+	for body, expected := range map[string]int{
+		`{"level":"verbose"}`:           http.StatusBadRequest,
+		`{"level":"debug","ttl":"-1m"}`: http.StatusBadRequest,
+		`{"ttl":"1m"}`:                  http.StatusBadRequest,
+	} {
+		if rec := put("EapAkaAuth", body); rec.Code != expected {
+			t.Errorf("expected %d for %s, got %d", expected, body, rec.Code)
+		}
+	}
+	if rec := put("Unknown", `{"level":"debug"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown category, got %d", rec.Code)
+	}
+
+	rec = doRequest(router, http.MethodDelete, apiPrefix+"/log-levels/EapAkaAuth", testToken)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	rec = doRequest(router, http.MethodGet, apiPrefix+"/log-levels/EapAkaAuth", testToken)
+	var reverted LogLevel
+	if err := json.Unmarshal(rec.Body.Bytes(), &reverted); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if reverted.Overridden || reverted.ExpiresAt != nil {
+		t.Errorf("expected the override to be removed, got %+v", reverted)
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(testToken+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	if token, err := loadToken(&factory.Admin{TokenFile: tokenFile}); err != nil || token != testToken {
+		t.Errorf("expected token from file, got %q, %v", token, err)
+	}
+	if _, err := loadToken(&factory.Admin{}); err == nil {
+		t.Error("expected an error without token or tokenFile")
+	}
+}
+
+func TestMaskSupi(t *testing.T) {
+	testCases := map[string]string{
+		"imsi-208930000000001":              "imsi-20893********01",
+		"suci-0-208-93-0000-0-0-0000000001": "suci-0-208-93-0000-0-0-********01",
+		"nai-user@example.com":              "nai-**************om",
+		"ab":                                "**",
+		"":                                  "",
+	}
+	for id, expected := range testCases {
+		if got := ausfContext.MaskSupi(id); got != expected {
+			t.Errorf("MaskSupi(%q) = %q, expected %q", id, got, expected)
+		}
+	}
+}
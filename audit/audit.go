@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Audit package keeps a tamper-evident record of the authentication decisions of
+ * the AUSF. Every record carries the keyed hash (HMAC) of the previous one, so that
+ * a modified, inserted or removed record breaks the chain when it is verified, and
+ * the chain cannot be rewritten without the key, which is kept outside the audit file.
+ */
+
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	ausf_context "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+)
+
+type Action string
+
+const (
+	ActionAuthenticate     Action = "AUTHENTICATE"       // authentication request from the AMF
+	ActionConfirm          Action = "CONFIRM"            // 5G AKA or EAP-AKA' confirmation
+	ActionDeleteAuthResult Action = "DELETE_AUTH_RESULT" // authentication result deleted by the AMF
+	ActionDeregister       Action = "DEREGISTER"         // authentication contexts of a SUPI removed
+)
+
+type Result string
+
+const (
+	ResultChallenge Result = "CHALLENGE" // authentication vector sent to the AMF
+	ResultSuccess   Result = "SUCCESS"
+	ResultFailure   Result = "FAILURE"
+)
+
+// MinKeySize is the minimum size of the key the records are hashed with
+const MinKeySize = 32
+
+// GenesisHash is the previous hash of the first record of a chain
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Event is an authentication decision
+type Event struct {
+	Action             Action `json:"action"`
+	Result             Result `json:"result"`
+	Cause              string `json:"cause,omitempty"`
+	Supi               string `json:"supi,omitempty"`
+	AuthCtxID          string `json:"authCtxId,omitempty"` // SUCI or SUPI the AMF identified the UE with
+	ServingNetworkName string `json:"servingNetworkName,omitempty"`
+	AuthType           string `json:"authType,omitempty"`
+	RequestID          string `json:"requestId,omitempty"`
+}
+
+// Record is an event in the audit chain
+type Record struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Event
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// computeHash returns the HMAC-SHA256 of the record under key, covering every field
+// but the hash itself
+func (r Record) computeHash(key []byte) string {
+	r.Hash = ""
+	b, err := json.Marshal(r)
+	if err != nil {
+		// Record only holds strings, numbers and a time, which always marshal
+		panic(err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LoadKey reads the key the records are hashed with
+func LoadKey(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read audit key: %w", err)
+	}
+	key := []byte(strings.TrimSpace(string(content)))
+	if len(key) < MinKeySize {
+		return nil, fmt.Errorf("audit key %s must hold at least %d bytes", path, MinKeySize)
+	}
+	return key, nil
+}
+
+// sink stores the encoded records
+type sink interface {
+	write(line []byte) error
+	close() error
+}
+
+// Log appends events to a hash chain and writes them to a sink
+type Log struct {
+	mu             sync.Mutex
+	sink           sink
+	key            []byte
+	seq            uint64
+	prevHash       string
+	maskIdentities bool
+	now            func() time.Time
+}
+
+// Open opens the audit log configured by cfg. A file log continues the chain of the
+// records already in the file, so that a restart does not start a new chain.
+func Open(cfg *factory.Audit) (*Log, error) {
+	key, err := LoadKey(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{
+		key:            key,
+		prevHash:       GenesisHash,
+		maskIdentities: cfg.MaskIdentities,
+		now:            time.Now,
+	}
+	switch cfg.Output {
+	case "", factory.AUDIT_OUTPUT_FILE:
+		s, last, err := openFileSink(cfg.Path, cfg.MaxSizeMB, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.prevHash = last.Seq, last.Hash
+		}
+		l.sink = s
+	case factory.AUDIT_OUTPUT_SYSLOG:
+		s, err := openSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddress)
+		if err != nil {
+			return nil, err
+		}
+		l.sink = s
+	default:
+		return nil, fmt.Errorf("unsupported audit output %q", cfg.Output)
+	}
+	return l, nil
+}
+
+// Write appends an event to the chain
+func (l *Log) Write(ev Event) error {
+	if l.maskIdentities {
+		ev.Supi = ausf_context.MaskSupi(ev.Supi)
+		ev.AuthCtxID = ausf_context.MaskSupi(ev.AuthCtxID)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := Record{
+		Seq:      l.seq + 1,
+		Time:     l.now().UTC(),
+		Event:    ev,
+		PrevHash: l.prevHash,
+	}
+	r.Hash = r.computeHash(l.key)
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := l.sink.write(line); err != nil {
+		return err
+	}
+	l.seq, l.prevHash = r.Seq, r.Hash
+	return nil
+}
+
+// Close flushes and closes the sink
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sink.close()
+}
+
+var (
+	auditMu  sync.Mutex
+	auditLog *Log
+)
+
+// Init opens the configured audit log; auditing stays disabled when cfg is nil
+func Init(cfg *factory.Audit) error {
+	if cfg == nil {
+		return nil
+	}
+	l, err := Open(cfg)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	auditMu.Lock()
+	previous := auditLog
+	auditLog = l
+	auditMu.Unlock()
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			logger.AuditLog.Warnf("closing the previous audit log failed: %v", err)
+		}
+	}
+	logger.AuditLog.Infof("audit log enabled, continuing at record %d", l.seq+1)
+	return nil
+}
+
+// Emit adds an authentication decision to the audit log, tagged with the ID of the
+// request in ctx. It does nothing when auditing is disabled.
+func Emit(ctx context.Context, ev Event) {
+	auditMu.Lock()
+	l := auditLog
+	auditMu.Unlock()
+	if l == nil {
+		return
+	}
+	if ev.RequestID == "" {
+		ev.RequestID = logger.RequestID(ctx)
+	}
+	if err := l.Write(ev); err != nil {
+		logger.AuditLog.Errorf("failed to write %s audit record: %v", ev.Action, err)
+	}
+}
+
+// Close closes the audit log and disables auditing
+func Close() error {
+	auditMu.Lock()
+	l := auditLog
+	auditLog = nil
+	auditMu.Unlock()
+	if l == nil {
+		return nil
+	}
+	return l.Close()
+}
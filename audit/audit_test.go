@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF audit log
+ */
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func keyFileForTest(t *testing.T) string {
+	t.Helper()
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	if err := os.WriteFile(keyFile, append(testKey, '\n'), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return keyFile
+}
+
+func testEvent(i int) Event {
+	return Event{
+		Action:             ActionConfirm,
+		Result:             ResultSuccess,
+		Supi:               fmt.Sprintf("imsi-0010100000000%02d", i),
+		AuthCtxID:          fmt.Sprintf("suci-0-001-01-0000-0-0-00000000%02d", i),
+		ServingNetworkName: "5G:mnc001.mcc001.3gppnetwork.org",
+		AuthType:           "5G_AKA",
+	}
+}
+
+func writeEvents(t *testing.T, l *Log, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		if err := l.Write(testEvent(i)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+}
+
+func verifyFiles(verifier *Verifier, files ...string) (*Verifier, error) {
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifier.Verify(file, bytes.NewReader(content)); err != nil {
+			return verifier, err
+		}
+	}
+	return verifier, nil
+}
+
+/*
+ * Audit Log Unit Tests
+ */
+
+func TestLog_ContinuesTheChainAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := &factory.Audit{Path: path, KeyFile: keyFileForTest(t)}
+
+	l, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	writeEvents(t, l, 1, 3)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	l, err = Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	writeEvents(t, l, 4, 5)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	verifier, err := verifyFiles(&Verifier{Key: testKey}, path)
+	if err != nil {
+		t.Fatalf("expected an intact chain: %v", err)
+	}
+	if verifier.Records != 5 || verifier.Chains != 1 {
+		t.Errorf("expected 5 records in 1 chain, got %d in %d", verifier.Records, verifier.Chains)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the audit file to be readable by the owner only, got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(&factory.Audit{Path: path, KeyFile: keyFileForTest(t)})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	writeEvents(t, l, 1, 4)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(content), "\n"), "\n")
+
+	testCases := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		expected string
+	}{
+		{"modified result", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"result":"SUCCESS"`, `"result":"FAILURE"`, 1)
+			return lines
+		}, "audit.log:2: record 2 does not match its hash"},
+		{"removed record", func(lines []string) []string {
+			return append(lines[:2], lines[3:]...)
+		}, "audit.log:3: record 4 does not follow record 2"},
+		{"swapped records", func(lines []string) []string {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}, "audit.log:2: record 3 does not follow record 1"},
+		{"added field", func(lines []string) []string {
+			lines[2] = strings.Replace(lines[2], `{"seq"`, `{"note":"ok","seq"`, 1)
+			return lines
+		}, "audit.log:3: record 3 is not in canonical form"},
+		{"removed head", func(lines []string) []string {
+			return lines[1:]
+		}, "audit.log:1: record 2 is not the start of the chain"},
+		{"spliced chain", func(lines []string) []string {
+			return append(lines[:2], lines...)
+		}, "audit.log:3: record 1 starts a new chain after record 2"},
+		{"rewritten with another key", func(lines []string) []string {
+			var r Record
+			if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+				t.Fatalf("invalid record: %v", err)
+			}
+			r.Result = ResultFailure
+			r.Hash = r.computeHash([]byte("fedcba9876543210fedcba9876543210"))
+			line, _ := json.Marshal(r)
+			lines[0] = string(line) + "\n"
+			return lines
+		}, "audit.log:1: record 1 does not match its hash"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := tc.tamper(append([]string(nil), lines...))
+			verifier := &Verifier{Key: testKey}
+			err := verifier.Verify("audit.log", strings.NewReader(strings.Join(tampered, "")))
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expected) {
+				t.Errorf("expected %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestFileSink_RotatesAndKeepsTheChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s, _, err := openFileSink(path, 1, 2)
+	if err != nil {
+		t.Fatalf("openFileSink: %v", err)
+	}
+	s.maxSize = 800 // a few records per file
+	l := &Log{sink: s, key: testKey, prevHash: GenesisHash, now: time.Now}
+	writeEvents(t, l, 1, 20)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := Files(path)
+	expectedFiles := []string{path + ".2", path + ".1", path}
+	if strings.Join(files, ",") != strings.Join(expectedFiles, ",") {
+		t.Fatalf("expected files %v, got %v", expectedFiles, files)
+	}
+	if _, err := verifyFiles(&Verifier{Key: testKey}, files...); err == nil {
+		t.Fatal("expected the rotated away start of the chain to be reported")
+	}
+	verifier, err := verifyFiles(&Verifier{Key: testKey, AllowRotatedStart: true}, files...)
+	if err != nil {
+		t.Fatalf("expected an intact chain across the rotated files: %v", err)
+	}
+	if verifier.Records >= 20 || verifier.Chains != 1 {
+		t.Errorf("expected the oldest records to be rotated away from a single chain, got %d records in %d chains",
+			verifier.Records, verifier.Chains)
+	}
+	if _, err := verifyFiles(&Verifier{Key: testKey, AllowRotatedStart: true}, path, path+".1"); err == nil {
+		t.Error("expected files verified out of order to break the chain")
+	}
+
+	// After a restart the chain continues from the newest file
+	last, err := lastRecord(path)
+	if err != nil || last == nil || last.Seq != 20 {
+		t.Fatalf("expected record 20 to be the last one, got %+v (%v)", last, err)
+	}
+}
+
+func TestLog_MaskIdentities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(&factory.Audit{Path: path, KeyFile: keyFileForTest(t), MaskIdentities: true})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	writeEvents(t, l, 1, 1)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	last, err := lastRecord(path)
+	if err != nil {
+		t.Fatalf("lastRecord: %v", err)
+	}
+	if last.Supi != "imsi-00101********01" || last.AuthCtxID != "suci-0-001-01-0000-0-0-********01" {
+		t.Errorf("expected masked identities, got %q and %q", last.Supi, last.AuthCtxID)
+	}
+}
+
+func TestLog_WritesToSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer conn.Close()
+
+	l, err := Open(&factory.Audit{
+		Output:        factory.AUDIT_OUTPUT_SYSLOG,
+		SyslogNetwork: "udp",
+		SyslogAddress: conn.LocalAddr().String(),
+		KeyFile:       keyFileForTest(t),
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+	writeEvents(t, l, 1, 2)
+
+	var received bytes.Buffer
+	buf := make([]byte, 4096)
+	for range 2 {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("SetReadDeadline: %v", err)
+		}
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom: %v", err)
+		}
+		received.Write(bytes.TrimRight(buf[:n], "\n"))
+		received.WriteByte('\n')
+	}
+	if !strings.Contains(received.String(), "ausf-audit") {
+		t.Errorf("expected the ausf-audit tag, got %q", received.String())
+	}
+	verifier := &Verifier{Key: testKey}
+	if err := verifier.Verify("syslog", &received); err != nil || verifier.Records != 2 {
+		t.Errorf("expected 2 verified records from syslog, got %d: %v", verifier.Records, err)
+	}
+}
+
+func TestVerify_AcceptsSyslogRestartsOnlyWhenAllowed(t *testing.T) {
+	var stream bytes.Buffer
+	for range 2 {
+		l := &Log{sink: &bufferSink{&stream}, key: testKey, prevHash: GenesisHash, now: time.Now}
+		writeEvents(t, l, 1, 2)
+	}
+	if err := (&Verifier{Key: testKey}).Verify("syslog", bytes.NewReader(stream.Bytes())); err == nil {
+		t.Error("expected a restarted chain to be reported")
+	}
+	verifier := &Verifier{Key: testKey, AllowRestarts: true}
+	if err := verifier.Verify("syslog", bytes.NewReader(stream.Bytes())); err != nil || verifier.Chains != 2 {
+		t.Errorf("expected 2 chains, got %d: %v", verifier.Chains, err)
+	}
+}
+
+func TestLoadKey_RejectsShortKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	if err := os.WriteFile(keyFile, []byte("short\n"), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if _, err := LoadKey(keyFile); err == nil {
+		t.Error("expected a short key to be refused")
+	}
+}
+
+type bufferSink struct {
+	buf *bytes.Buffer
+}
+
+func (s *bufferSink) write(line []byte) error {
+	s.buf.Write(append(line, '\n'))
+	return nil
+}
+
+func (s *bufferSink) close() error {
+	return nil
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/syslog"
+	"os"
+)
+
+const (
+	DefaultMaxSizeMB  = 100
+	DefaultMaxBackups = 5
+)
+
+// fileSink writes one record per line and rotates the file once it reaches maxSize:
+// path.1 is the most recent rotated file and path.<maxBackups> the oldest kept.
+type fileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openFileSink opens the audit file for appending and returns the last record written
+// to it, or to the most recent rotated file when it is empty
+func openFileSink(path string, maxSizeMB, maxBackups int) (*fileSink, *Record, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = DefaultMaxSizeMB
+	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	last, err := lastRecord(path)
+	if err == nil && last == nil {
+		last, err = lastRecord(backupPath(path, 1))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &fileSink{path: path, maxSize: int64(maxSizeMB) << 20, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, nil, err
+	}
+	return s, last, nil
+}
+
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+func (s *fileSink) write(line []byte) error {
+	line = append(line, '\n')
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("rotate %s: %w", s.path, err)
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(s.path, i), backupPath(s.path, i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, backupPath(s.path, 1)); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) close() error {
+	return s.file.Close()
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Files returns the rotated files of the audit file at path, oldest first, followed
+// by the file itself: the order in which the chain is verified
+func Files(path string) []string {
+	var files []string
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backupPath(path, i)); err != nil {
+			break
+		}
+		files = append([]string{backupPath(path, i)}, files...)
+	}
+	return append(files, path)
+}
+
+// lastRecord returns the last record of an audit file, or nil when the file is
+// missing or empty
+func lastRecord(path string) (*Record, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimRight(content, "\n")
+	if len(content) == 0 {
+		return nil, nil
+	}
+	line := content[bytes.LastIndexByte(content, '\n')+1:]
+	var r Record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return nil, fmt.Errorf("%s ends with an invalid audit record: %w", path, err)
+	}
+	return &r, nil
+}
+
+// syslogSink sends every record as a message of the authpriv facility
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func openSyslogSink(network, address string) (*syslogSink, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_AUTHPRIV|syslog.LOG_INFO, "ausf-audit")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) write(line []byte) error {
+	return s.writer.Info(string(line))
+}
+
+func (s *syslogSink) close() error {
+	return s.writer.Close()
+}
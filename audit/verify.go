@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Verifier checks the hash chain of audit records read from one or more sources,
+// given in the order they were written
+type Verifier struct {
+	// Key is the key the records were hashed with
+	Key []byte
+	// AllowRotatedStart accepts a chain whose first records were rotated away, that is
+	// whose first record verified is not the first record of the chain
+	AllowRotatedStart bool
+	// AllowRestarts accepts new chains after the first one, as written to syslog where
+	// every start of the AUSF begins a new chain
+	AllowRestarts bool
+	// Records is the number of records verified so far
+	Records int
+	// Chains is the number of chains seen
+	Chains int
+	last   *Record
+}
+
+// Verify checks the records read from r, where name identifies r in errors. Lines
+// that carry a prefix before the record, as added by syslog, are accepted.
+func (v *Verifier) Verify(name string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+		start := bytes.IndexByte(line, '{')
+		if start < 0 {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			return fmt.Errorf("%s:%d: not an audit record", name, lineNo)
+		}
+		if err := v.verifyRecord(line[start:]); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (v *Verifier) verifyRecord(line []byte) error {
+	var r Record
+	if err := json.Unmarshal(line, &r); err != nil {
+		return fmt.Errorf("invalid audit record: %w", err)
+	}
+	// Fields unknown to Record would not be covered by the hash
+	canonical, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonical, bytes.TrimRight(line, "\r ")) {
+		return fmt.Errorf("record %d is not in canonical form", r.Seq)
+	}
+	if !hmac.Equal([]byte(r.Hash), []byte(r.computeHash(v.Key))) {
+		return fmt.Errorf("record %d does not match its hash", r.Seq)
+	}
+
+	genesis := r.Seq == 1 && r.PrevHash == GenesisHash
+	switch {
+	case v.last != nil && r.PrevHash == v.last.Hash && r.Seq == v.last.Seq+1:
+	case genesis && (v.last == nil || v.AllowRestarts):
+		v.Chains++
+	case genesis:
+		return fmt.Errorf("record %d starts a new chain after record %d", r.Seq, v.last.Seq)
+	case v.last == nil && v.AllowRotatedStart:
+		v.Chains++
+	case v.last == nil:
+		return fmt.Errorf("record %d is not the start of the chain: the previous records are missing", r.Seq)
+	case r.PrevHash != v.last.Hash:
+		return fmt.Errorf("record %d does not follow record %d: previous hash mismatch", r.Seq, v.last.Seq)
+	default:
+		return fmt.Errorf("record %d follows record %d: sequence gap", r.Seq, v.last.Seq)
+	}
+	v.last = &r
+	v.Records++
+	return nil
+}
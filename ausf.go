@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/omec-project/ausf/audit"
 	"github.com/omec-project/ausf/logger"
 	"github.com/omec-project/ausf/service"
 	"github.com/urfave/cli/v3"
@@ -31,21 +32,83 @@ func main() {
 	app.Name = "ausf"
 	logger.AppLog.Infoln(app.Name)
 	app.Usage = "Authentication Server Function"
-	app.UsageText = "ausf -cfg <ausf_config_file.conf>"
+	app.UsageText = "ausf -cfg <ausf_config_file.conf> [--validate-config]\n" +
+		"ausf verify-audit --key-file <audit key file> <audit file>..."
 	app.Action = action
 	app.Flags = AUSF.GetCliCmd()
+	app.Commands = []*cli.Command{
+		{
+			Name:      "verify-audit",
+			Usage:     "verify the hash chain of audit files and of their rotated files",
+			ArgsUsage: "<audit file>...",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "key-file", Usage: "key the records are hashed with", Required: true},
+				&cli.BoolFlag{Name: "allow-rotated-start", Usage: "accept a chain whose oldest rotated files were removed"},
+				&cli.BoolFlag{Name: "allow-restarts", Usage: "accept a new chain at every start, as written to syslog"},
+			},
+			Action: verifyAudit,
+		},
+	}
 	if err := app.Run(context.Background(), os.Args); err != nil {
 		logger.AppLog.Fatalf("AUSF run error: %v", err)
 	}
 }
 
 func action(ctx context.Context, c *cli.Command) error {
+	if c.String("cfg") == "" {
+		return fmt.Errorf("required flag \"cfg\" not set")
+	}
+
+	if c.Bool("validate-config") {
+		if err := AUSF.ValidateConfig(c); err != nil {
+			logger.CfgLog.Errorf("%+v", err)
+			return fmt.Errorf("invalid configuration")
+		}
+		return nil
+	}
+
 	if err := AUSF.Initialize(c); err != nil {
 		logger.CfgLog.Errorf("%+v", err)
 		return fmt.Errorf("failed to initialize")
 	}
 
-	AUSF.Start()
+	if err := AUSF.Start(); err != nil {
+		logger.InitLog.Errorf("%+v", err)
+		return fmt.Errorf("AUSF stopped with an error")
+	}
+
+	return nil
+}
 
+func verifyAudit(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() == 0 {
+		return fmt.Errorf("no audit file given")
+	}
+	key, err := audit.LoadKey(c.String("key-file"))
+	if err != nil {
+		return err
+	}
+	verifier := audit.Verifier{
+		Key:               key,
+		AllowRotatedStart: c.Bool("allow-rotated-start"),
+		AllowRestarts:     c.Bool("allow-restarts"),
+	}
+	for _, path := range c.Args().Slice() {
+		for _, file := range audit.Files(path) {
+			if err := verifyAuditFile(&verifier, file); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Fprintf(c.Root().Writer, "audit chain intact: %d records in %d chain(s)\n", verifier.Records, verifier.Chains)
 	return nil
 }
+
+func verifyAuditFile(verifier *audit.Verifier, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verifier.Verify(file, f)
+}
@@ -133,7 +133,7 @@ func TestGetUDMUri(t *testing.T) {
 		t.Run(fmt.Sprintf("NRF caching is [%v]", parameters[i].inputEnableNrfCaching), func(t *testing.T) {
 			ausfContext.GetSelf().UdmUeauUrl = ""
 			ausfContext.GetSelf().EnableNrfCaching = parameters[i].inputEnableNrfCaching
-			udm_uri := producer.GetUdmUrl(ausfContext.GetSelf().NrfUri)
+			udm_uri := producer.GetUdmUrl(context.Background(), ausfContext.GetSelf().NrfUri)
 			if callCountSearchNFInstances != parameters[i].expectedCallCountSearchNFInstances {
 				t.Errorf("NF instance search count mismatch. got = %d, want = %d (NF instance is searched in the cache)",
 					callCountSearchNFInstances, parameters[i].expectedCallCountSearchNFInstances)
@@ -160,7 +160,7 @@ func TestGetUDMUri_SkipsInstancesWithoutUsableEndpoints(t *testing.T) {
 		consumer.SendNfDiscoveryToNrf = origSendNfDiscoveryToNrf
 	}()
 
-	consumer.SendSearchNFInstances = func(nrfUri string, targetNfType, requestNfType models.NFType,
+	consumer.SendSearchNFInstances = func(ctx context.Context, nrfUri string, targetNfType, requestNfType models.NFType,
 		configure consumer.SearchNFInstancesRequestConfigurer,
 	) (*models.SearchResult, error) {
 		invalidProfile := models.NFProfileDiscovery{
@@ -195,11 +195,60 @@ func TestGetUDMUri_SkipsInstancesWithoutUsableEndpoints(t *testing.T) {
 	}
 
 	ausfContext.GetSelf().UdmUeauUrl = ""
-	if got := producer.GetUdmUrl(ausfContext.GetSelf().NrfUri); got != "https://20.20.13.1:8090" {
+	if got := producer.GetUdmUrl(context.Background(), ausfContext.GetSelf().NrfUri); got != "https://20.20.13.1:8090" {
 		t.Fatalf("unexpected UDM URL: got %q want %q", got, "https://20.20.13.1:8090")
 	}
 }
 
+func TestGetUDMUri_RetriesWithAlternateNrfs(t *testing.T) {
+	origSendSearchNFInstances := consumer.SendSearchNFInstances
+	origSendNfDiscoveryToNrf := consumer.SendNfDiscoveryToNrf
+	self := ausfContext.GetSelf()
+	origNrfUris := self.NrfUris
+	defer func() {
+		consumer.SendSearchNFInstances = origSendSearchNFInstances
+		consumer.SendNfDiscoveryToNrf = origSendNfDiscoveryToNrf
+		self.NrfUris = origNrfUris
+	}()
+	self.NrfUris = []string{"https://nrf-1:29510", "https://nrf-2:29510", "https://nrf-3:29510"}
+
+	var searched []string
+	search := func(ctx context.Context, nrfUri string, targetNfType, requestNfType models.NFType,
+		configure consumer.SearchNFInstancesRequestConfigurer,
+	) (*models.SearchResult, error) {
+		searched = append(searched, nrfUri)
+		if nrfUri != "https://nrf-3:29510" {
+			return nil, errors.New("connection refused")
+		}
+		udmProfile := models.NFProfileDiscovery{
+			NfInstanceId: "udm-instance",
+			NfType:       models.NFTYPE_UDM,
+			NfStatus:     models.NFSTATUS_REGISTERED,
+			NfServices: []models.NFService{{
+				ServiceName: models.SERVICENAME_NUDM_UEAU,
+				Scheme:      models.URISCHEME_HTTPS,
+				IpEndPoints: []models.IpEndPoint{{
+					Ipv4Address: openapi.PtrString("20.20.13.3"),
+					Port:        openapi.PtrInt32(8090),
+				}},
+			}},
+		}
+		return models.NewSearchResult(2, []models.NFProfileDiscovery{udmProfile}), nil
+	}
+	consumer.SendSearchNFInstances = search
+	consumer.SendNfDiscoveryToNrf = search
+
+	self.UdmUeauUrl = ""
+	if got := producer.GetUdmUrl(context.Background(), "https://nrf-2:29510"); got != "https://20.20.13.3:8090" {
+		t.Fatalf("unexpected UDM URL: got %q want %q", got, "https://20.20.13.3:8090")
+	}
+	expectedSearches := []string{"https://nrf-2:29510", "https://nrf-2:29510", "https://nrf-3:29510"}
+	if !reflect.DeepEqual(searched, expectedSearches) {
+		t.Errorf("expected the UDM to be searched at %v, got %v", expectedSearches, searched)
+	}
+	self.UdmUeauUrl = ""
+}
+
 func TestCreateSubscriptionSuccess(t *testing.T) {
 	t.Logf("test cases for CreateSubscription")
 	udmProfile := models.NFProfileDiscovery{
@@ -547,13 +596,13 @@ func TestNfSubscriptionStatusNotify(t *testing.T) {
 		{
 			nil,
 			"Notification event type REGISTERED NRF caching is enabled",
-			"NF profile is not removed from cache and subscription is not removed",
+			"NF profile removed from cache and subscription is not removed",
 			nfInstanceID,
 			nfInstanceID,
 			subscriptionID,
 			"NF_REGISTERED",
 			0,
-			0,
+			1,
 			true,
 			false,
 		},
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package consumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/metrics"
+)
+
+// discoveryCache keeps the NF discovery results of the NRF for their validityPeriod. The
+// results are cached per request URL, that is per NRF and set of query parameters.
+type discoveryCache struct {
+	mu         sync.Mutex
+	entries    map[string]*discoveryEntry
+	disabled   bool
+	maxTTL     time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type discoveryEntry struct {
+	header        http.Header
+	body          []byte
+	expires       time.Time
+	nfInstanceIds []string
+}
+
+func newDiscoveryCache(cfg *factory.DiscoveryCache) *discoveryCache {
+	c := &discoveryCache{
+		entries:    make(map[string]*discoveryEntry),
+		maxTTL:     factory.DEFAULT_DISCOVERY_CACHE_MAX_TTL * time.Second,
+		maxEntries: factory.DEFAULT_DISCOVERY_CACHE_MAX_ENTRIES,
+		disabled:   cfg == nil || !cfg.Enabled,
+		now:        time.Now,
+	}
+	if cfg != nil {
+		if cfg.MaxTTL > 0 {
+			c.maxTTL = time.Duration(cfg.MaxTTL) * time.Second
+		}
+		if cfg.MaxEntries > 0 {
+			c.maxEntries = cfg.MaxEntries
+		}
+	}
+	return c
+}
+
+// client returns a copy of base answering the discoveries from the cache
+func (c *discoveryCache) client(base *http.Client) *http.Client {
+	client := *base
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &cachingTransport{cache: c, next: next}
+	return &client
+}
+
+func (c *discoveryCache) lookup(key string) *discoveryEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// store caches a search result for its validityPeriod, bounded by maxTTL; results
+// without a validity period are not cached
+func (c *discoveryCache) store(key string, header http.Header, body []byte) {
+	var result struct {
+		ValidityPeriod int `json:"validityPeriod"`
+		NfInstances    []struct {
+			NfInstanceId string `json:"nfInstanceId"`
+		} `json:"nfInstances"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.ValidityPeriod <= 0 {
+		return
+	}
+	entry := &discoveryEntry{
+		header:  header.Clone(),
+		body:    body,
+		expires: c.now().Add(min(time.Duration(result.ValidityPeriod)*time.Second, c.maxTTL)),
+	}
+	for _, nfInstance := range result.NfInstances {
+		entry.nfInstanceIds = append(entry.nfInstanceIds, nfInstance.NfInstanceId)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = entry
+}
+
+// evictLocked removes the expired entries and, when the cache is still full, the
+// entry that expires first
+func (c *discoveryCache) evictLocked() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	var first string
+	for key, entry := range c.entries {
+		if first == "" || entry.expires.Before(c.entries[first].expires) {
+			first = key
+		}
+	}
+	delete(c.entries, first)
+}
+
+// invalidate removes the results listing an NF instance and returns how many were removed
+func (c *discoveryCache) invalidate(nfInstanceId string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, entry := range c.entries {
+		if slices.Contains(entry.nfInstanceIds, nfInstanceId) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *discoveryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// cachingTransport answers the discovery requests from the cache and caches the
+// successful responses of the NRF
+type cachingTransport struct {
+	cache *discoveryCache
+	next  http.RoundTripper
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cache.disabled || req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	targetNfType := req.URL.Query().Get("target-nf-type")
+	if entry := t.cache.lookup(key); entry != nil {
+		metrics.IncrementDiscoveryCacheStats(targetNfType, true)
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+	metrics.IncrementDiscoveryCacheStats(targetNfType, false)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.cache.store(key, resp.Header, body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+var nfDiscoveryCache = newDiscoveryCache(nil)
+
+// InitDiscoveryCache applies the discoveryCache section of the configuration
+func InitDiscoveryCache(cfg *factory.DiscoveryCache) {
+	nfDiscoveryCache = newDiscoveryCache(cfg)
+	if nfDiscoveryCache.disabled {
+		logger.InitLog.Infoln("NF discovery cache disabled")
+		return
+	}
+	logger.InitLog.Infof("NF discovery results cached for their validity period, at most %v and %d results",
+		nfDiscoveryCache.maxTTL, nfDiscoveryCache.maxEntries)
+}
+
+// InvalidateDiscoveryCache forgets the discovery results listing an NF instance, so that
+// the next discovery fetches its current profile from the NRF
+func InvalidateDiscoveryCache(nfInstanceId string) {
+	if removed := nfDiscoveryCache.invalidate(nfInstanceId); removed > 0 {
+		logger.ConsumerLog.Debugf("%d discovery results of NF instance %s invalidated", removed, nfInstanceId)
+	}
+}
+
+// ClearDiscoveryCache forgets all the discovery results
+func ClearDiscoveryCache() {
+	nfDiscoveryCache.clear()
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the NF discovery cache
+ */
+
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/openapi/v2/Nnrf_NFDiscovery"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+func startNrfForTest(t *testing.T, validityPeriod int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var searches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		body := fmt.Sprintf(`{"validityPeriod":%d,"nfInstances":[{"nfInstanceId":"udm-1","nfType":"UDM","nfStatus":"REGISTERED"}]}`,
+			validityPeriod)
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &searches
+}
+
+func useDiscoveryCacheForTest(t *testing.T, cfg *factory.DiscoveryCache) *discoveryCache {
+	t.Helper()
+	originalCache := nfDiscoveryCache
+	originalCreateSubscription := CreateSubscription
+	nfDiscoveryCache = newDiscoveryCache(cfg)
+	CreateSubscription = func(string, models.SubscriptionData) (*models.SubscriptionData, *models.ProblemDetails, error) {
+		return models.NewSubscriptionData("http://ausf/nf-status-notify"), nil, nil
+	}
+	t.Cleanup(func() {
+		nfDiscoveryCache = originalCache
+		CreateSubscription = originalCreateSubscription
+	})
+	return nfDiscoveryCache
+}
+
+func searchServices(serviceNames ...models.ServiceName) SearchNFInstancesRequestConfigurer {
+	return func(request Nnrf_NFDiscovery.ApiSearchNFInstancesRequest) Nnrf_NFDiscovery.ApiSearchNFInstancesRequest {
+		return request.ServiceNames(serviceNames)
+	}
+}
+
+/*
+ * Discovery Cache Unit Tests
+ */
+
+func TestDiscoveryCache_AnswersTheSameQueryUntilTheValidityPeriodExpires(t *testing.T) {
+	cache := useDiscoveryCacheForTest(t, &factory.DiscoveryCache{Enabled: true})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	nrf, searches := startNrfForTest(t, 60)
+	discover := func(configure SearchNFInstancesRequestConfigurer) {
+		t.Helper()
+		result, err := SendNfDiscoveryToNrf(context.Background(), nrf.URL, models.NFTYPE_UDM, models.NFTYPE_AUSF, configure)
+		if err != nil || result == nil || len(result.NfInstances) != 1 {
+			t.Fatalf("expected the UDM to be discovered, got %+v (%v)", result, err)
+		}
+	}
+
+	discover(searchServices(models.SERVICENAME_NUDM_UEAU))
+	discover(searchServices(models.SERVICENAME_NUDM_UEAU))
+	if searches.Load() != 1 {
+		t.Fatalf("expected the second discovery to be answered from the cache, got %d NRF searches", searches.Load())
+	}
+
+	discover(searchServices(models.SERVICENAME_NUDM_SDM))
+	if searches.Load() != 2 {
+		t.Fatalf("expected a query with other parameters to reach the NRF, got %d NRF searches", searches.Load())
+	}
+
+	now = now.Add(61 * time.Second)
+	discover(searchServices(models.SERVICENAME_NUDM_UEAU))
+	if searches.Load() != 3 {
+		t.Errorf("expected an expired result to be discovered again, got %d NRF searches", searches.Load())
+	}
+}
+
+func TestDiscoveryCache_MaxTTLBoundsTheValidityPeriod(t *testing.T) {
+	cache := useDiscoveryCacheForTest(t, &factory.DiscoveryCache{Enabled: true, MaxTTL: 10})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	nrf, searches := startNrfForTest(t, 3600)
+
+	for range 2 {
+		if _, err := SendNfDiscoveryToNrf(context.Background(), nrf.URL, models.NFTYPE_UDM, models.NFTYPE_AUSF, nil); err != nil {
+			t.Fatalf("SendNfDiscoveryToNrf: %v", err)
+		}
+		now = now.Add(11 * time.Second)
+	}
+	if searches.Load() != 2 {
+		t.Errorf("expected the result to expire after maxTtl, got %d NRF searches", searches.Load())
+	}
+}
+
+func TestDiscoveryCache_InvalidatedByNfInstance(t *testing.T) {
+	useDiscoveryCacheForTest(t, &factory.DiscoveryCache{Enabled: true})
+	nrf, searches := startNrfForTest(t, 60)
+	discover := func() {
+		t.Helper()
+		if _, err := SendNfDiscoveryToNrf(context.Background(), nrf.URL, models.NFTYPE_UDM, models.NFTYPE_AUSF, nil); err != nil {
+			t.Fatalf("SendNfDiscoveryToNrf: %v", err)
+		}
+	}
+
+	discover()
+	InvalidateDiscoveryCache("udm-2")
+	discover()
+	if searches.Load() != 1 {
+		t.Fatalf("expected results without the NF instance to be kept, got %d NRF searches", searches.Load())
+	}
+	InvalidateDiscoveryCache("udm-1")
+	discover()
+	if searches.Load() != 2 {
+		t.Errorf("expected the results listing the NF instance to be invalidated, got %d NRF searches", searches.Load())
+	}
+}
+
+func TestDiscoveryCache_ResultsWithoutValidityPeriodOrWithoutEnabledCacheAreNotCached(t *testing.T) {
+	testCases := []struct {
+		name           string
+		cfg            *factory.DiscoveryCache
+		validityPeriod int
+	}{
+		{"no validity period", &factory.DiscoveryCache{Enabled: true}, 0},
+		{"not configured", nil, 60},
+		{"not enabled", &factory.DiscoveryCache{MaxTTL: 600}, 60},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			useDiscoveryCacheForTest(t, tc.cfg)
+			nrf, searches := startNrfForTest(t, tc.validityPeriod)
+			for range 2 {
+				if _, err := SendNfDiscoveryToNrf(context.Background(), nrf.URL, models.NFTYPE_UDM, models.NFTYPE_AUSF, nil); err != nil {
+					t.Fatalf("SendNfDiscoveryToNrf: %v", err)
+				}
+			}
+			if searches.Load() != 2 {
+				t.Errorf("expected every discovery to reach the NRF, got %d NRF searches", searches.Load())
+			}
+		})
+	}
+}
+
+func TestDiscoveryCache_EvictsTheEntryExpiringFirstWhenFull(t *testing.T) {
+	cache := newDiscoveryCache(&factory.DiscoveryCache{Enabled: true, MaxEntries: 2})
+	cache.store("a", http.Header{}, []byte(`{"validityPeriod":30}`))
+	cache.store("b", http.Header{}, []byte(`{"validityPeriod":10}`))
+	cache.store("c", http.Header{}, []byte(`{"validityPeriod":20}`))
+	if cache.lookup("a") == nil || cache.lookup("b") != nil || cache.lookup("c") == nil {
+		t.Errorf("expected the entry expiring first to be evicted, got %v", cache.entries)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	ausfContext "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/resilience"
 	"github.com/omec-project/openapi/v2"
 	"github.com/omec-project/openapi/v2/Nnrf_NFDiscovery"
 	"github.com/omec-project/openapi/v2/models"
@@ -35,6 +36,7 @@ func newNFDiscoveryClient(nrfUri string) *Nnrf_NFDiscovery.APIClient {
 		apiRootVar.DefaultValue = nrfUri
 		serverConfig.Variables["apiRoot"] = apiRootVar
 	}
+	configuration.HTTPClient = nfDiscoveryCache.client(resilience.HTTPClient())
 	return Nnrf_NFDiscovery.NewAPIClient(configuration)
 }
 
@@ -89,7 +91,7 @@ func executeSearchNFInstancesRequest(
 		}
 		{
 			nrfSubscriptionData := models.SubscriptionData{
-				NfStatusNotificationUri: fmt.Sprintf("%s/nausf-callback/v1/nf-status-notify", ausfSelf.GetIPv4Uri()),
+				NfStatusNotificationUri: fmt.Sprintf("%s/nausf-callback/v1/nf-status-notify", ausfSelf.GetSbiUri()),
 				SubscrCond: &models.SubscrCond{
 					NfInstanceIdCond: &models.NfInstanceIdCond{
 						NfInstanceId: openapi.PtrString(nfInstanceID),
@@ -119,11 +121,10 @@ func executeSearchNFInstancesRequest(
 	return result, returnErr
 }
 
-var SendSearchNFInstances = func(nrfUri string, targetNfType, requestNfType models.NFType,
+var SendSearchNFInstances = func(ctx context.Context, nrfUri string, targetNfType, requestNfType models.NFType,
 	configure SearchNFInstancesRequestConfigurer,
 ) (*models.SearchResult, error) {
-	ctx := context.Background()
-	if ausfContext.GetSelf().EnableNrfCaching {
+	if ausfContext.GetSelf().IsNrfCachingEnabled() {
 		client := newNFDiscoveryClient(nrfUri)
 		request := buildSearchNFInstancesRequest(ctx, client, targetNfType, requestNfType, configure)
 		return NRFCacheSearchNFInstances(ctx, nrfUri, targetNfType, requestNfType, request)
@@ -7,11 +7,17 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/load"
 	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/resilience"
+	"github.com/omec-project/ausf/tracing"
 	"github.com/omec-project/openapi/v2"
 	"github.com/omec-project/openapi/v2/Nnrf_NFManagement"
 	"github.com/omec-project/openapi/v2/models"
@@ -28,6 +34,17 @@ func closeNFManagementResponseBody(res *http.Response, operation string) {
 	}
 }
 
+func newNFManagementClient(nrfUri string) *Nnrf_NFManagement.APIClient {
+	configuration := Nnrf_NFManagement.NewConfiguration()
+	serverConfig := &configuration.Servers[0]
+	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
+		apiRootVar.DefaultValue = nrfUri
+		serverConfig.Variables["apiRoot"] = apiRootVar
+	}
+	configuration.HTTPClient = resilience.HTTPClient()
+	return Nnrf_NFManagement.NewAPIClient(configuration)
+}
+
 func getNfProfile(ausfContext *ausfContext.AUSFContext, plmnConfig []models.PlmnId) (profile models.NFProfile, err error) {
 	if ausfContext == nil {
 		return profile, openapi.ReportError("ausf context has not been initialized. NF profile cannot be built")
@@ -35,7 +52,15 @@ func getNfProfile(ausfContext *ausfContext.AUSFContext, plmnConfig []models.Plmn
 	profile.SetNfInstanceId(ausfContext.NfId)
 	profile.SetNfType(models.NFTYPE_AUSF)
 	profile.SetNfStatus(models.NFSTATUS_REGISTERED)
-	profile.SetIpv4Addresses([]string{ausfContext.RegisterIPv4})
+	if ausfContext.RegisterIPv4 != "" {
+		profile.SetIpv4Addresses([]string{ausfContext.RegisterIPv4})
+	}
+	if ausfContext.RegisterIPv6 != "" {
+		profile.SetIpv6Addresses([]string{ausfContext.RegisterIPv6})
+	}
+	if ausfContext.RegisterFqdn != "" {
+		profile.SetFqdn(ausfContext.RegisterFqdn)
+	}
 	services := []models.NFService{}
 	for _, nfService := range ausfContext.NfService {
 		services = append(services, nfService)
@@ -43,69 +68,92 @@ func getNfProfile(ausfContext *ausfContext.AUSFContext, plmnConfig []models.Plmn
 	if len(services) > 0 {
 		profile.SetNfServices(services)
 	}
-	ausfInfo := models.NewAusfInfo()
-	ausfInfo.SetGroupId(ausfContext.GroupID)
-	profile.SetAusfInfo(*ausfInfo)
+	profile.SetAusfInfo(ausfContext.GetAusfInfo())
 	profile.SetPlmnList(plmnConfig)
+	if ausfContext.Capacity != 0 {
+		profile.SetCapacity(int32(ausfContext.Capacity))
+	}
+	if ausfContext.Priority != 0 {
+		profile.SetPriority(int32(ausfContext.Priority))
+	}
+	if ausfContext.Locality != "" {
+		profile.SetLocality(ausfContext.Locality)
+	}
+	if len(ausfContext.NfSetIdList) > 0 {
+		profile.SetNfSetIdList(ausfContext.NfSetIdList)
+	}
+	profile.SetLoad(load.Current())
+	profile.SetLoadTimeStamp(time.Now().UTC())
 	return profile, err
 }
 
+// ErrNrfUnavailable marks the errors of the NRF requests that got no answer or a 5xx
+// answer, after which the AUSF fails over to another NRF
+var ErrNrfUnavailable = errors.New("NRF unavailable")
+
+// nrfUnavailable wraps err with ErrNrfUnavailable when the NRF did not answer or
+// answered with a server error
+func nrfUnavailable(res *http.Response, err error) error {
+	if res == nil || res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: %w", ErrNrfUnavailable, err)
+	}
+	return err
+}
+
 var SendRegisterNFInstance = func(plmnConfig []models.PlmnId) (prof *models.NFProfile, resourceNrfUri string, err error) {
+	ctx, span := tracing.Start(context.Background(), "nnrf-nfm RegisterNFInstance")
+	defer func() { tracing.End(span, err) }()
+
 	self := ausfContext.GetSelf()
 	nfProfile, err := getNfProfile(self, plmnConfig)
 	if err != nil {
 		return &models.NFProfile{}, "", err
 	}
 
-	configuration := Nnrf_NFManagement.NewConfiguration()
-	serverConfig := &configuration.Servers[0]
-	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
-		apiRootVar.DefaultValue = self.NrfUri
-		serverConfig.Variables["apiRoot"] = apiRootVar
-	}
-	client := Nnrf_NFManagement.NewAPIClient(configuration)
-	apiRegisterNFInstanceRequest := client.NFInstanceIDDocumentAPI.RegisterNFInstance(context.TODO(), nfProfile.GetNfInstanceId())
+	client := newNFManagementClient(self.GetNrfUri())
+	apiRegisterNFInstanceRequest := client.NFInstanceIDDocumentAPI.RegisterNFInstance(ctx, nfProfile.GetNfInstanceId())
 	apiRegisterNFInstanceRequest = apiRegisterNFInstanceRequest.NFProfile(nfProfile)
 	receivedNfProfile, res, err := client.NFInstanceIDDocumentAPI.RegisterNFInstanceExecute(apiRegisterNFInstanceRequest)
 	defer closeNFManagementResponseBody(res, "RegisterNFInstance")
 	logger.ConsumerLog.Debugf("registering NF Instance using profile: %+v", nfProfile)
 
 	if err != nil {
-		return &models.NFProfile{}, "", err
+		return &models.NFProfile{}, "", nrfUnavailable(res, err)
 	}
 	if res == nil {
-		return &models.NFProfile{}, "", openapi.ReportError("no response from server")
+		return &models.NFProfile{}, "", nrfUnavailable(nil, openapi.ReportError("no response from server"))
 	}
 
 	switch res.StatusCode {
 	case http.StatusOK: // NFUpdate
 		logger.ConsumerLog.Debugln("AUSF NF profile updated with complete replacement")
+		self.SetNrfResourceUri("")
 		return receivedNfProfile, "", nil
 	case http.StatusCreated: // NFRegister
 		resourceUri := res.Header.Get("Location")
-		resourceNrfUri = resourceUri[:strings.Index(resourceUri, "/nnrf-nfm/")]
+		if i := strings.Index(resourceUri, "/nnrf-nfm/"); i > 0 {
+			resourceNrfUri = resourceUri[:i]
+		}
 		retrieveNfInstanceId := resourceUri[strings.LastIndex(resourceUri, "/")+1:]
-		self.NfId = retrieveNfInstanceId
-		logger.ConsumerLog.Debugln("AUSF NF profile registered to the NRF")
+		if retrieveNfInstanceId != "" {
+			self.NfId = retrieveNfInstanceId
+		}
+		self.SetNrfResourceUri(resourceNrfUri)
+		logger.ConsumerLog.Debugf("AUSF NF profile registered to the NRF, resource at %s", self.GetNrfResourceUri())
 		return receivedNfProfile, resourceNrfUri, nil
 	default:
-		return receivedNfProfile, "", openapi.ReportError("unexpected status code returned by the NRF %d", res.StatusCode)
+		return receivedNfProfile, "", nrfUnavailable(res, openapi.ReportError("unexpected status code returned by the NRF %d", res.StatusCode))
 	}
 }
 
-var SendDeregisterNFInstance = func() error {
+var SendDeregisterNFInstance = func() (err error) {
 	logger.ConsumerLog.Infoln("send Deregister NFInstance")
+	ctx, span := tracing.Start(context.Background(), "nnrf-nfm DeregisterNFInstance")
+	defer func() { tracing.End(span, err) }()
 
 	ausfSelf := ausfContext.GetSelf()
-	// Set client and set url
-	configuration := Nnrf_NFManagement.NewConfiguration()
-	serverConfig := &configuration.Servers[0]
-	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
-		apiRootVar.DefaultValue = ausfSelf.NrfUri
-		serverConfig.Variables["apiRoot"] = apiRootVar
-	}
-	client := Nnrf_NFManagement.NewAPIClient(configuration)
-	apiDeregisterNFInstanceRequest := client.NFInstanceIDDocumentAPI.DeregisterNFInstance(context.Background(), ausfSelf.NfId)
+	client := newNFManagementClient(ausfSelf.GetNrfResourceUri())
+	apiDeregisterNFInstanceRequest := client.NFInstanceIDDocumentAPI.DeregisterNFInstance(ctx, ausfSelf.NfId)
 	res, err := client.NFInstanceIDDocumentAPI.DeregisterNFInstanceExecute(apiDeregisterNFInstanceRequest)
 	defer closeNFManagementResponseBody(res, "DeregisterNFInstance")
 	if err != nil {
@@ -122,18 +170,14 @@ var SendDeregisterNFInstance = func() error {
 
 var SendUpdateNFInstance = func(patchItem []models.PatchItem) (receivedNfProfile *models.NFProfile, problemDetails *models.ProblemDetails, err error) {
 	logger.ConsumerLog.Debugln("send Update NFInstance")
+	ctx, span := tracing.Start(context.Background(), "nnrf-nfm UpdateNFInstance")
+	defer func() { tracing.End(span, err) }()
 
 	ausfSelf := ausfContext.GetSelf()
-	configuration := Nnrf_NFManagement.NewConfiguration()
-	serverConfig := &configuration.Servers[0]
-	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
-		apiRootVar.DefaultValue = ausfSelf.NrfUri
-		serverConfig.Variables["apiRoot"] = apiRootVar
-	}
-	client := Nnrf_NFManagement.NewAPIClient(configuration)
+	client := newNFManagementClient(ausfSelf.GetNrfResourceUri())
 
 	var res *http.Response
-	apiUpdateNFInstanceRequest := client.NFInstanceIDDocumentAPI.UpdateNFInstance(context.Background(), ausfSelf.NfId)
+	apiUpdateNFInstanceRequest := client.NFInstanceIDDocumentAPI.UpdateNFInstance(ctx, ausfSelf.NfId)
 	apiUpdateNFInstanceRequest = apiUpdateNFInstanceRequest.PatchItem(patchItem)
 	receivedNfProfile, res, err = client.NFInstanceIDDocumentAPI.UpdateNFInstanceExecute(apiUpdateNFInstanceRequest)
 	defer closeNFManagementResponseBody(res, "UpdateNFInstance")
@@ -145,11 +189,11 @@ var SendUpdateNFInstance = func(patchItem []models.PatchItem) (receivedNfProfile
 				}
 			}
 		}
-		return &models.NFProfile{}, nil, err
+		return &models.NFProfile{}, nil, nrfUnavailable(res, err)
 	}
 
 	if res == nil {
-		return &models.NFProfile{}, nil, openapi.ReportError("no response from server")
+		return &models.NFProfile{}, nil, nrfUnavailable(nil, openapi.ReportError("no response from server"))
 	}
 	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNoContent {
 		return receivedNfProfile, nil, nil
@@ -160,14 +204,7 @@ var SendUpdateNFInstance = func(patchItem []models.PatchItem) (receivedNfProfile
 var SendCreateSubscription = func(nrfUri string, nrfSubscriptionData models.SubscriptionData) (nrfSubData *models.SubscriptionData, problemDetails *models.ProblemDetails, err error) {
 	logger.ConsumerLog.Debugln("send Create Subscription")
 
-	// Set client and set url
-	configuration := Nnrf_NFManagement.NewConfiguration()
-	serverConfig := &configuration.Servers[0]
-	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
-		apiRootVar.DefaultValue = nrfUri
-		serverConfig.Variables["apiRoot"] = apiRootVar
-	}
-	client := Nnrf_NFManagement.NewAPIClient(configuration)
+	client := newNFManagementClient(nrfUri)
 
 	var res *http.Response
 	apiCreateSubscriptionRequest := client.SubscriptionsCollectionAPI.CreateSubscription(context.TODO())
@@ -204,14 +241,7 @@ var SendRemoveSubscription = func(subscriptionId string) (problemDetails *models
 	logger.ConsumerLog.Infoln("send Remove Subscription")
 
 	ausfSelf := ausfContext.GetSelf()
-	// Set client and set url
-	configuration := Nnrf_NFManagement.NewConfiguration()
-	serverConfig := &configuration.Servers[0]
-	if apiRootVar, exists := serverConfig.Variables["apiRoot"]; exists {
-		apiRootVar.DefaultValue = ausfSelf.NrfUri
-		serverConfig.Variables["apiRoot"] = apiRootVar
-	}
-	client := Nnrf_NFManagement.NewAPIClient(configuration)
+	client := newNFManagementClient(ausfSelf.GetNrfUri())
 
 	var res *http.Response
 	apiRemoveSubscriptionRequest := client.SubscriptionIDDocumentAPI.RemoveSubscription(context.Background(), subscriptionId)
@@ -7,8 +7,7 @@
 package context
 
 import (
-	"os"
-	"strconv"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,16 +25,24 @@ func InitAusfContext(context *AUSFContext) {
 
 	context.NfId = uuid.New().String()
 	context.GroupID = configuration.GroupId
-	context.NrfUri = configuration.NrfUri
 	context.UriScheme = models.UriScheme(configuration.Sbi.Scheme) // default uri scheme
 	context.RegisterIPv4 = factory.AUSF_DEFAULT_IPV4               // default localhost
 	context.SBIPort = factory.AUSF_DEFAULT_PORT_INT                // default port
 	if sbi != nil {
 		configureSbiSettings(context, sbi)
 		configureBindingIPv4(context, sbi)
+		context.BindingIPv6 = sbi.BindingIPv6
 	}
+	if profile := configuration.NfProfile; profile != nil {
+		context.Capacity = profile.Capacity
+		context.Priority = profile.Priority
+		context.Locality = profile.Locality
+		context.NfSetIdList = profile.NfSetIdList
+	}
+	context.configuredAusfInfo = configuration.AusfInfo
 
-	context.Url = string(context.UriScheme) + "://" + context.RegisterIPv4 + ":" + strconv.Itoa(context.SBIPort)
+	context.Url = context.GetSbiUri()
+	context.ApplyReloadableConfig(configuration)
 	context.EnableNrfCaching = configuration.EnableNrfCaching
 	if configuration.EnableNrfCaching {
 		if configuration.NrfCacheEvictionInterval == 0 {
@@ -51,10 +58,33 @@ func InitAusfContext(context *AUSFContext) {
 	logger.ContextLog.Infoln("ausf context:", context)
 }
 
+// ApplyReloadableConfig updates the settings that can change without a restart, the
+// NRF URIs. When they change, the most preferred NRF is used first.
+func (context *AUSFContext) ApplyReloadableConfig(configuration *factory.Configuration) {
+	context.reloadableMu.Lock()
+	defer context.reloadableMu.Unlock()
+	if nrfUris := configuration.GetNrfUris(); !slices.Equal(context.NrfUris, nrfUris) {
+		context.NrfUris = nrfUris
+		context.NrfUri = ""
+		if len(nrfUris) > 0 {
+			context.NrfUri = nrfUris[0]
+		}
+		context.nrfResourceUri = ""
+	}
+}
+
 func configureSbiSettings(context *AUSFContext, sbi *factory.Sbi) {
 	if sbi.RegisterIPv4 != "" {
 		context.RegisterIPv4 = sbi.RegisterIPv4
 	}
+	if sbi.RegisterIPv6 != "" {
+		context.RegisterIPv6 = sbi.RegisterIPv6
+		if sbi.RegisterIPv4 == "" {
+			// IPv6-only deployment: do not advertise the default IPv4 address
+			context.RegisterIPv4 = ""
+		}
+	}
+	context.RegisterFqdn = sbi.RegisterFqdn
 	if sbi.Port != 0 {
 		context.SBIPort = sbi.Port
 	}
@@ -79,15 +109,10 @@ func configureSbiSettings(context *AUSFContext, sbi *factory.Sbi) {
 }
 
 func configureBindingIPv4(context *AUSFContext, sbi *factory.Sbi) {
-	context.BindingIPv4 = os.Getenv(sbi.BindingIPv4)
-	if context.BindingIPv4 != "" {
-		logger.InitLog.Infoln("parsing ServerIPv4 address from ENV Variable")
-	} else {
-		context.BindingIPv4 = sbi.BindingIPv4
-		if context.BindingIPv4 == "" {
-			logger.InitLog.Warnln("error parsing ServerIPv4 address as string. Using the 0.0.0.0 address as default")
-			context.BindingIPv4 = "0.0.0.0"
-		}
+	context.BindingIPv4 = sbi.BindingIPv4
+	if context.BindingIPv4 == "" && sbi.BindingIPv6 == "" {
+		logger.InitLog.Warnln("error parsing ServerIPv4 address as string. Using the 0.0.0.0 address as default")
+		context.BindingIPv4 = "0.0.0.0"
 	}
 }
 
@@ -100,17 +125,27 @@ func AddNfServices(serviceMap *map[models.ServiceName]models.NFService, config *
 	// nausf-auth
 	nfService.ServiceInstanceId = context.NfId
 	nfService.ServiceName = models.SERVICENAME_NAUSF_AUTH
-	ipEndPoint := models.NewIpEndPoint()
-	ipEndPoint.SetIpv4Address(context.RegisterIPv4)
-	ipEndPoint.SetPort(int32(context.SBIPort))
-
-	ipEndPoints = append(ipEndPoints, *ipEndPoint)
+	if context.RegisterIPv4 != "" {
+		ipEndPoint := models.NewIpEndPoint()
+		ipEndPoint.SetIpv4Address(context.RegisterIPv4)
+		ipEndPoint.SetPort(int32(context.SBIPort))
+		ipEndPoints = append(ipEndPoints, *ipEndPoint)
+	}
+	if context.RegisterIPv6 != "" {
+		ipEndPoint := models.NewIpEndPoint()
+		ipEndPoint.SetIpv6Address(context.RegisterIPv6)
+		ipEndPoint.SetPort(int32(context.SBIPort))
+		ipEndPoints = append(ipEndPoints, *ipEndPoint)
+	}
 
 	var nfServiceVersion models.NFServiceVersion
 	nfServiceVersion.ApiFullVersion = config.Info.Version
 	nfServiceVersion.ApiVersionInUri = "v1"
 	nfServiceVersions = append(nfServiceVersions, nfServiceVersion)
 
+	if context.RegisterFqdn != "" {
+		nfService.SetFqdn(context.RegisterFqdn)
+	}
 	nfService.Scheme = context.UriScheme
 	nfService.NfServiceStatus = models.NFSERVICESTATUS_REGISTERED
 
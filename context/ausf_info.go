@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package context
+
+import (
+	"reflect"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+// SetPolledAusfInfo replaces the configured AUSF info with the one received from the
+// webui, or restores the configured one when info is nil. It reports whether the AUSF
+// info advertised to the NRF changed.
+func (context *AUSFContext) SetPolledAusfInfo(info *factory.AusfInfo) bool {
+	context.ausfInfoMu.Lock()
+	defer context.ausfInfoMu.Unlock()
+	previous := context.currentAusfInfo()
+	context.polledAusfInfo = info
+	return !reflect.DeepEqual(previous, context.currentAusfInfo())
+}
+
+func (context *AUSFContext) currentAusfInfo() *factory.AusfInfo {
+	if context.polledAusfInfo != nil {
+		return context.polledAusfInfo
+	}
+	return context.configuredAusfInfo
+}
+
+// GetAusfInfo returns the AUSF info advertised in the NF profile
+func (context *AUSFContext) GetAusfInfo() models.AusfInfo {
+	context.ausfInfoMu.RLock()
+	info := context.currentAusfInfo()
+	context.ausfInfoMu.RUnlock()
+
+	ausfInfo := models.NewAusfInfo()
+	ausfInfo.SetGroupId(context.GroupID)
+	if info == nil {
+		return *ausfInfo
+	}
+	for _, supiRange := range info.SupiRanges {
+		r := models.NewSupiRange()
+		if supiRange.Pattern != "" {
+			r.SetPattern(supiRange.Pattern)
+		} else {
+			r.SetStart(supiRange.Start)
+			r.SetEnd(supiRange.End)
+		}
+		ausfInfo.SupiRanges = append(ausfInfo.SupiRanges, *r)
+	}
+	if len(info.RoutingIndicators) > 0 {
+		ausfInfo.SetRoutingIndicators(info.RoutingIndicators)
+	}
+	for _, suciInfo := range info.SuciInfos {
+		s := models.NewSuciInfo()
+		if len(suciInfo.RoutingInds) > 0 {
+			s.SetRoutingInds(suciInfo.RoutingInds)
+		}
+		for _, keyId := range suciInfo.HNwPubKeyIds {
+			s.HNwPubKeyIds = append(s.HNwPubKeyIds, int32(keyId))
+		}
+		ausfInfo.SuciInfos = append(ausfInfo.SuciInfos, *s)
+	}
+	return *ausfInfo
+}
@@ -7,10 +7,15 @@ package context
 
 import (
 	"fmt"
+	"net"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/omec-project/ausf/factory"
 	"github.com/omec-project/ausf/logger"
 	"github.com/omec-project/openapi/v2/models"
 )
@@ -20,18 +25,34 @@ type AUSFContext struct {
 	UePool                   sync.Map
 	NfStatusSubscriptions    sync.Map // map[NfInstanceID]models.NrfSubscriptionData.SubscriptionId
 	snRegex                  *regexp.Regexp
+	reloadableMu             sync.RWMutex // guards the fields that can change on a configuration reload
 	NfId                     string
 	GroupID                  string
 	RegisterIPv4             string
 	BindingIPv4              string
+	RegisterIPv6             string
+	BindingIPv6              string
+	RegisterFqdn             string
 	Url                      string
-	NrfUri                   string
+	NrfUri                   string   // NRF in use, one of NrfUris
+	NrfUris                  []string // NRFs in order of preference
+	nrfResourceUri           string   // API root of the NF instance resource returned on registration
+	nrfFailedOverAt          time.Time
 	UdmUeauUrl               string
 	UriScheme                models.UriScheme
 	Key                      string
 	PEM                      string
 	NfService                map[models.ServiceName]models.NFService
 	PlmnList                 []models.PlmnId
+	Capacity                 int // NF profile attributes, not advertised when zero or empty
+	Priority                 int
+	Locality                 string
+	NfSetIdList              []string
+	ausfInfoMu               sync.RWMutex
+	configuredAusfInfo       *factory.AusfInfo
+	polledAusfInfo           *factory.AusfInfo // received from the webui, replaces configuredAusfInfo
+	policyMu                 sync.RWMutex
+	allowedServingNetworks   []string // received from the webui, every serving network is allowed when empty
 	SBIPort                  int
 	EnableNrfCaching         bool
 	NrfCacheEvictionInterval time.Duration
@@ -42,8 +63,13 @@ type AusfUeContext struct {
 	Kausf              string
 	Kseaf              string
 	ServingNetworkName string
-	AuthStatus         models.AuthResult
-	UdmUeauUrl         string
+	UdmUeauUrl         string // UDM the authentication vector was generated by; the results go to the UDM in use
+
+	// AuthStatus and AuthStartTime are read by the load and metrics reporting: once the
+	// context is in the pool, they are only accessed through the methods below
+	authStateMu   sync.RWMutex
+	AuthStatus    models.AuthResult
+	AuthStartTime time.Time
 
 	// for 5G AKA
 	XresStar string
@@ -54,6 +80,34 @@ type AusfUeContext struct {
 	Rand  string
 }
 
+// StartAuthentication marks the authentication of the context as ongoing since now
+func (ueContext *AusfUeContext) StartAuthentication() {
+	ueContext.authStateMu.Lock()
+	defer ueContext.authStateMu.Unlock()
+	ueContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_ONGOING
+	ueContext.AuthStartTime = time.Now()
+}
+
+func (ueContext *AusfUeContext) SetAuthStatus(status models.AuthResult) {
+	ueContext.authStateMu.Lock()
+	defer ueContext.authStateMu.Unlock()
+	ueContext.AuthStatus = status
+}
+
+func (ueContext *AusfUeContext) GetAuthStatus() models.AuthResult {
+	ueContext.authStateMu.RLock()
+	defer ueContext.authStateMu.RUnlock()
+	return ueContext.AuthStatus
+}
+
+// ongoingSince reports whether the authentication of the context is ongoing and was
+// started after since
+func (ueContext *AusfUeContext) ongoingSince(since time.Time) bool {
+	ueContext.authStateMu.RLock()
+	defer ueContext.authStateMu.RUnlock()
+	return ueContext.AuthStatus == models.AUTHRESULT_AUTHENTICATION_ONGOING && ueContext.AuthStartTime.After(since)
+}
+
 type SuciSupiMap struct {
 	SupiOrSuci string
 	Supi       string
@@ -105,16 +159,137 @@ func CheckIfAusfUeContextExists(ref string) bool {
 	return ok
 }
 
+// GetAusfUeContext returns the UE context of the SUPI, or nil if there is none
 func GetAusfUeContext(ref string) *AusfUeContext {
 	context, _ := ausfContext.UePool.Load(ref)
-	ausfUeContext := context.(*AusfUeContext)
+	ausfUeContext, _ := context.(*AusfUeContext)
 	return ausfUeContext
 }
 
+// GetNrfUri returns the URI of the NRF in use, which changes on a failover or a
+// configuration reload
+func (context *AUSFContext) GetNrfUri() string {
+	context.reloadableMu.RLock()
+	defer context.reloadableMu.RUnlock()
+	return context.NrfUri
+}
+
+// GetNrfUris returns the NRFs in order of preference
+func (context *AUSFContext) GetNrfUris() []string {
+	context.reloadableMu.RLock()
+	defer context.reloadableMu.RUnlock()
+	return slices.Clone(context.NrfUris)
+}
+
+// FailoverNrf switches to the NRF following failedNrfUri in order of preference, wrapping
+// around to the first one, and returns the NRF now in use. It does nothing when another
+// caller already switched away from failedNrfUri.
+func (context *AUSFContext) FailoverNrf(failedNrfUri string) string {
+	context.reloadableMu.Lock()
+	defer context.reloadableMu.Unlock()
+	if context.NrfUri != failedNrfUri || len(context.NrfUris) < 2 {
+		return context.NrfUri
+	}
+	i := slices.Index(context.NrfUris, failedNrfUri)
+	context.NrfUri = context.NrfUris[(i+1)%len(context.NrfUris)]
+	context.nrfResourceUri = ""
+	context.nrfFailedOverAt = time.Now()
+	logger.ContextLog.Warnf("NRF %s failed, switching to %s", failedNrfUri, context.NrfUri)
+	return context.NrfUri
+}
+
+// SwitchToPreferredNrf makes the first NRF of NrfUris the NRF in use when another NRF
+// has been in use for at least interval, and returns a function switching back to that
+// NRF when the preferred one turns out to be still unavailable. It returns nil when no
+// fail-back is due.
+func (context *AUSFContext) SwitchToPreferredNrf(interval time.Duration) (restore func()) {
+	context.reloadableMu.Lock()
+	defer context.reloadableMu.Unlock()
+	if len(context.NrfUris) < 2 || context.NrfUri == context.NrfUris[0] ||
+		time.Since(context.nrfFailedOverAt) < interval {
+		return nil
+	}
+	previousNrfUri, previousResourceUri := context.NrfUri, context.nrfResourceUri
+	preferredNrfUri := context.NrfUris[0]
+	context.NrfUri, context.nrfResourceUri = preferredNrfUri, ""
+	return func() {
+		context.reloadableMu.Lock()
+		defer context.reloadableMu.Unlock()
+		if context.NrfUri != preferredNrfUri || !slices.Contains(context.NrfUris, previousNrfUri) {
+			return
+		}
+		context.NrfUri, context.nrfResourceUri = previousNrfUri, previousResourceUri
+		context.nrfFailedOverAt = time.Now()
+	}
+}
+
+// AlternateNrfUris returns the NRFs other than nrfUri, in the order they are failed over to
+func (context *AUSFContext) AlternateNrfUris(nrfUri string) []string {
+	context.reloadableMu.RLock()
+	defer context.reloadableMu.RUnlock()
+	i := slices.Index(context.NrfUris, nrfUri)
+	if i < 0 {
+		return slices.Clone(context.NrfUris)
+	}
+	return append(slices.Clone(context.NrfUris[i+1:]), context.NrfUris[:i]...)
+}
+
+// SetNrfResourceUri records the API root of the NF instance resource returned by the NRF
+// on registration; empty means the NRF in use
+func (context *AUSFContext) SetNrfResourceUri(resourceNrfUri string) {
+	context.reloadableMu.Lock()
+	defer context.reloadableMu.Unlock()
+	context.nrfResourceUri = resourceNrfUri
+}
+
+// GetNrfResourceUri returns the API root the NF instance of the AUSF is updated and
+// deregistered at
+func (context *AUSFContext) GetNrfResourceUri() string {
+	context.reloadableMu.RLock()
+	defer context.reloadableMu.RUnlock()
+	if context.nrfResourceUri != "" {
+		return context.nrfResourceUri
+	}
+	return context.NrfUri
+}
+
+// IsNrfCachingEnabled reports whether NF discovery goes through the NRF cache
+func (context *AUSFContext) IsNrfCachingEnabled() bool {
+	return context.EnableNrfCaching
+}
+
 func (context *AUSFContext) GetIPv4Uri() string {
 	return fmt.Sprintf("%s://%s:%d", context.UriScheme, context.RegisterIPv4, context.SBIPort)
 }
 
+// GetSbiUri returns the URI other NFs use to reach the AUSF. The registered FQDN is preferred,
+// then the IPv4 address; IPv6-only deployments get a bracketed IPv6 literal.
+func (context *AUSFContext) GetSbiUri() string {
+	host := context.RegisterFqdn
+	if host == "" {
+		host = context.RegisterIPv4
+	}
+	if host == "" {
+		host = context.RegisterIPv6
+	}
+	return fmt.Sprintf("%s://%s", context.UriScheme, net.JoinHostPort(host, strconv.Itoa(context.SBIPort)))
+}
+
+// GetBindingAddresses returns the addresses the SBI server listens on, one per IP family
+func (context *AUSFContext) GetBindingAddresses() []string {
+	addrs := make([]string, 0, 2)
+	if context.BindingIPv4 != "" {
+		addrs = append(addrs, net.JoinHostPort(context.BindingIPv4, strconv.Itoa(context.SBIPort)))
+	}
+	if context.BindingIPv6 != "" {
+		addrs = append(addrs, net.JoinHostPort(context.BindingIPv6, strconv.Itoa(context.SBIPort)))
+	}
+	if len(addrs) == 0 {
+		addrs = append(addrs, net.JoinHostPort("", strconv.Itoa(context.SBIPort)))
+	}
+	return addrs
+}
+
 func AddSuciSupiPairToMap(supiOrSuci string, supi string) {
 	newPair := new(SuciSupiMap)
 	newPair.SupiOrSuci = supiOrSuci
@@ -141,6 +316,98 @@ func ListSuciSupiPairsForSupi(supi string) []string {
 	return keys
 }
 
+// RangeSuciSupiPairs calls f for every authentication context ID and its SUPI until f returns false
+func RangeSuciSupiPairs(f func(authCtxID, supi string) bool) {
+	ausfContext.suciSupiMap.Range(func(key, value any) bool {
+		pair, ok := value.(*SuciSupiMap)
+		authCtxID, keyOk := key.(string)
+		if !ok || pair == nil || !keyOk {
+			return true
+		}
+		return f(authCtxID, pair.Supi)
+	})
+}
+
+// ListNfStatusSubscriptions returns the NRF subscription ID of every subscribed NF instance
+func ListNfStatusSubscriptions() map[string]string {
+	subscriptions := make(map[string]string)
+	ausfContext.NfStatusSubscriptions.Range(func(key, value any) bool {
+		nfInstanceID, keyOk := key.(string)
+		subscriptionID, ok := value.(string)
+		if keyOk && ok {
+			subscriptions[nfInstanceID] = subscriptionID
+		}
+		return true
+	})
+	return subscriptions
+}
+
+// MaskSupi hides the subscriber part of a SUPI or SUCI so that it can be shown to operators
+// and written to logs: the type prefix and PLMN digits are kept along with the last two characters.
+func MaskSupi(id string) string {
+	const visibleSuffix = 2
+	if id == "" {
+		return ""
+	}
+	prefix, value := "", id
+	switch {
+	case strings.HasPrefix(id, "suci-"):
+		// suci-<type>-<mcc>-<mnc>-<routing>-<scheme>-<key id>-<scheme output>
+		cut := strings.LastIndex(id, "-") + 1
+		prefix, value = id[:cut], id[cut:]
+	case strings.HasPrefix(id, "imsi-"):
+		// keep MCC and the first two digits of the MNC
+		cut := min(len("imsi-")+5, len(id))
+		prefix, value = id[:cut], id[cut:]
+	default:
+		if i := strings.Index(id, "-"); i >= 0 {
+			prefix, value = id[:i+1], id[i+1:]
+		}
+	}
+	if len(value) <= visibleSuffix {
+		return prefix + strings.Repeat("*", len(value))
+	}
+	return prefix + strings.Repeat("*", len(value)-visibleSuffix) + value[len(value)-visibleSuffix:]
+}
+
+// UeContextCount returns the number of UE contexts in the pool
+func UeContextCount() int {
+	count := 0
+	ausfContext.UePool.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// OngoingAuthContextCount returns the number of UE contexts whose authentication started
+// less than maxAge ago and has not been confirmed yet; older ones are left behind by UEs
+// that never answered the challenge and do not load the AUSF
+func OngoingAuthContextCount(maxAge time.Duration) int {
+	count := 0
+	since := time.Now().Add(-maxAge)
+	ausfContext.UePool.Range(func(_, value any) bool {
+		if value.(*AusfUeContext).ongoingSince(since) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// PendingAuthContextCount returns the number of authentication contexts waiting for confirmation
+func PendingAuthContextCount() int {
+	count := 0
+	RangeSuciSupiPairs(func(_, supi string) bool {
+		if ueContext := GetAusfUeContext(supi); ueContext != nil &&
+			ueContext.GetAuthStatus() == models.AUTHRESULT_AUTHENTICATION_ONGOING {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
 func HasSuciSupiPairForSupi(supi string) bool {
 	return len(ListSuciSupiPairsForSupi(supi)) > 0
 }
@@ -159,7 +426,7 @@ func GetSupiFromSuciSupiMap(ref string) (supi string) {
 
 func IsServingNetworkAuthorized(lookup string) bool {
 	if ausfContext.snRegex.MatchString(lookup) {
-		return true
+		return ausfContext.isServingNetworkAllowed(lookup)
 	} else {
 		return false
 	}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package context
+
+import (
+	"context"
+	"slices"
+
+	"github.com/omec-project/ausf/logger"
+)
+
+// SetAllowedServingNetworks restricts the serving networks in which UEs can be
+// authenticated; every serving network is allowed when names is empty
+func (context *AUSFContext) SetAllowedServingNetworks(names []string) {
+	context.policyMu.Lock()
+	defer context.policyMu.Unlock()
+	context.allowedServingNetworks = names
+}
+
+func (context *AUSFContext) isServingNetworkAllowed(name string) bool {
+	context.policyMu.RLock()
+	defer context.policyMu.RUnlock()
+	return len(context.allowedServingNetworks) == 0 || slices.Contains(context.allowedServingNetworks, name)
+}
+
+// WatchAllowedServingNetworks applies the allowed serving networks received from the
+// webconsole until ctx is done or ch is closed
+func WatchAllowedServingNetworks(ctx context.Context, ch <-chan []string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case names, ok := <-ch:
+			if !ok {
+				return
+			}
+			ausfContext.SetAllowedServingNetworks(names)
+			if len(names) == 0 {
+				logger.ContextLog.Infoln("every serving network is allowed")
+			} else {
+				logger.ContextLog.Infof("allowed serving networks: %v", names)
+			}
+		}
+	}
+}
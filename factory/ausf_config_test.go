@@ -7,6 +7,8 @@
 package factory
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -111,3 +113,50 @@ func TestValidateWebuiUri(t *testing.T) {
 		})
 	}
 }
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		isValid bool
+	}{
+		{
+			name:    "valid configuration",
+			content: "info:\n  version: 1.0.0\nconfiguration:\n  nrfUri: http://nrf:8081\n",
+			isValid: true,
+		},
+		{
+			name:    "missing configuration section",
+			content: "info:\n  version: 1.0.0\n",
+			isValid: false,
+		},
+		{
+			name:    "malformed YAML",
+			content: "configuration: [",
+			isValid: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			origAusfConfig := AusfConfig
+			defer func() { AusfConfig = origAusfConfig }()
+			AusfConfig = Config{CfgLocation: "running"}
+
+			configFile := filepath.Join(t.TempDir(), "ausfcfg.yaml")
+			if err := os.WriteFile(configFile, []byte(tc.content), 0o600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			_, err := ReadConfig(configFile)
+			if err == nil && !tc.isValid {
+				t.Error("expected configuration to be refused")
+			}
+			if err != nil && tc.isValid {
+				t.Errorf("expected configuration to be accepted: %v", err)
+			}
+			if AusfConfig.CfgLocation != "running" || AusfConfig.Configuration != nil {
+				t.Error("expected ReadConfig to leave the running configuration untouched")
+			}
+		})
+	}
+}
@@ -10,6 +10,9 @@
 package factory
 
 import (
+	"slices"
+
+	"github.com/omec-project/openapi/v2/models"
 	"github.com/omec-project/util/logger"
 )
 
@@ -33,16 +36,30 @@ const (
 	AUSF_DEFAULT_IPV4     = "127.0.0.9"
 	AUSF_DEFAULT_PORT     = "8000"
 	AUSF_DEFAULT_PORT_INT = 8000
+
+	DEFAULT_SBI_DRAIN_TIMEOUT = 10 // seconds
 )
 
 type Configuration struct {
-	Sbi                      *Sbi     `yaml:"sbi,omitempty"`
-	ServiceNameList          []string `yaml:"serviceNameList,omitempty"`
-	NrfUri                   string   `yaml:"nrfUri,omitempty"`
-	WebuiUri                 string   `yaml:"webuiUri"`
-	GroupId                  string   `yaml:"groupId,omitempty"`
-	EnableNrfCaching         bool     `yaml:"enableNrfCaching"`
-	NrfCacheEvictionInterval int      `yaml:"nrfCacheEvictionInterval,omitempty"`
+	Sbi                      *Sbi               `yaml:"sbi,omitempty"`
+	ServiceNameList          []string           `yaml:"serviceNameList,omitempty"`
+	NrfUri                   string             `yaml:"nrfUri,omitempty"`
+	NrfUris                  []string           `yaml:"nrfUris,omitempty"` // secondary NRFs, in order of preference
+	WebuiUri                 string             `yaml:"webuiUri"`
+	GroupId                  string             `yaml:"groupId,omitempty"`
+	EnableNrfCaching         bool               `yaml:"enableNrfCaching"`
+	NrfCacheEvictionInterval int                `yaml:"nrfCacheEvictionInterval,omitempty"`
+	Resilience               *Resilience        `yaml:"resilience,omitempty"`
+	AuthEventDelivery        *AuthEventDelivery `yaml:"authEventDelivery,omitempty"`
+	Admin                    *Admin             `yaml:"admin,omitempty"`
+	Metrics                  *Metrics           `yaml:"metrics,omitempty"`
+	Tracing                  *Tracing           `yaml:"tracing,omitempty"`
+	Logging                  *Logging           `yaml:"logging,omitempty"`
+	Audit                    *Audit             `yaml:"audit,omitempty"`
+	NfProfile                *NfProfile         `yaml:"nfProfile,omitempty"`
+	AusfInfo                 *AusfInfo          `yaml:"ausfInfo,omitempty"`
+	ConfigUpdates            *ConfigUpdates     `yaml:"configUpdates,omitempty"`
+	DiscoveryCache           *DiscoveryCache    `yaml:"discoveryCache,omitempty"`
 }
 
 type Sbi struct {
@@ -50,14 +67,213 @@ type Sbi struct {
 	TLS          *TLS   `yaml:"tls"`
 	RegisterIPv4 string `yaml:"registerIPv4,omitempty"` // IP that is registered at NRF.
 	BindingIPv4  string `yaml:"bindingIPv4,omitempty"`  // IP used to run the server in the node.
+	RegisterIPv6 string `yaml:"registerIPv6,omitempty"` // IPv6 address that is registered at NRF.
+	BindingIPv6  string `yaml:"bindingIPv6,omitempty"`  // IPv6 address used to run the server in the node.
+	RegisterFqdn string `yaml:"registerFqdn,omitempty"` // FQDN that is registered at NRF and used in callback URIs.
 	Port         int    `yaml:"port,omitempty"`
+	DrainTimeout int    `yaml:"drainTimeout,omitempty"` // seconds in-flight requests are given to complete on shutdown, default 10
 }
 
 type TLS struct {
+	PEM            string `yaml:"pem,omitempty"`
+	Key            string `yaml:"key,omitempty"`
+	CA             string `yaml:"ca,omitempty"`             // CA bundle used to verify peers; enables mutual TLS.
+	ClientPEM      string `yaml:"clientPem,omitempty"`      // Certificate presented to other NFs, defaults to pem.
+	ClientKey      string `yaml:"clientKey,omitempty"`      // Key of clientPem, defaults to key.
+	MinVersion     string `yaml:"minVersion,omitempty"`     // Minimum TLS version: "1.2" (default) or "1.3".
+	ReloadInterval int    `yaml:"reloadInterval,omitempty"` // Seconds between checks for certificate changes, default 10.
+}
+
+// Resilience configures the retry and circuit breaker behaviour of the
+// outbound SBI clients (NRF and UDM).
+type Resilience struct {
+	Retry          *Retry          `yaml:"retry,omitempty"`
+	CircuitBreaker *CircuitBreaker `yaml:"circuitBreaker,omitempty"`
+}
+
+// Retry is only applied to idempotent HTTP methods (GET, HEAD, PUT, DELETE, OPTIONS).
+type Retry struct {
+	MaxAttempts      int     `yaml:"maxAttempts,omitempty"`
+	InitialBackoffMs int     `yaml:"initialBackoffMs,omitempty"`
+	MaxBackoffMs     int     `yaml:"maxBackoffMs,omitempty"`
+	Multiplier       float64 `yaml:"multiplier,omitempty"`
+}
+
+// CircuitBreaker opens per target (host:port) after FailureThreshold consecutive
+// failures and lets a single probe through after OpenTimeout seconds.
+type CircuitBreaker struct {
+	Disabled         bool `yaml:"disabled,omitempty"`
+	FailureThreshold int  `yaml:"failureThreshold,omitempty"`
+	OpenTimeout      int  `yaml:"openTimeout,omitempty"`
+}
+
+const (
+	DEFAULT_DISCOVERY_CACHE_MAX_TTL     = 3600 // seconds
+	DEFAULT_DISCOVERY_CACHE_MAX_ENTRIES = 1000
+)
+
+// DiscoveryCache keeps the NF discovery results of the NRF, per NRF and query, for the
+// validityPeriod of the result. The cache is only used when enabled.
+type DiscoveryCache struct {
+	Enabled    bool `yaml:"enabled,omitempty"`
+	MaxTTL     int  `yaml:"maxTtl,omitempty"`     // seconds a result is kept at most, default 3600
+	MaxEntries int  `yaml:"maxEntries,omitempty"` // default 1000
+}
+
+const (
+	AUTH_EVENT_DELIVERY_SYNC  = "sync"
+	AUTH_EVENT_DELIVERY_ASYNC = "async"
+)
+
+// AuthEventDelivery selects how ConfirmAuth and DeleteAuth events reach the UDM.
+// In "sync" mode (default) a UDM failure fails the AMF request; in "async" mode the
+// events are queued and retried in the background.
+type AuthEventDelivery struct {
+	Mode               string `yaml:"mode,omitempty"`
+	MaxAttempts        int    `yaml:"maxAttempts,omitempty"`
+	RetryInterval      int    `yaml:"retryInterval,omitempty"` // seconds
+	PersistencePath    string `yaml:"persistencePath,omitempty"`
+	PersistenceKeyFile string `yaml:"persistenceKeyFile,omitempty"` // default persistencePath + ".key"
+}
+
+// Admin enables the operator API on a separate listener. Requests must carry
+// "Authorization: Bearer <token>" with the configured token.
+type Admin struct {
+	BindingAddress string `yaml:"bindingAddress,omitempty"` // default 127.0.0.1
+	Port           int    `yaml:"port,omitempty"`           // default 9090
+	Token          string `yaml:"token,omitempty"`
+	TokenFile      string `yaml:"tokenFile,omitempty"` // file holding the token, used when token is empty
+}
+
+const (
+	DEFAULT_METRICS_PORT = 8080
+	DEFAULT_METRICS_PATH = "/metrics"
+)
+
+// Metrics configures the listener serving the Prometheus metrics and the /healthz
+// and /readyz probes. It listens on all interfaces on port 8080 by default.
+type Metrics struct {
+	BindingAddress string      `yaml:"bindingAddress,omitempty"`
+	Port           int         `yaml:"port,omitempty"` // default 8080
+	Path           string      `yaml:"path,omitempty"` // default /metrics
+	TLS            *MetricsTLS `yaml:"tls,omitempty"`
+}
+
+// MetricsTLS serves the metrics listener over HTTPS with the given certificate and key
+type MetricsTLS struct {
 	PEM string `yaml:"pem,omitempty"`
 	Key string `yaml:"key,omitempty"`
 }
 
+// Tracing exports OpenTelemetry traces over OTLP/HTTP. W3C trace context is
+// propagated on the SBI whether or not tracing is configured.
+type Tracing struct {
+	Endpoint    string  `yaml:"endpoint,omitempty"`    // collector URL, e.g. http://otel-collector:4318; path defaults to /v1/traces
+	SampleRatio float64 `yaml:"sampleRatio,omitempty"` // fraction of new traces that are sampled, default 1
+}
+
+const (
+	LOG_ENCODING_CONSOLE = "console"
+	LOG_ENCODING_JSON    = "json"
+)
+
+// Logging selects the log encoding and where the logs are written. Output paths are
+// file paths or stdout/stderr.
+type Logging struct {
+	Encoding    string   `yaml:"encoding,omitempty"`    // console (default) or json
+	OutputPaths []string `yaml:"outputPaths,omitempty"` // default stdout
+}
+
+const (
+	AUDIT_OUTPUT_FILE   = "file"
+	AUDIT_OUTPUT_SYSLOG = "syslog"
+)
+
+// Audit writes a hash-chained record of every authentication decision to a rotating
+// file or to syslog. Auditing is disabled when the section is absent.
+type Audit struct {
+	Output         string `yaml:"output,omitempty"`         // file (default) or syslog
+	Path           string `yaml:"path,omitempty"`           // audit file, required with the file output
+	KeyFile        string `yaml:"keyFile,omitempty"`        // key the records are hashed with, kept apart from the audit file
+	MaxSizeMB      int    `yaml:"maxSizeMb,omitempty"`      // size at which the file is rotated, default 100
+	MaxBackups     int    `yaml:"maxBackups,omitempty"`     // rotated files kept, default 5
+	SyslogNetwork  string `yaml:"syslogNetwork,omitempty"`  // udp or tcp; the local syslog daemon when empty
+	SyslogAddress  string `yaml:"syslogAddress,omitempty"`  // host:port of a remote syslog server
+	MaskIdentities bool   `yaml:"maskIdentities,omitempty"` // mask SUPIs and SUCIs as in the operational logs
+}
+
+const (
+	DEFAULT_LOAD_MAX_IN_FLIGHT_REQUESTS = 100
+	DEFAULT_LOAD_MAX_UE_CONTEXTS        = 100000
+)
+
+// NfProfile sets the NF profile attributes the AMFs use to select and load-balance
+// across AUSF instances, and the values at which the AUSF reports a load of 100%.
+type NfProfile struct {
+	Capacity            int      `yaml:"capacity,omitempty"`            // static capacity relative to other AUSFs, 0-65535; not advertised when 0
+	Priority            int      `yaml:"priority,omitempty"`            // lower values are preferred, 0-65535; not advertised when 0
+	Locality            string   `yaml:"locality,omitempty"`            // e.g. the data center or region of the AUSF
+	NfSetIdList         []string `yaml:"nfSetIdList,omitempty"`         // NF sets the AUSF belongs to
+	MaxInFlightRequests int      `yaml:"maxInFlightRequests,omitempty"` // in-flight nausf-auth requests reported as full load, default 100
+	MaxUeContexts       int      `yaml:"maxUeContexts,omitempty"`       // authentications waiting for their confirmation reported as full load, default 100000
+}
+
+// AusfInfo is advertised in the NF profile so that AMFs discover the AUSF serving a
+// SUPI or routing indicator. The webui can replace it at runtime.
+type AusfInfo struct {
+	SupiRanges        []SupiRange `yaml:"supiRanges,omitempty" json:"supiRanges,omitempty"`
+	RoutingIndicators []string    `yaml:"routingIndicators,omitempty" json:"routingIndicators,omitempty"` // 1 to 4 digits
+	SuciInfos         []SuciInfo  `yaml:"suciInfos,omitempty" json:"suciInfos,omitempty"`
+}
+
+// SupiRange is either a range of SUPIs from start to end, digits only and of the same
+// length, or a regular expression matching the SUPIs
+type SupiRange struct {
+	Start   string `yaml:"start,omitempty" json:"start,omitempty"`
+	End     string `yaml:"end,omitempty" json:"end,omitempty"`
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+}
+
+// SuciInfo lists the home network public key IDs of the SUCI protection schemes the AUSF
+// supports for the given routing indicators
+type SuciInfo struct {
+	RoutingInds  []string `yaml:"routingInds,omitempty" json:"routingInds,omitempty"`
+	HNwPubKeyIds []int    `yaml:"hNwPubKeyIds,omitempty" json:"hNwPubKeyIds,omitempty"` // 0-255
+}
+
+const (
+	CONFIG_UPDATES_MODE_POLL = "poll"
+	CONFIG_UPDATES_MODE_PUSH = "push"
+
+	DEFAULT_POLLING_INTERVAL    = 5  // seconds
+	DEFAULT_POLLING_MAX_BACKOFF = 40 // seconds
+	DEFAULT_POLLING_JITTER      = 20 // percent
+)
+
+// ConfigUpdates selects how the PLMN configuration and the AUSF policy are received
+// from the webconsole: polled, or pushed over a server-sent events stream with
+// polling as a fallback while the stream is down
+type ConfigUpdates struct {
+	Mode              string `yaml:"mode,omitempty"`              // poll (default) or push
+	PollingInterval   int    `yaml:"pollingInterval,omitempty"`   // seconds between polls, default 5
+	PollingMaxBackoff int    `yaml:"pollingMaxBackoff,omitempty"` // seconds the interval grows to while polling fails, default 40
+	PollingJitter     int    `yaml:"pollingJitter,omitempty"`     // percent by which each interval is randomly shortened, 1-100, default 20
+}
+
+// AusfPolicy is the AUSF-specific policy served by the webconsole at /nfconfig/ausf-policy
+type AusfPolicy struct {
+	AllowedServingNetworks []string         `json:"allowedServingNetworks,omitempty"` // every serving network is allowed when empty
+	PlmnPolicies           []PlmnAuthPolicy `json:"plmnPolicies,omitempty"`
+	AusfInfo               *AusfInfo        `json:"ausfInfo,omitempty"` // replaces the configured ausfInfo
+}
+
+// PlmnAuthPolicy sets how the UEs served by a PLMN are authenticated
+type PlmnAuthPolicy struct {
+	PlmnId              models.PlmnId `json:"plmnId"`
+	PreferredAuthMethod string        `json:"preferredAuthMethod,omitempty"` // 5G_AKA or EAP_AKA_PRIME
+	AkmaEnabled         bool          `json:"akmaEnabled,omitempty"`         // accepted, AKMA keys are not derived as AAnF is not supported
+}
+
 type Security struct {
 	IntegrityOrder []string `yaml:"integrityOrder,omitempty"`
 	CipheringOrder []string `yaml:"cipheringOrder,omitempty"`
@@ -69,3 +285,14 @@ func (c *Config) GetVersion() string {
 	}
 	return ""
 }
+
+// GetNrfUris returns the NRFs in order of preference: nrfUri, when set, then nrfUris
+func (c *Configuration) GetNrfUris() []string {
+	var uris []string
+	for _, uri := range append([]string{c.NrfUri}, c.NrfUris...) {
+		if uri != "" && !slices.Contains(uris, uri) {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * AUSF Configuration Environment Overrides
+ */
+
+package factory
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/omec-project/ausf/logger"
+)
+
+const envPrefix = "AUSF"
+
+// applyEnvOverrides overrides configuration fields with AUSF_* environment variables.
+// The variable name is the YAML path of the field in upper snake case, without the
+// top-level "configuration" key: configuration.sbi.registerIPv4 is AUSF_SBI_REGISTER_IPV4
+// and logger.AUSF.debugLevel is AUSF_LOGGER_AUSF_DEBUG_LEVEL. Lists are comma separated.
+// Precedence: environment variable, then YAML value, then built-in default.
+func applyEnvOverrides(cfg *Config) error {
+	resolveLegacyBindingIPv4(cfg)
+	_, err := applyEnvToStruct(reflect.ValueOf(cfg).Elem(), envPrefix, true)
+	return err
+}
+
+// applyEnvToStruct walks the yaml-tagged fields of v and reports whether any of them was set.
+// Nil struct pointers are only allocated when one of their fields is overridden.
+func applyEnvToStruct(v reflect.Value, prefix string, root bool) (bool, error) {
+	applied := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if name == "" || !field.IsExported() {
+			continue
+		}
+		envName := prefix + "_" + toUpperSnake(name)
+		if root && name == "configuration" {
+			envName = prefix
+		}
+		fieldValue := v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+			target := fieldValue
+			if fieldValue.IsNil() {
+				target = reflect.New(field.Type.Elem())
+			}
+			set, err := applyEnvToStruct(target.Elem(), envName, false)
+			if err != nil {
+				return applied, err
+			}
+			if set && fieldValue.IsNil() {
+				fieldValue.Set(target)
+			}
+			applied = applied || set
+		case field.Type.Kind() == reflect.Struct:
+			set, err := applyEnvToStruct(fieldValue, envName, false)
+			if err != nil {
+				return applied, err
+			}
+			applied = applied || set
+		default:
+			value, ok := os.LookupEnv(envName)
+			if !ok {
+				continue
+			}
+			if err := setFromEnv(fieldValue, envName, value); err != nil {
+				return applied, err
+			}
+			logger.CfgLog.Infof("%s overridden by environment variable %s", name, envName)
+			applied = true
+		}
+	}
+	return applied, nil
+}
+
+func setFromEnv(v reflect.Value, envName, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer in %s: %q", envName, value)
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean in %s: %q", envName, value)
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number in %s: %q", envName, value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s cannot be set from the environment", envName)
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s cannot be set from the environment", envName)
+	}
+	return nil
+}
+
+// resolveLegacyBindingIPv4 keeps supporting configurations where bindingIPv4 holds the
+// name of an environment variable (e.g. POD_IP) instead of an address.
+func resolveLegacyBindingIPv4(cfg *Config) {
+	if cfg.Configuration == nil || cfg.Configuration.Sbi == nil {
+		return
+	}
+	sbi := cfg.Configuration.Sbi
+	if sbi.BindingIPv4 == "" || net.ParseIP(sbi.BindingIPv4) != nil {
+		return
+	}
+	if value := os.Getenv(sbi.BindingIPv4); value != "" {
+		logger.CfgLog.Warnf("bindingIPv4 refers to environment variable %s; this is deprecated, use %s_SBI_BINDING_IPV4 instead",
+			sbi.BindingIPv4, envPrefix)
+		sbi.BindingIPv4 = value
+	}
+}
+
+func yamlName(field reflect.StructField) string {
+	tag := field.Tag.Get("yaml")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// toUpperSnake converts a YAML key to upper snake case, starting a new word at every
+// upper case letter that follows a lower case letter or a digit: registerIPv4 is REGISTER_IPV4.
+func toUpperSnake(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
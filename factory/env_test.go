@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for AUSF Configuration Environment Overrides
+ */
+
+package factory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const envTestConfig = `info:
+  version: 1.0.0
+configuration:
+  nrfUri: http://nrf:8081
+  webuiUri: http://webui:5001
+  sbi:
+    scheme: http
+    bindingIPv4: 0.0.0.0
+    registerIPv4: 1.1.1.1
+    port: 29509
+  serviceNameList:
+    - nausf-auth
+logger:
+  AUSF:
+    debugLevel: info
+`
+
+func readConfigForTest(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "ausfcfg.yaml")
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return ReadConfig(configFile)
+}
+
+func TestToUpperSnake(t *testing.T) {
+	testCases := map[string]string{
+		"nrfUri":                   "NRF_URI",
+		"registerIPv4":             "REGISTER_IPV4",
+		"bindingIPv6":              "BINDING_IPV6",
+		"nrfCacheEvictionInterval": "NRF_CACHE_EVICTION_INTERVAL",
+		"initialBackoffMs":         "INITIAL_BACKOFF_MS",
+		"pem":                      "PEM",
+		"AUSF":                     "AUSF",
+		"OpenApi":                  "OPEN_API",
+	}
+	for name, expected := range testCases {
+		if got := toUpperSnake(name); got != expected {
+			t.Errorf("toUpperSnake(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestReadConfig_EnvironmentOverridesYaml(t *testing.T) {
+	t.Setenv("AUSF_NRF_URI", "https://nrf-override:29510")
+	t.Setenv("AUSF_SBI_PORT", "8443")
+	t.Setenv("AUSF_SBI_REGISTER_IPV4", "10.0.0.9")
+	t.Setenv("AUSF_ENABLE_NRF_CACHING", "true")
+	t.Setenv("AUSF_SERVICE_NAME_LIST", "nausf-auth, nausf-sorprotection")
+	t.Setenv("AUSF_LOGGER_AUSF_DEBUG_LEVEL", "debug")
+	t.Setenv("AUSF_RESILIENCE_RETRY_MAX_ATTEMPTS", "5")
+
+	cfg, err := readConfigForTest(t, envTestConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	configuration := cfg.Configuration
+	if configuration.NrfUri != "https://nrf-override:29510" {
+		t.Errorf("expected overridden nrfUri, got %s", configuration.NrfUri)
+	}
+	if configuration.Sbi.Port != 8443 {
+		t.Errorf("expected overridden port, got %d", configuration.Sbi.Port)
+	}
+	if configuration.Sbi.RegisterIPv4 != "10.0.0.9" {
+		t.Errorf("expected overridden registerIPv4, got %s", configuration.Sbi.RegisterIPv4)
+	}
+	if configuration.Sbi.BindingIPv4 != "0.0.0.0" {
+		t.Errorf("expected YAML bindingIPv4 to be kept, got %s", configuration.Sbi.BindingIPv4)
+	}
+	if !configuration.EnableNrfCaching {
+		t.Error("expected NRF caching to be enabled by the environment")
+	}
+	if expected := []string{"nausf-auth", "nausf-sorprotection"}; !reflect.DeepEqual(configuration.ServiceNameList, expected) {
+		t.Errorf("expected serviceNameList %v, got %v", expected, configuration.ServiceNameList)
+	}
+	if cfg.Logger.AUSF.DebugLevel != "debug" {
+		t.Errorf("expected overridden log level, got %s", cfg.Logger.AUSF.DebugLevel)
+	}
+	if configuration.Resilience == nil || configuration.Resilience.Retry == nil || configuration.Resilience.Retry.MaxAttempts != 5 {
+		t.Errorf("expected resilience section to be created from the environment, got %+v", configuration.Resilience)
+	}
+	if configuration.AuthEventDelivery != nil {
+		t.Errorf("expected sections without overrides to stay unset, got %+v", configuration.AuthEventDelivery)
+	}
+}
+
+func TestReadConfig_EnvironmentOverridesDefault(t *testing.T) {
+	t.Setenv("AUSF_WEBUI_URI", "https://webui-override:5001")
+
+	cfg, err := readConfigForTest(t, "info:\n  version: 1.0.0\nconfiguration:\n  nrfUri: http://nrf:8081\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Configuration.WebuiUri != "https://webui-override:5001" {
+		t.Errorf("expected webuiUri from the environment instead of the default, got %s", cfg.Configuration.WebuiUri)
+	}
+}
+
+func TestReadConfig_InvalidEnvironmentValue(t *testing.T) {
+	t.Setenv("AUSF_SBI_PORT", "not-a-port")
+
+	if _, err := readConfigForTest(t, envTestConfig); err == nil {
+		t.Error("expected an error for a non-numeric AUSF_SBI_PORT")
+	}
+}
+
+func TestReadConfig_LegacyBindingIPv4EnvironmentVariable(t *testing.T) {
+	t.Setenv("MY_POD_IP", "192.168.1.10")
+
+	cfg, err := readConfigForTest(t, "configuration:\n  sbi:\n    bindingIPv4: MY_POD_IP\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Configuration.Sbi.BindingIPv4 != "192.168.1.10" {
+		t.Errorf("expected bindingIPv4 to be resolved from MY_POD_IP, got %s", cfg.Configuration.Sbi.BindingIPv4)
+	}
+}
@@ -20,28 +20,47 @@ import (
 
 var AusfConfig Config
 
-// TODO: Support configuration update from REST api
 func InitConfigFactory(f string) error {
+	AusfConfig = Config{}
+	cfg, err := ReadConfig(f)
+	if cfg != nil {
+		AusfConfig = *cfg
+	}
+	return err
+}
+
+// ReadConfig parses the configuration file and applies the environment overrides and the
+// default webuiUri without modifying AusfConfig, so that a reloaded file can be checked with
+// Validate before it is applied.
+func ReadConfig(f string) (*Config, error) {
 	content, err := os.ReadFile(f)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	AusfConfig = Config{}
+	cfg := &Config{}
 
-	if err = yaml.Unmarshal(content, &AusfConfig); err != nil {
-		return err
+	if err = yaml.Unmarshal(content, cfg); err != nil {
+		return nil, err
 	}
-	if AusfConfig.Configuration.WebuiUri == "" {
-		AusfConfig.Configuration.WebuiUri = "http://webui:5001"
-		logger.CfgLog.Infof("webuiUri not set in configuration file. Using %v", AusfConfig.Configuration.WebuiUri)
-		return nil
+	if err = applyEnvOverrides(cfg); err != nil {
+		return nil, err
 	}
-	err = validateWebuiUri(AusfConfig.Configuration.WebuiUri)
-	return err
+	if cfg.Configuration == nil {
+		return nil, fmt.Errorf("missing configuration section in %s", f)
+	}
+	if cfg.Configuration.WebuiUri == "" {
+		cfg.Configuration.WebuiUri = "http://webui:5001"
+		logger.CfgLog.Infof("webuiUri not set in configuration file. Using %v", cfg.Configuration.WebuiUri)
+	}
+	return cfg, nil
 }
 
 func CheckConfigVersion() error {
-	currentVersion := AusfConfig.GetVersion()
+	return AusfConfig.CheckVersion()
+}
+
+func (c *Config) CheckVersion() error {
+	currentVersion := c.GetVersion()
 
 	if currentVersion != AUSF_EXPECTED_CONFIG_VERSION {
 		return fmt.Errorf("config version is [%s], but expected is [%s]",
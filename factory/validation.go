@@ -0,0 +1,527 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * AUSF Configuration Validation
+ */
+
+package factory
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/omec-project/openapi/v2/models"
+	utilLogger "github.com/omec-project/util/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	fqdnRegex    = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.?$`)
+	groupIdRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	supiRegex    = regexp.MustCompile(`^[0-9]+$`)
+	routingRegex = regexp.MustCompile(`^[0-9]{1,4}$`)
+	mccRegex     = regexp.MustCompile(`^[0-9]{3}$`)
+	mncRegex     = regexp.MustCompile(`^[0-9]{2,3}$`)
+
+	servingNetworkRegex = regexp.MustCompile(`^5G:mnc[0-9]{3}[.]mcc[0-9]{3}[.]3gppnetwork[.]org$`)
+)
+
+// supportedServiceNames lists the services the AUSF can expose in serviceNameList
+var supportedServiceNames = []string{string(models.SERVICENAME_NAUSF_AUTH)}
+
+// ValidationError collects every problem found in a configuration. Each problem is
+// prefixed with the YAML path of the offending field.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// Validate checks the whole configuration and returns a *ValidationError listing all
+// problems found, or nil when the configuration is valid.
+func (c *Config) Validate() error {
+	v := &validator{}
+	if c.Info == nil {
+		v.addf("info", "missing")
+	} else if c.Info.Version != AUSF_EXPECTED_CONFIG_VERSION {
+		v.addf("info.version", "is [%s], but expected is [%s]", c.Info.Version, AUSF_EXPECTED_CONFIG_VERSION)
+	}
+	if c.Configuration == nil {
+		v.addf("configuration", "missing")
+	} else {
+		v.validateConfiguration(c.Configuration)
+	}
+	v.validateLogger(c.Logger)
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (v *validator) validateConfiguration(cfg *Configuration) {
+	v.validateSbi(cfg.Sbi)
+	switch {
+	case cfg.NrfUri != "":
+		v.validateUri("configuration.nrfUri", cfg.NrfUri)
+	case len(cfg.NrfUris) == 0:
+		v.addf("configuration.nrfUri", "missing")
+	}
+	for i, nrfUri := range cfg.NrfUris {
+		v.validateUri(fmt.Sprintf("configuration.nrfUris[%d]", i), nrfUri)
+	}
+	if cfg.WebuiUri != "" {
+		if err := validateWebuiUri(cfg.WebuiUri); err != nil {
+			v.addf("configuration.webuiUri", "%v", err)
+		}
+	}
+	for i, serviceName := range cfg.ServiceNameList {
+		if !isSupportedServiceName(serviceName) {
+			v.addf(fmt.Sprintf("configuration.serviceNameList[%d]", i), "unsupported service %q, expected one of %v",
+				serviceName, supportedServiceNames)
+		}
+	}
+	if cfg.GroupId != "" && !groupIdRegex.MatchString(cfg.GroupId) {
+		v.addf("configuration.groupId", "invalid value %q", cfg.GroupId)
+	}
+	if cfg.NrfCacheEvictionInterval < 0 {
+		v.addf("configuration.nrfCacheEvictionInterval", "must not be negative")
+	}
+	v.validateResilience(cfg.Resilience)
+	v.validateAuthEventDelivery(cfg.AuthEventDelivery)
+	v.validateAdmin(cfg.Admin)
+	v.validateMetrics(cfg.Metrics)
+	v.validateTracing(cfg.Tracing)
+	v.validateLogging(cfg.Logging)
+	v.validateAudit(cfg.Audit)
+	v.validateNfProfile(cfg.NfProfile)
+	v.validateAusfInfo("configuration.ausfInfo", cfg.AusfInfo)
+	v.validateConfigUpdates(cfg.ConfigUpdates)
+	v.validateDiscoveryCache(cfg.DiscoveryCache)
+}
+
+func (v *validator) validateSbi(sbi *Sbi) {
+	if sbi == nil {
+		v.addf("configuration.sbi", "missing")
+		return
+	}
+	if sbi.Scheme != "http" && sbi.Scheme != "https" {
+		v.addf("configuration.sbi.scheme", "must be http or https, got %q", sbi.Scheme)
+	}
+	if sbi.Port < 0 || sbi.Port > 65535 {
+		v.addf("configuration.sbi.port", "must be between 1 and 65535, or 0 for the default, got %d", sbi.Port)
+	}
+	if sbi.DrainTimeout < 0 {
+		v.addf("configuration.sbi.drainTimeout", "must not be negative")
+	}
+	v.validateIP("configuration.sbi.registerIPv4", sbi.RegisterIPv4, false)
+	v.validateIP("configuration.sbi.registerIPv6", sbi.RegisterIPv6, true)
+	v.validateIP("configuration.sbi.bindingIPv4", sbi.BindingIPv4, false)
+	v.validateIP("configuration.sbi.bindingIPv6", sbi.BindingIPv6, true)
+	if sbi.RegisterFqdn != "" && !fqdnRegex.MatchString(sbi.RegisterFqdn) {
+		v.addf("configuration.sbi.registerFqdn", "invalid FQDN %q", sbi.RegisterFqdn)
+	}
+	v.validateTLS(sbi.TLS, sbi.Scheme == "https")
+}
+
+func (v *validator) validateTLS(tls *TLS, serveTLS bool) {
+	if tls == nil {
+		if serveTLS {
+			v.addf("configuration.sbi.tls", "missing, required by the https scheme")
+		}
+		return
+	}
+	if serveTLS {
+		if tls.PEM == "" {
+			v.addf("configuration.sbi.tls.pem", "missing, required by the https scheme")
+		}
+		if tls.Key == "" {
+			v.addf("configuration.sbi.tls.key", "missing, required by the https scheme")
+		}
+	}
+	v.validateFile("configuration.sbi.tls.pem", tls.PEM)
+	v.validateFile("configuration.sbi.tls.key", tls.Key)
+	v.validateFile("configuration.sbi.tls.ca", tls.CA)
+	v.validateFile("configuration.sbi.tls.clientPem", tls.ClientPEM)
+	v.validateFile("configuration.sbi.tls.clientKey", tls.ClientKey)
+	if (tls.ClientPEM == "") != (tls.ClientKey == "") {
+		v.addf("configuration.sbi.tls", "clientPem and clientKey must be configured together")
+	}
+	switch tls.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		v.addf("configuration.sbi.tls.minVersion", "must be 1.2 or 1.3, got %q", tls.MinVersion)
+	}
+	if tls.ReloadInterval < 0 {
+		v.addf("configuration.sbi.tls.reloadInterval", "must not be negative")
+	}
+}
+
+func (v *validator) validateResilience(resilience *Resilience) {
+	if resilience == nil {
+		return
+	}
+	if retry := resilience.Retry; retry != nil {
+		if retry.MaxAttempts < 0 {
+			v.addf("configuration.resilience.retry.maxAttempts", "must not be negative")
+		}
+		if retry.InitialBackoffMs < 0 {
+			v.addf("configuration.resilience.retry.initialBackoffMs", "must not be negative")
+		}
+		if retry.MaxBackoffMs < 0 {
+			v.addf("configuration.resilience.retry.maxBackoffMs", "must not be negative")
+		}
+		if retry.Multiplier != 0 && retry.Multiplier < 1 {
+			v.addf("configuration.resilience.retry.multiplier", "must be at least 1, got %v", retry.Multiplier)
+		}
+	}
+	if breaker := resilience.CircuitBreaker; breaker != nil {
+		if breaker.FailureThreshold < 0 {
+			v.addf("configuration.resilience.circuitBreaker.failureThreshold", "must not be negative")
+		}
+		if breaker.OpenTimeout < 0 {
+			v.addf("configuration.resilience.circuitBreaker.openTimeout", "must not be negative")
+		}
+	}
+}
+
+func (v *validator) validateAuthEventDelivery(delivery *AuthEventDelivery) {
+	if delivery == nil {
+		return
+	}
+	switch delivery.Mode {
+	case "", AUTH_EVENT_DELIVERY_SYNC, AUTH_EVENT_DELIVERY_ASYNC:
+	default:
+		v.addf("configuration.authEventDelivery.mode", "must be %s or %s, got %q",
+			AUTH_EVENT_DELIVERY_SYNC, AUTH_EVENT_DELIVERY_ASYNC, delivery.Mode)
+	}
+	if delivery.MaxAttempts < 0 {
+		v.addf("configuration.authEventDelivery.maxAttempts", "must not be negative")
+	}
+	if delivery.RetryInterval < 0 {
+		v.addf("configuration.authEventDelivery.retryInterval", "must not be negative")
+	}
+}
+
+func (v *validator) validateAdmin(admin *Admin) {
+	if admin == nil {
+		return
+	}
+	if admin.Port < 0 || admin.Port > 65535 {
+		v.addf("configuration.admin.port", "must be between 1 and 65535, or 0 for the default, got %d", admin.Port)
+	}
+	if admin.BindingAddress != "" && net.ParseIP(admin.BindingAddress) == nil {
+		v.addf("configuration.admin.bindingAddress", "invalid IP address %q", admin.BindingAddress)
+	}
+	if admin.Token == "" && admin.TokenFile == "" {
+		v.addf("configuration.admin", "token or tokenFile is required")
+	}
+	v.validateFile("configuration.admin.tokenFile", admin.TokenFile)
+}
+
+func (v *validator) validateMetrics(metrics *Metrics) {
+	if metrics == nil {
+		return
+	}
+	if metrics.Port < 0 || metrics.Port > 65535 {
+		v.addf("configuration.metrics.port", "must be between 1 and 65535, or 0 for the default, got %d", metrics.Port)
+	}
+	if metrics.BindingAddress != "" && net.ParseIP(metrics.BindingAddress) == nil {
+		v.addf("configuration.metrics.bindingAddress", "invalid IP address %q", metrics.BindingAddress)
+	}
+	switch {
+	case metrics.Path == "":
+	case !strings.HasPrefix(metrics.Path, "/"):
+		v.addf("configuration.metrics.path", "must start with /, got %q", metrics.Path)
+	case metrics.Path == "/healthz" || metrics.Path == "/readyz":
+		v.addf("configuration.metrics.path", "%s is reserved for the health probes", metrics.Path)
+	}
+	if tls := metrics.TLS; tls != nil {
+		if tls.PEM == "" || tls.Key == "" {
+			v.addf("configuration.metrics.tls", "pem and key must be configured together")
+		}
+		v.validateFile("configuration.metrics.tls.pem", tls.PEM)
+		v.validateFile("configuration.metrics.tls.key", tls.Key)
+	}
+}
+
+func (v *validator) validateTracing(tracing *Tracing) {
+	if tracing == nil {
+		return
+	}
+	if tracing.Endpoint == "" {
+		v.addf("configuration.tracing.endpoint", "missing")
+	} else {
+		v.validateUri("configuration.tracing.endpoint", tracing.Endpoint)
+	}
+	if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+		v.addf("configuration.tracing.sampleRatio", "must be between 0 and 1, got %v", tracing.SampleRatio)
+	}
+}
+
+func (v *validator) validateLogging(logging *Logging) {
+	if logging == nil {
+		return
+	}
+	switch logging.Encoding {
+	case "", LOG_ENCODING_CONSOLE, LOG_ENCODING_JSON:
+	default:
+		v.addf("configuration.logging.encoding", "must be %s or %s, got %q", LOG_ENCODING_CONSOLE, LOG_ENCODING_JSON, logging.Encoding)
+	}
+	for i, path := range logging.OutputPaths {
+		if path == "" {
+			v.addf(fmt.Sprintf("configuration.logging.outputPaths[%d]", i), "empty path")
+		}
+	}
+}
+
+func (v *validator) validateConfigUpdates(configUpdates *ConfigUpdates) {
+	if configUpdates == nil {
+		return
+	}
+	switch configUpdates.Mode {
+	case "", CONFIG_UPDATES_MODE_POLL, CONFIG_UPDATES_MODE_PUSH:
+	default:
+		v.addf("configuration.configUpdates.mode", "must be %s or %s, got %q",
+			CONFIG_UPDATES_MODE_POLL, CONFIG_UPDATES_MODE_PUSH, configUpdates.Mode)
+	}
+	if configUpdates.PollingInterval < 0 {
+		v.addf("configuration.configUpdates.pollingInterval", "must not be negative")
+	}
+	if configUpdates.PollingMaxBackoff < 0 {
+		v.addf("configuration.configUpdates.pollingMaxBackoff", "must not be negative")
+	} else if configUpdates.PollingMaxBackoff > 0 && configUpdates.PollingMaxBackoff < configUpdates.PollingInterval {
+		v.addf("configuration.configUpdates.pollingMaxBackoff", "must not be less than pollingInterval")
+	}
+	if configUpdates.PollingJitter < 0 || configUpdates.PollingJitter > 100 {
+		v.addf("configuration.configUpdates.pollingJitter", "must be between 1 and 100, got %d", configUpdates.PollingJitter)
+	}
+}
+
+func (v *validator) validateDiscoveryCache(discoveryCache *DiscoveryCache) {
+	if discoveryCache == nil {
+		return
+	}
+	if discoveryCache.MaxTTL < 0 {
+		v.addf("configuration.discoveryCache.maxTtl", "must not be negative")
+	}
+	if discoveryCache.MaxEntries < 0 {
+		v.addf("configuration.discoveryCache.maxEntries", "must not be negative")
+	}
+}
+
+func (v *validator) validateAudit(audit *Audit) {
+	if audit == nil {
+		return
+	}
+	if audit.KeyFile == "" {
+		v.addf("configuration.audit.keyFile", "required")
+	}
+	v.validateFile("configuration.audit.keyFile", audit.KeyFile)
+	switch audit.Output {
+	case "", AUDIT_OUTPUT_FILE:
+		if audit.Path == "" {
+			v.addf("configuration.audit.path", "required with the %s output", AUDIT_OUTPUT_FILE)
+		}
+	case AUDIT_OUTPUT_SYSLOG:
+		switch audit.SyslogNetwork {
+		case "":
+			if audit.SyslogAddress != "" {
+				v.addf("configuration.audit.syslogNetwork", "required with syslogAddress")
+			}
+		case "udp", "tcp":
+			if _, _, err := net.SplitHostPort(audit.SyslogAddress); err != nil {
+				v.addf("configuration.audit.syslogAddress", "must be host:port, got %q", audit.SyslogAddress)
+			}
+		default:
+			v.addf("configuration.audit.syslogNetwork", "must be udp or tcp, got %q", audit.SyslogNetwork)
+		}
+	default:
+		v.addf("configuration.audit.output", "must be %s or %s, got %q", AUDIT_OUTPUT_FILE, AUDIT_OUTPUT_SYSLOG, audit.Output)
+	}
+	if audit.MaxSizeMB < 0 {
+		v.addf("configuration.audit.maxSizeMb", "must not be negative")
+	}
+	if audit.MaxBackups < 0 {
+		v.addf("configuration.audit.maxBackups", "must not be negative")
+	}
+}
+
+func (v *validator) validateNfProfile(profile *NfProfile) {
+	if profile == nil {
+		return
+	}
+	if profile.Capacity < 0 || profile.Capacity > 65535 {
+		v.addf("configuration.nfProfile.capacity", "must be between 0 and 65535, got %d", profile.Capacity)
+	}
+	if profile.Priority < 0 || profile.Priority > 65535 {
+		v.addf("configuration.nfProfile.priority", "must be between 0 and 65535, got %d", profile.Priority)
+	}
+	for i, nfSetId := range profile.NfSetIdList {
+		if nfSetId == "" {
+			v.addf(fmt.Sprintf("configuration.nfProfile.nfSetIdList[%d]", i), "empty NF set ID")
+		}
+	}
+	if profile.MaxInFlightRequests < 0 {
+		v.addf("configuration.nfProfile.maxInFlightRequests", "must not be negative")
+	}
+	if profile.MaxUeContexts < 0 {
+		v.addf("configuration.nfProfile.maxUeContexts", "must not be negative")
+	}
+}
+
+// ValidateAusfPolicy checks the AUSF policy received from the webconsole
+func ValidateAusfPolicy(policy *AusfPolicy) error {
+	v := &validator{}
+	for i, servingNetwork := range policy.AllowedServingNetworks {
+		if !servingNetworkRegex.MatchString(servingNetwork) {
+			v.addf(fmt.Sprintf("allowedServingNetworks[%d]", i), "invalid serving network name %q", servingNetwork)
+		}
+	}
+	for i, plmnPolicy := range policy.PlmnPolicies {
+		path := fmt.Sprintf("plmnPolicies[%d]", i)
+		if !mccRegex.MatchString(plmnPolicy.PlmnId.Mcc) || !mncRegex.MatchString(plmnPolicy.PlmnId.Mnc) {
+			v.addf(path+".plmnId", "invalid PLMN ID %+v", plmnPolicy.PlmnId)
+		}
+		switch models.AuthType(plmnPolicy.PreferredAuthMethod) {
+		case "", models.AUTHTYPE__5_G_AKA, models.AUTHTYPE_EAP_AKA_PRIME:
+		default:
+			v.addf(path+".preferredAuthMethod", "must be %s or %s, got %q",
+				models.AUTHTYPE__5_G_AKA, models.AUTHTYPE_EAP_AKA_PRIME, plmnPolicy.PreferredAuthMethod)
+		}
+	}
+	v.validateAusfInfo("ausfInfo", policy.AusfInfo)
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (v *validator) validateAusfInfo(path string, info *AusfInfo) {
+	if info == nil {
+		return
+	}
+	for i, supiRange := range info.SupiRanges {
+		rangePath := fmt.Sprintf("%s.supiRanges[%d]", path, i)
+		switch {
+		case supiRange.Pattern != "":
+			if supiRange.Start != "" || supiRange.End != "" {
+				v.addf(rangePath, "pattern cannot be combined with start and end")
+			}
+			if _, err := regexp.Compile(supiRange.Pattern); err != nil {
+				v.addf(rangePath+".pattern", "invalid regular expression: %v", err)
+			}
+		case !supiRegex.MatchString(supiRange.Start) || !supiRegex.MatchString(supiRange.End):
+			v.addf(rangePath, "start and end must be digits, got %q and %q", supiRange.Start, supiRange.End)
+		case len(supiRange.Start) != len(supiRange.End) || supiRange.Start > supiRange.End:
+			v.addf(rangePath, "start %s must not be after end %s and have the same length", supiRange.Start, supiRange.End)
+		}
+	}
+	for i, routingIndicator := range info.RoutingIndicators {
+		if !routingRegex.MatchString(routingIndicator) {
+			v.addf(fmt.Sprintf("%s.routingIndicators[%d]", path, i), "must be 1 to 4 digits, got %q", routingIndicator)
+		}
+	}
+	for i, suciInfo := range info.SuciInfos {
+		for j, routingIndicator := range suciInfo.RoutingInds {
+			if !routingRegex.MatchString(routingIndicator) {
+				v.addf(fmt.Sprintf("%s.suciInfos[%d].routingInds[%d]", path, i, j), "must be 1 to 4 digits, got %q", routingIndicator)
+			}
+		}
+		for j, keyId := range suciInfo.HNwPubKeyIds {
+			if keyId < 0 || keyId > 255 {
+				v.addf(fmt.Sprintf("%s.suciInfos[%d].hNwPubKeyIds[%d]", path, i, j), "must be between 0 and 255, got %d", keyId)
+			}
+		}
+	}
+}
+
+func (v *validator) validateLogger(cfgLogger *utilLogger.Logger) {
+	if cfgLogger == nil {
+		return
+	}
+	settings := []struct {
+		path    string
+		setting *utilLogger.LogSetting
+	}{
+		{"logger.AUSF.debugLevel", cfgLogger.AUSF},
+		{"logger.OpenApi.debugLevel", cfgLogger.OpenApi},
+	}
+	for _, s := range settings {
+		if s.setting == nil || s.setting.DebugLevel == "" {
+			continue
+		}
+		if _, err := zapcore.ParseLevel(s.setting.DebugLevel); err != nil {
+			v.addf(s.path, "invalid log level %q", s.setting.DebugLevel)
+		}
+	}
+}
+
+func (v *validator) validateUri(path, uri string) {
+	parsedUrl, err := url.ParseRequestURI(uri)
+	if err != nil {
+		v.addf(path, "invalid URI %q: %v", uri, err)
+		return
+	}
+	if parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https" {
+		v.addf(path, "unsupported scheme %q", parsedUrl.Scheme)
+		return
+	}
+	if parsedUrl.Hostname() == "" {
+		v.addf(path, "missing host")
+	}
+}
+
+func (v *validator) validateIP(path, address string, ipv6 bool) {
+	if address == "" {
+		return
+	}
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		v.addf(path, "invalid IP address %q", address)
+	case ipv6 && ip.To4() != nil:
+		v.addf(path, "expected an IPv6 address, got %q", address)
+	case !ipv6 && ip.To4() == nil:
+		v.addf(path, "expected an IPv4 address, got %q", address)
+	}
+}
+
+func (v *validator) validateFile(path, file string) {
+	if file == "" {
+		return
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		v.addf(path, "cannot read %s: %v", file, err)
+		return
+	}
+	if info.IsDir() {
+		v.addf(path, "%s is a directory", file)
+	}
+}
+
+func isSupportedServiceName(serviceName string) bool {
+	for _, supported := range supportedServiceNames {
+		if serviceName == supported {
+			return true
+		}
+	}
+	return false
+}
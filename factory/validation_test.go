@@ -0,0 +1,479 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for AUSF Configuration Validation
+ */
+
+package factory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/omec-project/openapi/v2/models"
+	utilLogger "github.com/omec-project/util/logger"
+)
+
+func validConfigForTest(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	pem := filepath.Join(dir, "ausf.pem")
+	key := filepath.Join(dir, "ausf.key")
+	for _, file := range []string{pem, key} {
+		if err := os.WriteFile(file, []byte("test"), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	return &Config{
+		Info: &Info{Version: AUSF_EXPECTED_CONFIG_VERSION},
+		Configuration: &Configuration{
+			Sbi: &Sbi{
+				Scheme:       "https",
+				RegisterIPv4: "10.0.0.9",
+				BindingIPv4:  "0.0.0.0",
+				Port:         29509,
+				TLS:          &TLS{PEM: pem, Key: key},
+			},
+			ServiceNameList: []string{"nausf-auth"},
+			NrfUri:          "https://nrf:29510",
+			WebuiUri:        "http://webui:5001",
+			GroupId:         "ausfGroup001",
+		},
+		Logger: &utilLogger.Logger{AUSF: &utilLogger.LogSetting{DebugLevel: "debug"}},
+	}
+}
+
+func problemsOf(t *testing.T, err error) []string {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a *ValidationError, got %v", err)
+	}
+	return validationErr.Problems
+}
+
+func TestValidate_ValidConfiguration(t *testing.T) {
+	if err := validConfigForTest(t).Validate(); err != nil {
+		t.Errorf("expected configuration to be valid: %v", err)
+	}
+}
+
+func TestValidate_MissingConfigurationSection(t *testing.T) {
+	cfg := &Config{Info: &Info{Version: AUSF_EXPECTED_CONFIG_VERSION}}
+	problems := problemsOf(t, cfg.Validate())
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "configuration: ") {
+		t.Errorf("expected a single problem on configuration, got %v", problems)
+	}
+}
+
+func TestValidate_CollectsAllProblemsWithPaths(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Info.Version = "0.9.0"
+	cfg.Configuration.Sbi.Scheme = "ftp"
+	cfg.Configuration.Sbi.Port = 70000
+	cfg.Configuration.Sbi.DrainTimeout = -1
+	cfg.Configuration.Sbi.RegisterIPv4 = "2001:db8::9"
+	cfg.Configuration.Sbi.TLS.CA = "/does/not/exist/ca.pem"
+	cfg.Configuration.NrfUri = "nrf:29510"
+	cfg.Configuration.WebuiUri = "ftp://webui:21"
+	cfg.Configuration.ServiceNameList = []string{"nausf-auth", "nudm-ueau"}
+	cfg.Configuration.GroupId = "bad group"
+	cfg.Configuration.AuthEventDelivery = &AuthEventDelivery{Mode: "batch"}
+	cfg.Logger.AUSF.DebugLevel = "verbose"
+
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{
+		"info.version",
+		"configuration.sbi.scheme",
+		"configuration.sbi.port",
+		"configuration.sbi.drainTimeout",
+		"configuration.sbi.registerIPv4",
+		"configuration.sbi.tls.ca",
+		"configuration.nrfUri",
+		"configuration.webuiUri",
+		"configuration.serviceNameList[1]",
+		"configuration.groupId",
+		"configuration.authEventDelivery.mode",
+		"logger.AUSF.debugLevel",
+	}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_PortZeroSelectsTheDefault(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Sbi.Port = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected port 0 to select the default port: %v", err)
+	}
+}
+
+func TestValidate_HttpsRequiresCertificate(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Sbi.TLS = nil
+	problems := problemsOf(t, cfg.Validate())
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "configuration.sbi.tls: ") {
+		t.Errorf("expected a single problem on configuration.sbi.tls, got %v", problems)
+	}
+
+	cfg.Configuration.Sbi.Scheme = "http"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected http configuration without TLS to be valid: %v", err)
+	}
+}
+
+func TestValidate_Admin(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Admin = &Admin{Token: "secret"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected admin configuration with a token to be valid: %v", err)
+	}
+
+	cfg.Configuration.Admin = &Admin{BindingAddress: "localhost", Port: 70000}
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{
+		"configuration.admin.port",
+		"configuration.admin.bindingAddress",
+		"configuration.admin",
+	}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_Metrics(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Metrics = &Metrics{BindingAddress: "0.0.0.0", Port: 9089, Path: "/prometheus"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected metrics configuration to be valid: %v", err)
+	}
+
+	cfg.Configuration.Metrics = &Metrics{Port: -1, Path: "/readyz", TLS: &MetricsTLS{PEM: cfg.Configuration.Sbi.TLS.PEM}}
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{
+		"configuration.metrics.port",
+		"configuration.metrics.path",
+		"configuration.metrics.tls",
+	}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_Tracing(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Tracing = &Tracing{Endpoint: "http://otel-collector:4318", SampleRatio: 0.1}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected tracing configuration to be valid: %v", err)
+	}
+
+	cfg.Configuration.Tracing = &Tracing{Endpoint: "otel-collector:4318", SampleRatio: 1.5}
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{
+		"configuration.tracing.endpoint",
+		"configuration.tracing.sampleRatio",
+	}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_Logging(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.Logging = &Logging{Encoding: LOG_ENCODING_JSON, OutputPaths: []string{"stdout", "/var/log/ausf.log"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected logging configuration to be valid: %v", err)
+	}
+
+	cfg.Configuration.Logging = &Logging{Encoding: "logfmt", OutputPaths: []string{"stdout", ""}}
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{
+		"configuration.logging.encoding",
+		"configuration.logging.outputPaths[1]",
+	}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_Audit(t *testing.T) {
+	cfg := validConfigForTest(t)
+	keyFile := cfg.Configuration.Sbi.TLS.Key
+	for _, audit := range []*Audit{
+		{Path: "/var/log/ausf/audit.log", KeyFile: keyFile, MaxSizeMB: 10, MaxBackups: 3},
+		{Output: AUDIT_OUTPUT_SYSLOG, KeyFile: keyFile},
+		{Output: AUDIT_OUTPUT_SYSLOG, KeyFile: keyFile, SyslogNetwork: "tcp", SyslogAddress: "syslog.example.org:514"},
+	} {
+		cfg.Configuration.Audit = audit
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected audit configuration %+v to be valid: %v", audit, err)
+		}
+	}
+
+	testCases := []struct {
+		audit         *Audit
+		expectedPaths []string
+	}{
+		{&Audit{KeyFile: keyFile, MaxSizeMB: -1, MaxBackups: -1}, []string{
+			"configuration.audit.path",
+			"configuration.audit.maxSizeMb",
+			"configuration.audit.maxBackups",
+		}},
+		{&Audit{Path: "/var/log/ausf/audit.log"}, []string{"configuration.audit.keyFile"}},
+		{&Audit{Path: "/var/log/ausf/audit.log", KeyFile: keyFile + ".missing"}, []string{"configuration.audit.keyFile"}},
+		{&Audit{Output: AUDIT_OUTPUT_SYSLOG, KeyFile: keyFile, SyslogAddress: "syslog:514"}, []string{"configuration.audit.syslogNetwork"}},
+		{&Audit{Output: AUDIT_OUTPUT_SYSLOG, KeyFile: keyFile, SyslogNetwork: "udp", SyslogAddress: "syslog"}, []string{"configuration.audit.syslogAddress"}},
+		{&Audit{Output: "kafka", KeyFile: keyFile}, []string{"configuration.audit.output"}},
+	}
+	for _, tc := range testCases {
+		cfg.Configuration.Audit = tc.audit
+		problems := problemsOf(t, cfg.Validate())
+		if len(problems) != len(tc.expectedPaths) {
+			t.Fatalf("expected %d problems, got %d: %v", len(tc.expectedPaths), len(problems), problems)
+		}
+		for i, path := range tc.expectedPaths {
+			if !strings.HasPrefix(problems[i], path+": ") {
+				t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+			}
+		}
+	}
+}
+
+func TestValidate_ConfigUpdates(t *testing.T) {
+	cfg := validConfigForTest(t)
+	for _, mode := range []string{"", CONFIG_UPDATES_MODE_POLL, CONFIG_UPDATES_MODE_PUSH} {
+		cfg.Configuration.ConfigUpdates = &ConfigUpdates{Mode: mode}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected mode %q to be valid: %v", mode, err)
+		}
+	}
+
+	cfg.Configuration.ConfigUpdates = &ConfigUpdates{PollingInterval: 10, PollingMaxBackoff: 60, PollingJitter: 50}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the polling settings to be valid: %v", err)
+	}
+
+	testCases := []struct {
+		configUpdates *ConfigUpdates
+		expectedPaths []string
+	}{
+		{&ConfigUpdates{Mode: "grpc"}, []string{"configuration.configUpdates.mode"}},
+		{&ConfigUpdates{PollingInterval: -1, PollingMaxBackoff: -1, PollingJitter: 101}, []string{
+			"configuration.configUpdates.pollingInterval",
+			"configuration.configUpdates.pollingMaxBackoff",
+			"configuration.configUpdates.pollingJitter",
+		}},
+		{&ConfigUpdates{PollingInterval: 30, PollingMaxBackoff: 20}, []string{"configuration.configUpdates.pollingMaxBackoff"}},
+	}
+	for _, tc := range testCases {
+		cfg.Configuration.ConfigUpdates = tc.configUpdates
+		problems := problemsOf(t, cfg.Validate())
+		if len(problems) != len(tc.expectedPaths) {
+			t.Fatalf("expected %d problems, got %d: %v", len(tc.expectedPaths), len(problems), problems)
+		}
+		for i, path := range tc.expectedPaths {
+			if !strings.HasPrefix(problems[i], path+": ") {
+				t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+			}
+		}
+	}
+}
+
+func TestValidate_DiscoveryCache(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.DiscoveryCache = &DiscoveryCache{MaxTTL: 600, MaxEntries: 100}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the discovery cache configuration to be valid: %v", err)
+	}
+
+	cfg.Configuration.DiscoveryCache = &DiscoveryCache{MaxTTL: -1, MaxEntries: -1}
+	problems := problemsOf(t, cfg.Validate())
+	expectedPaths := []string{"configuration.discoveryCache.maxTtl", "configuration.discoveryCache.maxEntries"}
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_NfProfile(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.NfProfile = &NfProfile{
+		Capacity:            100,
+		Priority:            1,
+		Locality:            "us-east",
+		NfSetIdList:         []string{"set1.ausfset.5gc.mnc001.mcc001"},
+		MaxInFlightRequests: 500,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the NF profile configuration to be valid: %v", err)
+	}
+
+	cfg.Configuration.NfProfile = &NfProfile{
+		Capacity:            70000,
+		Priority:            -1,
+		NfSetIdList:         []string{"set1.ausfset.5gc.mnc001.mcc001", ""},
+		MaxInFlightRequests: -1,
+		MaxUeContexts:       -1,
+	}
+	expectedPaths := []string{
+		"configuration.nfProfile.capacity",
+		"configuration.nfProfile.priority",
+		"configuration.nfProfile.nfSetIdList[1]",
+		"configuration.nfProfile.maxInFlightRequests",
+		"configuration.nfProfile.maxUeContexts",
+	}
+	problems := problemsOf(t, cfg.Validate())
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_AusfInfo(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.AusfInfo = &AusfInfo{
+		SupiRanges: []SupiRange{
+			{Start: "001010000000000", End: "001010000099999"},
+			{Pattern: "^imsi-00102[0-9]{10}$"},
+		},
+		RoutingIndicators: []string{"0", "1234"},
+		SuciInfos:         []SuciInfo{{RoutingInds: []string{"0"}, HNwPubKeyIds: []int{1, 2}}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the AUSF info to be valid: %v", err)
+	}
+
+	cfg.Configuration.AusfInfo = &AusfInfo{
+		SupiRanges: []SupiRange{
+			{Start: "001010000000000"},
+			{Start: "00101000009", End: "001010000000000"},
+			{Start: "001010000099999", End: "001010000000000"},
+			{Start: "1", End: "2", Pattern: "("},
+		},
+		RoutingIndicators: []string{"12345"},
+		SuciInfos:         []SuciInfo{{RoutingInds: []string{"x"}, HNwPubKeyIds: []int{256}}},
+	}
+	expectedPaths := []string{
+		"configuration.ausfInfo.supiRanges[0]",
+		"configuration.ausfInfo.supiRanges[1]",
+		"configuration.ausfInfo.supiRanges[2]",
+		"configuration.ausfInfo.supiRanges[3]",
+		"configuration.ausfInfo.supiRanges[3].pattern",
+		"configuration.ausfInfo.routingIndicators[0]",
+		"configuration.ausfInfo.suciInfos[0].routingInds[0]",
+		"configuration.ausfInfo.suciInfos[0].hNwPubKeyIds[0]",
+	}
+	problems := problemsOf(t, cfg.Validate())
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
+
+func TestValidate_NrfUris(t *testing.T) {
+	cfg := validConfigForTest(t)
+	cfg.Configuration.NrfUris = []string{"https://nrf-2:29510", "https://nrf:29510"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected secondary NRFs to be valid: %v", err)
+	}
+	expectedUris := []string{"https://nrf:29510", "https://nrf-2:29510"}
+	if uris := cfg.Configuration.GetNrfUris(); !reflect.DeepEqual(uris, expectedUris) {
+		t.Errorf("expected NRFs %v, got %v", expectedUris, uris)
+	}
+
+	cfg.Configuration.NrfUri = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected nrfUris to be enough: %v", err)
+	}
+
+	cfg.Configuration.NrfUris = []string{"https://nrf-2:29510", "nrf-3"}
+	problems := problemsOf(t, cfg.Validate())
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "configuration.nrfUris[1]: ") {
+		t.Errorf("expected a single problem on configuration.nrfUris[1], got %v", problems)
+	}
+
+	cfg.Configuration.NrfUris = nil
+	problems = problemsOf(t, cfg.Validate())
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "configuration.nrfUri: ") {
+		t.Errorf("expected a single problem on configuration.nrfUri, got %v", problems)
+	}
+}
+
+func TestValidateAusfPolicy(t *testing.T) {
+	policy := &AusfPolicy{
+		AllowedServingNetworks: []string{"5G:mnc001.mcc001.3gppnetwork.org"},
+		PlmnPolicies: []PlmnAuthPolicy{
+			{PlmnId: models.PlmnId{Mcc: "001", Mnc: "01"}, PreferredAuthMethod: "5G_AKA", AkmaEnabled: true},
+			{PlmnId: models.PlmnId{Mcc: "208", Mnc: "093"}},
+		},
+		AusfInfo: &AusfInfo{RoutingIndicators: []string{"0"}},
+	}
+	if err := ValidateAusfPolicy(policy); err != nil {
+		t.Errorf("expected the AUSF policy to be valid: %v", err)
+	}
+
+	policy = &AusfPolicy{
+		AllowedServingNetworks: []string{"mnc001.mcc001"},
+		PlmnPolicies:           []PlmnAuthPolicy{{PlmnId: models.PlmnId{Mcc: "01", Mnc: "01"}, PreferredAuthMethod: "EAP_TLS"}},
+		AusfInfo:               &AusfInfo{RoutingIndicators: []string{"abc"}},
+	}
+	expectedPaths := []string{
+		"allowedServingNetworks[0]",
+		"plmnPolicies[0].plmnId",
+		"plmnPolicies[0].preferredAuthMethod",
+		"ausfInfo.routingIndicators[0]",
+	}
+	problems := problemsOf(t, ValidateAusfPolicy(policy))
+	if len(problems) != len(expectedPaths) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expectedPaths), len(problems), problems)
+	}
+	for i, path := range expectedPaths {
+		if !strings.HasPrefix(problems[i], path+": ") {
+			t.Errorf("expected problem %d to be reported on %s, got %q", i, path, problems[i])
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Filewatch package notifies when files on disk change.
+ */
+
+package filewatch
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/omec-project/ausf/logger"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func stat(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+// Watch polls the given files every interval and calls onChange once per polling cycle
+// in which any of them was modified, created or removed. It returns when the context is
+// cancelled. Polling is used instead of inotify so that Kubernetes secret and configmap
+// updates (which swap symlinks) are detected reliably.
+func Watch(ctx context.Context, interval time.Duration, paths []string, onChange func()) {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		states[path] = stat(path)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed := false
+			for _, path := range paths {
+				current := stat(path)
+				if current != states[path] {
+					logger.AppLog.Infof("file %s changed", path)
+					states[path] = current
+					changed = true
+				}
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Health package tracks the liveness and readiness of the AUSF. Readiness checks are
+ * reported by the components owning them (NRF registration, UDM discovery, config polling);
+ * liveness covers the SBI server and the long-running goroutines.
+ */
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/metrics"
+)
+
+// Readiness checks
+const (
+	CheckNrfRegistration = "nrf_registration"
+	CheckUdmDiscovery    = "udm_discovery"
+	CheckConfigPolling   = "config_polling"
+)
+
+var readinessChecks = []string{CheckNrfRegistration, CheckUdmDiscovery, CheckConfigPolling}
+
+var errNotChecked = errors.New("not checked yet")
+
+var (
+	mu         sync.RWMutex
+	readiness  = map[string]error{}
+	components = map[string]bool{} // component name to running
+)
+
+// SetReadiness records the outcome of a readiness check; a nil error means passing
+func SetReadiness(check string, err error) {
+	mu.Lock()
+	previous, known := readiness[check]
+	readiness[check] = err
+	mu.Unlock()
+
+	metrics.SetHealthCheckState("readiness", check, err == nil)
+	switch {
+	case err != nil && (!known || previous == nil):
+		logger.HealthLog.Warnf("readiness check %s failing: %v", check, err)
+	case err == nil && known && previous != nil:
+		logger.HealthLog.Infof("readiness check %s passing", check)
+	}
+}
+
+// Ready returns nil when every readiness check passes, else an error listing the failing checks
+func Ready() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	var problems []string
+	for _, check := range readinessChecks {
+		err, ok := readiness[check]
+		if !ok {
+			err = errNotChecked
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", check, err))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Track marks a long-running component as running and returns the function to call when it
+// stops. A component that stops makes the liveness probe fail.
+func Track(component string) (stopped func()) {
+	setRunning(component, true)
+	return func() { setRunning(component, false) }
+}
+
+func setRunning(component string, running bool) {
+	mu.Lock()
+	components[component] = running
+	mu.Unlock()
+	metrics.SetHealthCheckState("liveness", component, running)
+	if !running {
+		logger.HealthLog.Warnf("%s stopped", component)
+	}
+}
+
+// Live returns nil when every tracked component is running, else an error listing the stopped ones
+func Live() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	var stopped []string
+	for component, running := range components {
+		if !running {
+			stopped = append(stopped, component)
+		}
+	}
+	if len(stopped) > 0 {
+		sort.Strings(stopped)
+		return fmt.Errorf("stopped: %s", strings.Join(stopped, ", "))
+	}
+	return nil
+}
+
+// Poll runs probe right away and then every interval until the context is cancelled,
+// recording its outcome as the readiness check
+func Poll(ctx context.Context, interval time.Duration, check string, probe func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		SetReadiness(check, probe())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF Health Checks
+ */
+
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func resetForTest(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		mu.Lock()
+		readiness = map[string]error{}
+		components = map[string]bool{}
+		mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+/*
+ * Health Unit Tests
+ */
+
+func TestReady_RequiresEveryCheckToPass(t *testing.T) {
+	resetForTest(t)
+
+	err := Ready()
+	if err == nil {
+		t.Fatal("expected not to be ready before any check is reported")
+	}
+	for _, check := range readinessChecks {
+		if !strings.Contains(err.Error(), check+": not checked yet") {
+			t.Errorf("expected %s to be reported as not checked, got %v", check, err)
+		}
+	}
+
+	SetReadiness(CheckNrfRegistration, nil)
+	SetReadiness(CheckUdmDiscovery, nil)
+	SetReadiness(CheckConfigPolling, errors.New("3 consecutive polling failures"))
+	if err := Ready(); err == nil || err.Error() != "config_polling: 3 consecutive polling failures" {
+		t.Errorf("expected only config polling to fail, got %v", err)
+	}
+
+	SetReadiness(CheckConfigPolling, nil)
+	if err := Ready(); err != nil {
+		t.Errorf("expected to be ready, got %v", err)
+	}
+}
+
+func TestLive_FailsWhenATrackedComponentStops(t *testing.T) {
+	resetForTest(t)
+
+	stopPolling := Track("config_polling")
+	stopServer := Track("sbi_server")
+	if err := Live(); err != nil {
+		t.Fatalf("expected to be live, got %v", err)
+	}
+
+	stopServer()
+	stopPolling()
+	if err := Live(); err == nil || err.Error() != "stopped: config_polling, sbi_server" {
+		t.Errorf("expected the stopped components to be reported, got %v", err)
+	}
+}
+
+func TestPoll_RecordsProbeOutcomeUntilCancelled(t *testing.T) {
+	resetForTest(t)
+
+	var calls atomic.Int32
+	probe := func() error {
+		if calls.Add(1) == 1 {
+			return errors.New("no UDM UEAU instance discovered")
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Poll(ctx, 10*time.Millisecond, CheckUdmDiscovery, probe)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("probe was not run periodically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.RLock()
+	err, ok := readiness[CheckUdmDiscovery]
+	mu.RUnlock()
+	if !ok || err != nil {
+		t.Errorf("expected the UDM check to pass after the second probe, got %v", err)
+	}
+}
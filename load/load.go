@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Load package estimates the load the AUSF reports to the NRF, so that the AMFs can
+ * balance the authentications across the AUSF instances.
+ */
+
+package load
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+)
+
+const authApiPrefix = "/nausf-auth/"
+
+// ongoingAuthMaxAge bounds the age of the authentications counted in the load: the AMF
+// gives up on a challenge after 5 expiries of T3560 (6s each), and the context of an
+// authentication that was never confirmed stays in the pool until the UE is seen again
+const ongoingAuthMaxAge = 30 * time.Second
+
+// Estimator reports the load in percent as the highest of the peak number of in-flight
+// authentication requests since the previous estimate and the number of authentications
+// waiting for their confirmation, each relative to the value considered full load
+type Estimator struct {
+	maxInFlight atomic.Int64
+	maxContexts atomic.Int64
+	inFlight    atomic.Int64
+	peak        atomic.Int64
+	contexts    func() int
+}
+
+// NewEstimator returns an estimator counting the ongoing authentications with contexts
+func NewEstimator(maxInFlight, maxContexts int, contexts func() int) *Estimator {
+	e := &Estimator{contexts: contexts}
+	e.setLimits(maxInFlight, maxContexts)
+	return e
+}
+
+func (e *Estimator) setLimits(maxInFlight, maxContexts int) {
+	if maxInFlight <= 0 {
+		maxInFlight = factory.DEFAULT_LOAD_MAX_IN_FLIGHT_REQUESTS
+	}
+	if maxContexts <= 0 {
+		maxContexts = factory.DEFAULT_LOAD_MAX_UE_CONTEXTS
+	}
+	e.maxInFlight.Store(int64(maxInFlight))
+	e.maxContexts.Store(int64(maxContexts))
+}
+
+// Middleware counts the nausf-auth requests in flight
+func (e *Estimator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, authApiPrefix) {
+			c.Next()
+			return
+		}
+		e.raisePeak(e.inFlight.Add(1))
+		defer e.inFlight.Add(-1)
+		c.Next()
+	}
+}
+
+func (e *Estimator) raisePeak(inFlight int64) {
+	for {
+		peak := e.peak.Load()
+		if inFlight <= peak || e.peak.CompareAndSwap(peak, inFlight) {
+			return
+		}
+	}
+}
+
+// Current returns the load between 0 and 100 and starts a new peak period
+func (e *Estimator) Current() int32 {
+	peak := e.peak.Swap(e.inFlight.Load())
+	load := peak * 100 / e.maxInFlight.Load()
+	if e.contexts != nil {
+		load = max(load, int64(e.contexts())*100/e.maxContexts.Load())
+	}
+	return int32(min(load, 100))
+}
+
+var estimator = NewEstimator(0, 0, ongoingAuthentications)
+
+func ongoingAuthentications() int {
+	return ausfContext.OngoingAuthContextCount(ongoingAuthMaxAge)
+}
+
+// Init applies the full load values of the nfProfile section of the configuration
+func Init(cfg *factory.NfProfile) {
+	var maxInFlight, maxContexts int
+	if cfg != nil {
+		maxInFlight, maxContexts = cfg.MaxInFlightRequests, cfg.MaxUeContexts
+	}
+	estimator.setLimits(maxInFlight, maxContexts)
+	logger.InitLog.Infof("load reported to the NRF is full at %d in-flight requests or %d ongoing authentications",
+		estimator.maxInFlight.Load(), estimator.maxContexts.Load())
+}
+
+// Middleware counts the nausf-auth requests in flight for Current
+func Middleware() gin.HandlerFunc {
+	return estimator.Middleware()
+}
+
+// Current returns the load of the AUSF, in percent, since the previous call
+func Current() int32 {
+	return estimator.Current()
+}
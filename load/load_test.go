@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF load estimator
+ */
+
+package load
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+/*
+ * Load Estimator Unit Tests
+ */
+
+func TestEstimator_ReportsThePeakOfInFlightRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := NewEstimator(4, 0, nil)
+	router := gin.New()
+	router.Use(e.Middleware())
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	handler := func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	}
+	router.POST("/nausf-auth/v1/ue-authentications", handler)
+	router.POST("/nnrf-nfm/v1/nf-status-notify", handler)
+	serve := func(path string, done chan<- struct{}) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+		done <- struct{}{}
+	}
+
+	if load := e.Current(); load != 0 {
+		t.Fatalf("expected no load when idle, got %d", load)
+	}
+	done := make(chan struct{}, 3)
+	go serve("/nausf-auth/v1/ue-authentications", done)
+	go serve("/nausf-auth/v1/ue-authentications", done)
+	go serve("/nnrf-nfm/v1/nf-status-notify", done)
+	for range 3 {
+		<-started
+	}
+	close(release)
+	for range 3 {
+		<-done
+	}
+
+	if load := e.Current(); load != 50 {
+		t.Errorf("expected the peak of 2 authentication requests out of 4 to be a load of 50, got %d", load)
+	}
+	if load := e.Current(); load != 0 {
+		t.Errorf("expected the peak to be reset by the previous estimate, got %d", load)
+	}
+}
+
+func TestEstimator_ReportsTheUeContextsAndCapsTheLoad(t *testing.T) {
+	contexts := 30
+	e := NewEstimator(0, 120, func() int { return contexts })
+	if load := e.Current(); load != 25 {
+		t.Errorf("expected 30 UE contexts out of 120 to be a load of 25, got %d", load)
+	}
+	contexts = 500
+	if load := e.Current(); load != 100 {
+		t.Errorf("expected the load to be capped at 100, got %d", load)
+	}
+}
+
+func TestOngoingAuthentications_CountsOnlyRecentUnconfirmedAuthentications(t *testing.T) {
+	contexts := map[string]*ausfContext.AusfUeContext{
+		"imsi-001010000000001": {AuthStatus: models.AUTHRESULT_AUTHENTICATION_ONGOING, AuthStartTime: time.Now()},
+		"imsi-001010000000002": {AuthStatus: models.AUTHRESULT_AUTHENTICATION_ONGOING, AuthStartTime: time.Now().Add(-time.Hour)},
+		"imsi-001010000000003": {AuthStatus: models.AUTHRESULT_AUTHENTICATION_SUCCESS, AuthStartTime: time.Now()},
+	}
+	for supi, ueContext := range contexts {
+		ueContext.Supi = supi
+		ausfContext.AddAusfUeContextToPool(ueContext)
+		t.Cleanup(func() { ausfContext.RemoveAusfUeContextFromPool(supi) })
+	}
+	if count := ongoingAuthentications(); count != 1 {
+		t.Errorf("expected only the recent unconfirmed authentication to be counted, got %d", count)
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package logger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrUnknownCategory = errors.New("unknown logger category")
+
+var (
+	levelMu      sync.Mutex
+	defaultLevel = zapcore.InfoLevel
+	levels       = map[string]zap.AtomicLevel{} // category to level
+	overrides    = map[string]*override{}       // categories whose level differs from the default
+)
+
+type override struct {
+	expiresAt time.Time // zero when the override does not expire
+	timer     *time.Timer
+}
+
+// CategoryLevel is the level of a logger category. Overridden is set when the level was
+// changed at runtime; it reverts to the default level at ExpiresAt, when set.
+type CategoryLevel struct {
+	Level      zapcore.Level
+	Overridden bool
+	ExpiresAt  time.Time
+}
+
+// Categories returns the names of the logger categories
+func Categories() []string {
+	names := make([]string, 0, len(categories))
+	for _, category := range categories {
+		names = append(names, category.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetCategoryLevel returns the current level of a logger category
+func GetCategoryLevel(category string) (CategoryLevel, error) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	atomicLevel, ok := levels[category]
+	if !ok {
+		return CategoryLevel{}, ErrUnknownCategory
+	}
+	current := CategoryLevel{Level: atomicLevel.Level()}
+	if o, overridden := overrides[category]; overridden {
+		current.Overridden = true
+		current.ExpiresAt = o.expiresAt
+	}
+	return current, nil
+}
+
+// SetCategoryLevel overrides the level of a logger category. With a positive ttl the
+// category reverts to the default level once the ttl has elapsed.
+func SetCategoryLevel(category string, level zapcore.Level, ttl time.Duration) error {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	atomicLevel, ok := levels[category]
+	if !ok {
+		return ErrUnknownCategory
+	}
+	clearOverride(category)
+	o := &override{}
+	if ttl > 0 {
+		o.expiresAt = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() {
+			levelMu.Lock()
+			defer levelMu.Unlock()
+			if overrides[category] == o {
+				revert(category)
+				AdminLog.Infof("log level of %s reverted to %s after %s", category, defaultLevel, ttl)
+			}
+		})
+	}
+	overrides[category] = o
+	atomicLevel.SetLevel(level)
+	return nil
+}
+
+// ResetCategoryLevel reverts a logger category to the default level
+func ResetCategoryLevel(category string) error {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	if _, ok := levels[category]; !ok {
+		return ErrUnknownCategory
+	}
+	revert(category)
+	return nil
+}
+
+// revert must be called with levelMu held
+func revert(category string) {
+	clearOverride(category)
+	levels[category].SetLevel(defaultLevel)
+}
+
+// clearOverride must be called with levelMu held
+func clearOverride(category string) {
+	if o, ok := overrides[category]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(overrides, category)
+	}
+}
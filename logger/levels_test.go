@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF per-category log levels
+ */
+
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func resetLevels(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		for _, category := range Categories() {
+			if err := ResetCategoryLevel(category); err != nil {
+				t.Errorf("failed to reset %s: %v", category, err)
+			}
+		}
+		SetLogLevel(zapcore.InfoLevel)
+	})
+}
+
+/*
+ * Log Level Unit Tests
+ */
+
+func TestSetCategoryLevel_OnlyChangesThatCategory(t *testing.T) {
+	resetLevels(t)
+
+	if err := SetCategoryLevel("EapAkaAuth", zapcore.DebugLevel, 0); err != nil {
+		t.Fatalf("SetCategoryLevel: %v", err)
+	}
+	if !EapAuthComfirmLog.Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("expected debug to be enabled for EapAkaAuth")
+	}
+	if Auth5gAkaComfirmLog.Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Error("expected debug to stay disabled for 5gAkaAuth")
+	}
+
+	SetLogLevel(zapcore.WarnLevel)
+	if current, _ := GetCategoryLevel("EapAkaAuth"); current.Level != zapcore.DebugLevel || !current.Overridden {
+		t.Errorf("expected the override to survive a global level change, got %+v", current)
+	}
+	if current, _ := GetCategoryLevel("5gAkaAuth"); current.Level != zapcore.WarnLevel || current.Overridden {
+		t.Errorf("expected the global level on other categories, got %+v", current)
+	}
+
+	if err := ResetCategoryLevel("EapAkaAuth"); err != nil {
+		t.Fatalf("ResetCategoryLevel: %v", err)
+	}
+	if current, _ := GetCategoryLevel("EapAkaAuth"); current.Level != zapcore.WarnLevel || current.Overridden {
+		t.Errorf("expected the global level after reset, got %+v", current)
+	}
+}
+
+func TestSetCategoryLevel_RevertsAfterTtl(t *testing.T) {
+	resetLevels(t)
+
+	if err := SetCategoryLevel("NrfRegistration", zapcore.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatalf("SetCategoryLevel: %v", err)
+	}
+	current, _ := GetCategoryLevel("NrfRegistration")
+	if current.Level != zapcore.DebugLevel || current.ExpiresAt.IsZero() {
+		t.Fatalf("expected a debug override with an expiry, got %+v", current)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		current, _ = GetCategoryLevel("NrfRegistration")
+		if !current.Overridden {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("override did not expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if current.Level != zapcore.InfoLevel {
+		t.Errorf("expected the level to revert to info, got %s", current.Level)
+	}
+}
+
+func TestSetCategoryLevel_ReplacingAnOverrideCancelsItsTtl(t *testing.T) {
+	resetLevels(t)
+
+	if err := SetCategoryLevel("PollConfig", zapcore.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatalf("SetCategoryLevel: %v", err)
+	}
+	if err := SetCategoryLevel("PollConfig", zapcore.ErrorLevel, 0); err != nil {
+		t.Fatalf("SetCategoryLevel: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if current, _ := GetCategoryLevel("PollConfig"); current.Level != zapcore.ErrorLevel || !current.Overridden {
+		t.Errorf("expected the permanent override to remain, got %+v", current)
+	}
+}
+
+func TestCategoryLevel_UnknownCategory(t *testing.T) {
+	if _, err := GetCategoryLevel("Unknown"); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("expected ErrUnknownCategory, got %v", err)
+	}
+	if err := SetCategoryLevel("Unknown", zapcore.DebugLevel, 0); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("expected ErrUnknownCategory, got %v", err)
+	}
+}
@@ -7,12 +7,13 @@
 package logger
 
 import (
+	"fmt"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var (
-	log                 *zap.Logger
 	AppLog              *zap.SugaredLogger
 	InitLog             *zap.SugaredLogger
 	CfgLog              *zap.SugaredLogger
@@ -27,53 +28,111 @@ var (
 	GinLog              *zap.SugaredLogger
 	PollConfigLog       *zap.SugaredLogger
 	NrfRegistrationLog  *zap.SugaredLogger
-	atomicLevel         zap.AtomicLevel
+	AdminLog            *zap.SugaredLogger
+	HealthLog           *zap.SugaredLogger
+	AuditLog            *zap.SugaredLogger
+	closeOutputs        = func() {}
 )
 
+// categories lists every logger category; each one has its own level
+var categories = []struct {
+	name   string
+	logger **zap.SugaredLogger
+}{
+	{"App", &AppLog},
+	{"Init", &InitLog},
+	{"CFG", &CfgLog},
+	{"UeAuthPost", &UeAuthPostLog},
+	{"5gAkaAuth", &Auth5gAkaComfirmLog},
+	{"EapAkaAuth", &EapAuthComfirmLog},
+	{"Handler", &HandlerLog},
+	{"Callback", &CallbackLog},
+	{"Producer", &ProducerLog},
+	{"ctx", &ContextLog},
+	{"Consumer", &ConsumerLog},
+	{"GIN", &GinLog},
+	{"PollConfig", &PollConfigLog},
+	{"NrfRegistration", &NrfRegistrationLog},
+	{"Admin", &AdminLog},
+	{"Health", &HealthLog},
+	{"Audit", &AuditLog},
+}
+
 func init() {
-	atomicLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
-	config := zap.Config{
-		Level:            atomicLevel,
-		Development:      false,
-		Encoding:         "console",
-		EncoderConfig:    zap.NewProductionEncoderConfig(),
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
+	for _, category := range categories {
+		levels[category.name] = zap.NewAtomicLevelAt(defaultLevel)
+	}
+	if err := Configure("", nil); err != nil {
+		panic(err)
+	}
+}
+
+// Configure rebuilds the loggers with the given encoding (console or json, default console)
+// and output paths (default stdout). It must be called before the services are started.
+func Configure(encoding string, outputPaths []string) error {
+	if encoding == "" {
+		encoding = "console"
+	}
+	if len(outputPaths) == 0 {
+		outputPaths = []string{"stdout"}
 	}
 
-	config.EncoderConfig.TimeKey = "timestamp"
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	config.EncoderConfig.LevelKey = "level"
-	config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	config.EncoderConfig.CallerKey = "caller"
-	config.EncoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
-	config.EncoderConfig.MessageKey = "message"
-	config.EncoderConfig.StacktraceKey = ""
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.LevelKey = "level"
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	encoderConfig.CallerKey = "caller"
+	encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+	encoderConfig.MessageKey = "message"
+	encoderConfig.StacktraceKey = ""
 
-	var err error
-	log, err = config.Build()
+	var encoder zapcore.Encoder
+	switch encoding {
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	default:
+		return fmt.Errorf("unsupported log encoding %q", encoding)
+	}
+	output, closeOutput, err := zap.Open(outputPaths...)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	errorOutput, closeErrorOutput, err := zap.Open("stderr")
+	if err != nil {
+		closeOutput()
+		return err
 	}
 
-	AppLog = log.Sugar().With("component", "AUSF", "category", "App")
-	InitLog = log.Sugar().With("component", "AUSF", "category", "Init")
-	CfgLog = log.Sugar().With("component", "AUSF", "category", "CFG")
-	UeAuthPostLog = log.Sugar().With("component", "AUSF", "category", "UeAuthPost")
-	Auth5gAkaComfirmLog = log.Sugar().With("component", "AUSF", "category", "5gAkaAuth")
-	EapAuthComfirmLog = log.Sugar().With("component", "AUSF", "category", "EapAkaAuth")
-	HandlerLog = log.Sugar().With("component", "AUSF", "category", "Handler")
-	CallbackLog = log.Sugar().With("component", "AUSF", "category", "Callback")
-	ProducerLog = log.Sugar().With("component", "AUSF", "category", "Producer")
-	ContextLog = log.Sugar().With("component", "AUSF", "category", "ctx")
-	ConsumerLog = log.Sugar().With("component", "AUSF", "category", "Consumer")
-	GinLog = log.Sugar().With("component", "AUSF", "category", "GIN")
-	PollConfigLog = log.Sugar().With("component", "AUSF", "category", "PollConfig")
-	NrfRegistrationLog = log.Sugar().With("component", "AUSF", "category", "NrfRegistration")
+	levelMu.Lock()
+	for _, category := range categories {
+		core := zapcore.NewCore(encoder, output, levels[category.name])
+		*category.logger = zap.New(core, zap.AddCaller(), zap.ErrorOutput(errorOutput)).
+			Sugar().With("component", "AUSF", "category", category.name)
+	}
+	levelMu.Unlock()
+
+	previousClose := closeOutputs
+	closeOutputs = func() {
+		closeOutput()
+		closeErrorOutput()
+	}
+	previousClose()
+	return nil
 }
 
-// SetLogLevel: set the log level (panic|fatal|error|warn|info|debug)
+// SetLogLevel: set the log level (panic|fatal|error|warn|info|debug) of every category
+// whose level has not been overridden
 func SetLogLevel(level zapcore.Level) {
 	InitLog.Infoln("set log level:", level)
-	atomicLevel.SetLevel(level)
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	defaultLevel = level
+	for name, atomicLevel := range levels {
+		if _, overridden := overrides[name]; !overridden {
+			atomicLevel.SetLevel(level)
+		}
+	}
 }
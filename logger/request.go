@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID; an ID received from the caller is kept, else one is generated
+const RequestIDHeader = "X-Request-Id"
+
+const maxRequestIDLength = 64
+
+type fieldsKey struct{}
+
+// WithFields returns a context whose scoped loggers carry the given key-value pairs in
+// addition to the ones already in ctx
+func WithFields(ctx context.Context, keysAndValues ...any) context.Context {
+	previous, _ := ctx.Value(fieldsKey{}).([]any)
+	fields := make([]any, 0, len(previous)+len(keysAndValues))
+	fields = append(fields, previous...)
+	fields = append(fields, keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FromContext returns base scoped to the request in ctx, i.e. carrying its request ID,
+// authCtxId, masked SUPI and auth type when known
+func FromContext(ctx context.Context, base *zap.SugaredLogger) *zap.SugaredLogger {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	if len(fields) == 0 {
+		return base
+	}
+	return base.With(fields...)
+}
+
+// RequestID returns the ID of the inbound request in ctx, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "requestId" {
+			requestID, _ := fields[i+1].(string)
+			return requestID
+		}
+	}
+	return ""
+}
+
+// RequestMiddleware assigns a request ID to every inbound SBI request, returns it in the
+// response and adds it to the scoped loggers of the request
+func RequestMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(WithFields(c.Request.Context(), "requestId", requestID))
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
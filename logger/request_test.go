@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF request-scoped loggers
+ */
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+/*
+ * Request Logger Unit Tests
+ */
+
+func TestFromContext_AddsScopedFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	base := zap.New(core).Sugar().With("category", "5gAkaAuth")
+
+	ctx := WithFields(context.Background(), "requestId", "req-1")
+	ctx = WithFields(ctx, "authCtxId", "suci-0-208-93-0000-0-0-********01")
+	FromContext(ctx, base).Infoln("5G AKA confirmation succeeded")
+	FromContext(context.Background(), base).Infoln("no request")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["category"] != "5gAkaAuth" || fields["requestId"] != "req-1" ||
+		fields["authCtxId"] != "suci-0-208-93-0000-0-0-********01" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if _, ok := entries[1].ContextMap()["requestId"]; ok {
+		t.Error("expected no request fields without a request context")
+	}
+}
+
+func TestRequestMiddleware_AssignsRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestMiddleware())
+	var scoped []any
+	router.GET("/", func(c *gin.Context) {
+		scoped, _ = c.Request.Context().Value(fieldsKey{}).([]any)
+	})
+
+	testCases := []struct {
+		name     string
+		received string
+		kept     bool
+	}{
+		{"caller ID is kept", "amf-7f3a9c", true},
+		{"missing ID is generated", "", false},
+		{"malformed ID is replaced", "bad id\n", false},
+		{"oversized ID is replaced", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.received != "" {
+				req.Header.Set(RequestIDHeader, tc.received)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get(RequestIDHeader)
+			if tc.kept && requestID != tc.received {
+				t.Errorf("expected the caller request ID to be kept, got %q", requestID)
+			}
+			if !tc.kept && (requestID == tc.received || len(requestID) != 16) {
+				t.Errorf("expected a generated request ID, got %q", requestID)
+			}
+			if len(scoped) != 2 || scoped[0] != "requestId" || scoped[1] != requestID {
+				t.Errorf("expected the request ID in the request context, got %v", scoped)
+			}
+		})
+	}
+}
+
+func TestConfigure_WritesJSONToOutputPath(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "ausf.log")
+	if err := Configure("json", []string{logFile}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	defer func() {
+		if err := Configure("", nil); err != nil {
+			t.Errorf("failed to restore the default loggers: %v", err)
+		}
+	}()
+
+	ctx := WithFields(context.Background(), "requestId", "req-2", "supi", "imsi-20893********01")
+	FromContext(ctx, UeAuthPostLog).Infoln("serving network authorized")
+	if err := UeAuthPostLog.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read the log file: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", content, err)
+	}
+	expected := map[string]string{
+		"message":   "serving network authorized",
+		"category":  "UeAuthPost",
+		"requestId": "req-2",
+		"supi":      "imsi-20893********01",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s=%q, got %v", key, value, entry[key])
+		}
+	}
+}
@@ -8,32 +8,141 @@
 package metrics
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/omec-project/ausf/factory"
 	"github.com/omec-project/ausf/logger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const shutdownTimeout = 5 * time.Second
+
+// NRF registration states reported by ausf_nrf_registration_state
+const (
+	NrfNotRegistered = 0
+	NrfRegistered    = 1
+)
+
 // AusfStats captures AUSF stats
 type AusfStats struct {
-	ueAuths *prometheus.CounterVec
+	ueAuths                 *prometheus.CounterVec
+	circuitBreakerState     *prometheus.GaugeVec
+	inboundRequestDuration  *prometheus.HistogramVec
+	inboundRequestsInFlight prometheus.Gauge
+	outboundRequestDuration *prometheus.HistogramVec
+	authConfirmations       *prometheus.CounterVec
+	resynchronizations      *prometheus.CounterVec
+	servingNetworkRejects   *prometheus.CounterVec
+	nrfRegistrationState    prometheus.Gauge
+	ueContexts              prometheus.GaugeFunc
+	pendingAuthContexts     prometheus.GaugeFunc
+	healthCheckStatus       *prometheus.GaugeVec
+	discoveryCacheLookups   *prometheus.CounterVec
 }
 
 var ausfStats *AusfStats
 
+var (
+	contextSourcesMu    sync.RWMutex
+	ueContextCount      = func() int { return 0 }
+	pendingContextCount = func() int { return 0 }
+)
+
 func initAusfStats() *AusfStats {
 	return &AusfStats{
 		ueAuths: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "ausf_ue_authentications_total",
 			Help: "Counter of total UE Authentications",
 		}, []string{"ausf_id", "serving_network_name", "auth_type", "result"}),
+		circuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ausf_circuit_breaker_state",
+			Help: "State of the outbound circuit breaker per target (0 closed, 1 half-open, 2 open)",
+		}, []string{"target"}),
+		inboundRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ausf_sbi_inbound_request_duration_seconds",
+			Help:    "Latency of the SBI requests handled by the AUSF",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inboundRequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ausf_sbi_inbound_requests_in_flight",
+			Help: "Number of SBI requests currently being handled by the AUSF",
+		}),
+		outboundRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ausf_sbi_outbound_request_duration_seconds",
+			Help:    "Latency of the SBI requests sent by the AUSF to the UDM and NRF, retries included",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service", "method", "status"}),
+		authConfirmations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ausf_auth_confirmations_total",
+			Help: "Counter of 5G AKA and EAP-AKA' confirmations by result and failure cause",
+		}, []string{"auth_type", "result", "cause"}),
+		resynchronizations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ausf_auth_resynchronizations_total",
+			Help: "Counter of authentication requests carrying resynchronization info",
+		}, []string{"serving_network_name"}),
+		servingNetworkRejects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ausf_serving_network_rejects_total",
+			Help: "Counter of authentication requests rejected because the serving network is not authorized",
+		}, []string{"serving_network_name"}),
+		nrfRegistrationState: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ausf_nrf_registration_state",
+			Help: "Registration state of the AUSF in the NRF (0 not registered, 1 registered)",
+		}),
+		ueContexts: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ausf_ue_contexts",
+			Help: "Number of UE contexts in the AUSF context pool",
+		}, func() float64 {
+			contextSourcesMu.RLock()
+			defer contextSourcesMu.RUnlock()
+			return float64(ueContextCount())
+		}),
+		pendingAuthContexts: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ausf_pending_auth_contexts",
+			Help: "Number of authentication contexts waiting for confirmation",
+		}, func() float64 {
+			contextSourcesMu.RLock()
+			defer contextSourcesMu.RUnlock()
+			return float64(pendingContextCount())
+		}),
+		healthCheckStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ausf_health_check_status",
+			Help: "Status of the liveness and readiness checks (1 passing, 0 failing)",
+		}, []string{"probe", "check"}),
+		discoveryCacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ausf_nrf_discovery_cache_lookups_total",
+			Help: "Counter of NF discoveries answered from the discovery cache (hit) or sent to the NRF (miss)",
+		}, []string{"target_nf_type", "result"}),
 	}
 }
 
 func (ps *AusfStats) register() error {
-	if err := prometheus.Register(ps.ueAuths); err != nil {
-		return err
+	collectors := []prometheus.Collector{
+		ps.ueAuths,
+		ps.circuitBreakerState,
+		ps.inboundRequestDuration,
+		ps.inboundRequestsInFlight,
+		ps.outboundRequestDuration,
+		ps.authConfirmations,
+		ps.resynchronizations,
+		ps.servingNetworkRejects,
+		ps.nrfRegistrationState,
+		ps.ueContexts,
+		ps.pendingAuthContexts,
+		ps.healthCheckStatus,
+		ps.discoveryCacheLookups,
+	}
+	for _, collector := range collectors {
+		if err := prometheus.Register(collector); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -46,15 +155,178 @@ func init() {
 	}
 }
 
-// InitMetrics initialises AUSF metrics
-func InitMetrics() {
-	http.Handle("/metrics", promhttp.Handler())
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		logger.InitLog.Errorf("Could not open metrics port: %v", err)
+var (
+	healthChecksMu sync.RWMutex
+	livenessCheck  = func() error { return nil }
+	readinessCheck = func() error { return nil }
+)
+
+// SetHealthChecks installs the functions answering /healthz and /readyz. A non-nil error
+// makes the probe fail with 503 and the error as body.
+func SetHealthChecks(liveness, readiness func() error) {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	livenessCheck = liveness
+	readinessCheck = readiness
+}
+
+func probeHandler(check func() func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := check()(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(err.Error() + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
 	}
 }
 
+// NewServeMux returns the mux serving the metrics on path and the health probes
+func NewServeMux(path string) *http.ServeMux {
+	if path == "" {
+		path = factory.DEFAULT_METRICS_PATH
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, promhttp.Handler())
+	mux.Handle("/healthz", probeHandler(func() func() error {
+		healthChecksMu.RLock()
+		defer healthChecksMu.RUnlock()
+		return livenessCheck
+	}))
+	mux.Handle("/readyz", probeHandler(func() func() error {
+		healthChecksMu.RLock()
+		defer healthChecksMu.RUnlock()
+		return readinessCheck
+	}))
+	return mux
+}
+
+// Start serves the metrics and the health probes until the context is cancelled.
+// A nil configuration listens on port 8080 of all interfaces.
+func Start(ctx context.Context, cfg *factory.Metrics) error {
+	if cfg == nil {
+		cfg = &factory.Metrics{}
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = factory.DEFAULT_METRICS_PORT
+	}
+	addr := net.JoinHostPort(cfg.BindingAddress, strconv.Itoa(port))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewServeMux(cfg.Path),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.InitLog.Warnf("metrics server shutdown: %v", err)
+		}
+	}()
+
+	var err error
+	if cfg.TLS != nil {
+		logger.InitLog.Infof("metrics server listening on https://%s", addr)
+		err = server.ListenAndServeTLS(cfg.TLS.PEM, cfg.TLS.Key)
+	} else {
+		logger.InitLog.Infof("metrics server listening on http://%s", addr)
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.InitLog.Infoln("metrics server stopped")
+	return nil
+}
+
 // IncrementUeAuthStats increments number of total UE authentications
 func IncrementUeAuthStats(ausfID, servingNetworkName, authType, result string) {
 	ausfStats.ueAuths.WithLabelValues(ausfID, servingNetworkName, authType, result).Inc()
 }
+
+// SetCircuitBreakerState records the current circuit breaker state of an outbound target
+func SetCircuitBreakerState(target string, state float64) {
+	ausfStats.circuitBreakerState.WithLabelValues(target).Set(state)
+}
+
+// SetContextSources installs the functions counting the UE contexts and the authentication
+// contexts waiting for confirmation. They are called on every scrape.
+func SetContextSources(ueContexts, pendingAuthContexts func() int) {
+	contextSourcesMu.Lock()
+	defer contextSourcesMu.Unlock()
+	ueContextCount = ueContexts
+	pendingContextCount = pendingAuthContexts
+}
+
+// InboundRequestMiddleware records the latency and the number of in-flight SBI requests.
+// It must be installed before the routes are added to the engine.
+func InboundRequestMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ausfStats.inboundRequestsInFlight.Inc()
+		defer ausfStats.inboundRequestsInFlight.Dec()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ausfStats.inboundRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveOutboundRequest records the latency of a request sent to another NF. service is the
+// SBI service name (e.g. nudm-ueau) and status the HTTP status code, or 0 if no response was received.
+func ObserveOutboundRequest(service, method string, status int, duration time.Duration) {
+	statusLabel := "error"
+	if status > 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	ausfStats.outboundRequestDuration.WithLabelValues(service, method, statusLabel).Observe(duration.Seconds())
+}
+
+// IncrementAuthConfirmationStats counts a 5G AKA or EAP-AKA' confirmation. cause is empty on success.
+func IncrementAuthConfirmationStats(authType string, success bool, cause string) {
+	result := "SUCCESS"
+	if !success {
+		result = "FAILURE"
+	}
+	ausfStats.authConfirmations.WithLabelValues(authType, result, cause).Inc()
+}
+
+// IncrementResynchronizationStats counts an authentication request carrying resynchronization info
+func IncrementResynchronizationStats(servingNetworkName string) {
+	ausfStats.resynchronizations.WithLabelValues(servingNetworkName).Inc()
+}
+
+// IncrementServingNetworkRejectStats counts an authentication request from an unauthorized serving network
+func IncrementServingNetworkRejectStats(servingNetworkName string) {
+	ausfStats.servingNetworkRejects.WithLabelValues(servingNetworkName).Inc()
+}
+
+// SetNrfRegistrationState records whether the AUSF is registered in the NRF
+func SetNrfRegistrationState(state float64) {
+	ausfStats.nrfRegistrationState.Set(state)
+}
+
+// SetHealthCheckState records whether a liveness or readiness check is passing
+func SetHealthCheckState(probe, check string, passing bool) {
+	value := 0.0
+	if passing {
+		value = 1
+	}
+	ausfStats.healthCheckStatus.WithLabelValues(probe, check).Set(value)
+}
+
+// IncrementDiscoveryCacheStats counts an NF discovery answered from the discovery cache or not
+func IncrementDiscoveryCacheStats(targetNfType string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	ausfStats.discoveryCacheLookups.WithLabelValues(targetNfType, result).Inc()
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF Metrics
+ */
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/omec-project/ausf/factory"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func histogramCount(t *testing.T, vec *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := vec.WithLabelValues(labels...).(prometheus.Histogram).Write(m); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func collectorValue(t *testing.T, collector prometheus.Metric) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := collector.Write(m); err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	if m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetGauge().GetValue()
+}
+
+/*
+ * Metrics Unit Tests
+ */
+
+func TestInboundRequestMiddleware_ObservesRouteAndStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(InboundRequestMiddleware())
+	router.PUT("/nausf-auth/v1/ue-authentications/:authCtxId/5g-aka-confirmation", func(c *gin.Context) {
+		if got := collectorValue(t, ausfStats.inboundRequestsInFlight); got != 1 {
+			t.Errorf("expected 1 request in flight, got %v", got)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	route := "/nausf-auth/v1/ue-authentications/:authCtxId/5g-aka-confirmation"
+	before := histogramCount(t, ausfStats.inboundRequestDuration, http.MethodPut, route, "200")
+	req := httptest.NewRequest(http.MethodPut, "/nausf-auth/v1/ue-authentications/suci-0-001-01-0-0-0-1/5g-aka-confirmation", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := histogramCount(t, ausfStats.inboundRequestDuration, http.MethodPut, route, "200"); got != before+1 {
+		t.Errorf("expected the request to be observed under its route template, got %d samples", got-before)
+	}
+	if got := collectorValue(t, ausfStats.inboundRequestsInFlight); got != 0 {
+		t.Errorf("expected no request in flight, got %v", got)
+	}
+
+	unmatchedBefore := histogramCount(t, ausfStats.inboundRequestDuration, http.MethodGet, "unmatched", "404")
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/imsi-001010000000001", nil))
+	if got := histogramCount(t, ausfStats.inboundRequestDuration, http.MethodGet, "unmatched", "404"); got != unmatchedBefore+1 {
+		t.Error("expected unmatched paths to share a single route label")
+	}
+}
+
+func TestObserveOutboundRequest_LabelsTransportErrors(t *testing.T) {
+	before := histogramCount(t, ausfStats.outboundRequestDuration, "nudm-ueau", http.MethodPost, "error")
+	ObserveOutboundRequest("nudm-ueau", http.MethodPost, 0, 10*time.Millisecond)
+	if got := histogramCount(t, ausfStats.outboundRequestDuration, "nudm-ueau", http.MethodPost, "error"); got != before+1 {
+		t.Errorf("expected a sample with status error, got %d", got-before)
+	}
+}
+
+func TestIncrementAuthConfirmationStats(t *testing.T) {
+	success := ausfStats.authConfirmations.WithLabelValues("5G_AKA", "SUCCESS", "")
+	failure := ausfStats.authConfirmations.WithLabelValues("5G_AKA", "FAILURE", "RES_MISMATCH")
+	successBefore, failureBefore := collectorValue(t, success), collectorValue(t, failure)
+
+	IncrementAuthConfirmationStats("5G_AKA", true, "")
+	IncrementAuthConfirmationStats("5G_AKA", false, "RES_MISMATCH")
+
+	if got := collectorValue(t, success); got != successBefore+1 {
+		t.Errorf("expected success counter to be incremented, got %v", got-successBefore)
+	}
+	if got := collectorValue(t, failure); got != failureBefore+1 {
+		t.Errorf("expected failure counter to be incremented, got %v", got-failureBefore)
+	}
+}
+
+func TestContextGauges_UseInstalledSources(t *testing.T) {
+	SetContextSources(func() int { return 7 }, func() int { return 3 })
+	defer SetContextSources(func() int { return 0 }, func() int { return 0 })
+
+	if got := collectorValue(t, ausfStats.ueContexts); got != 7 {
+		t.Errorf("expected 7 UE contexts, got %v", got)
+	}
+	if got := collectorValue(t, ausfStats.pendingAuthContexts); got != 3 {
+		t.Errorf("expected 3 pending auth contexts, got %v", got)
+	}
+}
+
+func TestServeMux_ServesMetricsOnConfiguredPathAndProbes(t *testing.T) {
+	mux := NewServeMux("/custom-metrics")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/custom-metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "ausf_sbi_inbound_requests_in_flight") {
+		t.Errorf("expected metrics on the configured path, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected the default path not to be served, got %d", rec.Code)
+	}
+
+	SetHealthChecks(func() error { return nil }, func() error { return errors.New("not registered in NRF") })
+	defer SetHealthChecks(func() error { return nil }, func() error { return nil })
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected /healthz to succeed, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "not registered in NRF") {
+		t.Errorf("expected /readyz to fail with the check error, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStart_ServesUntilContextIsCancelled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err := listener.Close(); err != nil {
+		t.Fatalf("failed to release the port: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Start(ctx, &factory.Metrics{BindingAddress: "127.0.0.1", Port: port})
+	}()
+
+	url := "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)) + "/healthz"
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		res, err := http.Get(url)
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("expected /healthz to succeed, got %d", res.StatusCode)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("metrics server did not stop after the context was cancelled")
+	}
+}
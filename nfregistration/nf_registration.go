@@ -10,11 +10,20 @@ package nfregistration
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/omec-project/ausf/consumer"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/health"
+	"github.com/omec-project/ausf/load"
 	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/metrics"
+	"github.com/omec-project/ausf/resilience"
 	"github.com/omec-project/openapi/v2/models"
 )
 
@@ -23,19 +32,31 @@ var (
 	keepAliveTimerMutex sync.Mutex
 	registerCtxMutex    sync.Mutex
 	afterFunc           = time.AfterFunc
+	reRegisterChan      = make(chan struct{}, 1)
+	// nrfFailbackInterval is the time a secondary NRF is used before the heartbeat tries
+	// to register with the preferred NRF again
+	nrfFailbackInterval = 5 * time.Minute
+)
+
+var (
+	errDeregistered   = errors.New("deregistered from NRF")
+	errUndiscoverable = errors.New("undiscoverable in NRF, shutting down")
 )
 
 const (
 	defaultHeartbeatTimer int32 = 60
+	initialRetryTime            = 1 * time.Second
 	retryTime                   = 10 * time.Second
+	retryBackoffFactor          = 2
 )
 
 // StartNfRegistrationService starts the registration service. If the new config is empty, the NF
 // deregisters from the NRF. Else, it registers to the NRF. It cancels registerCancel to ensure
 // that only one registration process runs at the time.
-func StartNfRegistrationService(ctx context.Context, plmnConfigChan <-chan []models.PlmnId) {
+func StartNfRegistrationService(ctx context.Context, plmnConfigChan <-chan []models.PlmnId, ausfInfoChan <-chan *factory.AusfInfo) {
 	var registerCancel context.CancelFunc
 	var registerCtx context.Context
+	var currentPlmnConfig []models.PlmnId
 	logger.NrfRegistrationLog.Infoln("Started NF registration to NRF service")
 	for {
 		select {
@@ -64,6 +85,7 @@ func StartNfRegistrationService(ctx context.Context, plmnConfigChan <-chan []mod
 				logger.NrfRegistrationLog.Infoln("NF registration context cancelled")
 				registerCancel()
 			}
+			currentPlmnConfig = newPlmnConfig
 
 			if len(newPlmnConfig) == 0 {
 				logger.NrfRegistrationLog.Debugln("PLMN config is empty. AUSF will deregister")
@@ -74,28 +96,75 @@ func StartNfRegistrationService(ctx context.Context, plmnConfigChan <-chan []mod
 			registerCtx, registerCancel = context.WithCancel(context.Background())
 			// Create new cancellable context for this registration
 			go registerNF(registerCtx, newPlmnConfig)
+		case ausfInfo, ok := <-ausfInfoChan:
+			if !ok {
+				ausfInfoChan = nil
+				continue
+			}
+			if !ausfContext.GetSelf().SetPolledAusfInfo(ausfInfo) || len(currentPlmnConfig) == 0 {
+				continue
+			}
+			if registerCancel != nil {
+				registerCancel()
+			}
+			logger.NrfRegistrationLog.Infoln("AUSF info changed. Re-registering AUSF instance to NRF")
+			registerCtx, registerCancel = context.WithCancel(context.Background())
+			go registerNF(registerCtx, currentPlmnConfig)
+		case <-reRegisterChan:
+			if len(currentPlmnConfig) == 0 {
+				logger.NrfRegistrationLog.Debugln("no PLMN config yet. Skipping re-registration")
+				continue
+			}
+			if registerCancel != nil {
+				registerCancel()
+			}
+			logger.NrfRegistrationLog.Infoln("re-registering AUSF instance to NRF")
+			registerCtx, registerCancel = context.WithCancel(context.Background())
+			go registerNF(registerCtx, currentPlmnConfig)
 		}
 	}
 }
 
-// registerNF sends a RegisterNFInstance. If it fails, it keeps retrying, until the context is cancelled by StartNfRegistrationService
+// TriggerReRegistration makes the registration service register the AUSF again with the
+// latest PLMN config, e.g. after the NRF URI has been changed by a configuration reload.
+func TriggerReRegistration() {
+	select {
+	case reRegisterChan <- struct{}{}:
+	default:
+	}
+}
+
+// registerNF sends a RegisterNFInstance. If it fails, it keeps retrying with an exponential backoff
+// capped at retryTime, failing over to the next NRF when the NRF is unavailable, until the
+// context is cancelled by StartNfRegistrationService
 var registerNF = func(registerCtx context.Context, newPlmnConfig []models.PlmnId) {
 	registerCtxMutex.Lock()
 	defer registerCtxMutex.Unlock()
 	interval := 0 * time.Millisecond
+	backoff := resilience.NewBackoff(initialRetryTime, retryTime, retryBackoffFactor)
 	for {
 		select {
 		case <-registerCtx.Done():
 			logger.NrfRegistrationLog.Infoln("no-op. Registration context was cancelled")
 			return
 		case <-time.After(interval):
+			if registerCtx.Err() != nil {
+				logger.NrfRegistrationLog.Infoln("no-op. Registration context was cancelled")
+				return
+			}
+			nrfUri := ausfContext.GetSelf().GetNrfUri()
 			nfProfile, _, err := consumer.SendRegisterNFInstance(newPlmnConfig)
 			if err != nil {
-				logger.NrfRegistrationLog.Errorln("register AUSF instance to NRF failed. Will retry.", err.Error())
-				interval = retryTime
+				interval = backoff.Next()
+				logger.NrfRegistrationLog.Errorf("register AUSF instance to NRF %s failed. Will retry in %v: %v", nrfUri, interval, err)
+				if errors.Is(err, consumer.ErrNrfUnavailable) {
+					ausfContext.GetSelf().FailoverNrf(nrfUri)
+				}
+				setRegistrationState(err)
 				continue
 			}
 			logger.NrfRegistrationLog.Infoln("register AUSF instance to NRF with updated profile succeeded")
+			setRegistrationState(nil)
 			startKeepAliveTimer(getProfileHeartbeatTimer(nfProfile), newPlmnConfig)
 			return
 		}
@@ -103,8 +172,10 @@ var registerNF = func(registerCtx context.Context, newPlmnConfig []models.PlmnId
 }
 
 // heartbeatNF is the callback function, this is called when keepalivetimer elapsed.
-// It sends a Update NF instance to the NRF. If it fails, it tries to register again.
-// keepAliveTimer is restarted at the end.
+// It sends a Update NF instance to the NRF with the current load of the AUSF. If it
+// fails, it tries to register again, with the next NRF when the NRF is unavailable.
+// When a secondary NRF has been in use for nrfFailbackInterval, it registers with the
+// preferred NRF instead. keepAliveTimer is restarted at the end.
 func heartbeatNF(plmnConfig []models.PlmnId) {
 	keepAliveTimerMutex.Lock()
 	if keepAliveTimer == nil {
@@ -114,29 +185,81 @@ func heartbeatNF(plmnConfig []models.PlmnId) {
 	}
 	keepAliveTimerMutex.Unlock()
 
+	if nfProfile, ok := failBackToPreferredNrf(plmnConfig); ok {
+		setRegistrationState(nil)
+		startKeepAliveTimer(getProfileHeartbeatTimer(nfProfile), plmnConfig)
+		return
+	}
+
 	patchItem := []models.PatchItem{
 		{
 			Op:    models.PATCHOPERATION_REPLACE,
 			Path:  "/nfStatus",
 			Value: models.NFSTATUS_REGISTERED,
 		},
+		{
+			Op:    models.PATCHOPERATION_REPLACE,
+			Path:  "/load",
+			Value: load.Current(),
+		},
+		{
+			Op:    models.PATCHOPERATION_REPLACE,
+			Path:  "/loadTimeStamp",
+			Value: time.Now().UTC(),
+		},
 	}
+	nrfUri := ausfContext.GetSelf().GetNrfUri()
 	nfProfile, problemDetails, err := consumer.SendUpdateNFInstance(patchItem)
 
 	if shouldRegister(problemDetails, err) {
 		logger.NrfRegistrationLog.Debugln("NF heartbeat failed. Trying to register again")
+		if isNrfUnavailable(problemDetails, err) {
+			nrfUri = ausfContext.GetSelf().FailoverNrf(nrfUri)
+		}
 		nfProfile, _, err = consumer.SendRegisterNFInstance(plmnConfig)
 		if err != nil {
 			logger.NrfRegistrationLog.Errorln("register AUSF instance error:", err.Error())
+			if errors.Is(err, consumer.ErrNrfUnavailable) {
+				ausfContext.GetSelf().FailoverNrf(nrfUri)
+			}
 		} else {
 			logger.NrfRegistrationLog.Infoln("register AUSF instance to NRF with updated profile succeeded")
 		}
+		setRegistrationState(err)
 	} else {
 		logger.NrfRegistrationLog.Debugln("AUSF update NF instance (heartbeat) succeeded")
 	}
 	startKeepAliveTimer(getProfileHeartbeatTimer(nfProfile), plmnConfig)
 }
 
+// failBackToPreferredNrf registers with the preferred NRF when it is due, and returns
+// whether the registration succeeded; the NRF in use is kept otherwise
+func failBackToPreferredNrf(plmnConfig []models.PlmnId) (*models.NFProfile, bool) {
+	self := ausfContext.GetSelf()
+	nrfUri := self.GetNrfUri()
+	restore := self.SwitchToPreferredNrf(nrfFailbackInterval)
+	if restore == nil {
+		return nil, false
+	}
+	preferredNrfUri := self.GetNrfUri()
+	nfProfile, _, err := consumer.SendRegisterNFInstance(plmnConfig)
+	if err != nil {
+		logger.NrfRegistrationLog.Warnf("preferred NRF %s still fails, staying with %s: %v", preferredNrfUri, nrfUri, err)
+		restore()
+		return nil, false
+	}
+	logger.NrfRegistrationLog.Infof("registered with the preferred NRF %s again, leaving %s", preferredNrfUri, nrfUri)
+	return nfProfile, true
+}
+
+// isNrfUnavailable returns whether a failed NRF request got no answer or a 5xx answer
+func isNrfUnavailable(problemDetails *models.ProblemDetails, err error) bool {
+	if problemDetails != nil {
+		return problemDetails.GetStatus() >= http.StatusInternalServerError
+	}
+	return errors.Is(err, consumer.ErrNrfUnavailable)
+}
+
 func getProfileHeartbeatTimer(nfProfile *models.NFProfile) int32 {
 	if nfProfile == nil {
 		return defaultHeartbeatTimer
@@ -156,10 +279,40 @@ func shouldRegister(problemDetails *models.ProblemDetails, err error) bool {
 	return false
 }
 
+// SetUndiscoverable stops the heartbeat and sets the AUSF to NFSTATUS_UNDISCOVERABLE in the
+// NRF, so that NF consumers stop selecting it while it shuts down. The registration service
+// must be stopped first: SetUndiscoverable waits for the registration attempt in progress, so
+// that it cannot register the AUSF again afterwards.
+var SetUndiscoverable = func() error {
+	registerCtxMutex.Lock()
+	defer registerCtxMutex.Unlock()
+	keepAliveTimerMutex.Lock()
+	stopKeepAliveTimer()
+	keepAliveTimerMutex.Unlock()
+	setRegistrationState(errUndiscoverable)
+	patchItem := []models.PatchItem{
+		{
+			Op:    models.PATCHOPERATION_REPLACE,
+			Path:  "/nfStatus",
+			Value: models.NFSTATUS_UNDISCOVERABLE,
+		},
+	}
+	_, problemDetails, err := consumer.SendUpdateNFInstance(patchItem)
+	if err != nil {
+		return err
+	}
+	if problemDetails != nil {
+		return fmt.Errorf("NRF rejected the update: %s", problemDetails.GetDetail())
+	}
+	logger.NrfRegistrationLog.Infoln("AUSF instance set to undiscoverable in NRF")
+	return nil
+}
+
 var DeregisterNF = func() {
 	keepAliveTimerMutex.Lock()
 	stopKeepAliveTimer()
 	keepAliveTimerMutex.Unlock()
+	setRegistrationState(errDeregistered)
 	err := consumer.SendDeregisterNFInstance()
 	if err != nil {
 		logger.NrfRegistrationLog.Warnln("deregister instance from NRF error:", err.Error())
@@ -168,6 +321,17 @@ var DeregisterNF = func() {
 	logger.NrfRegistrationLog.Infoln("deregister instance from NRF successful")
 }
 
+// setRegistrationState reports the NRF registration to the metrics and the readiness probe;
+// a nil error means the AUSF is registered
+func setRegistrationState(err error) {
+	if err != nil {
+		metrics.SetNrfRegistrationState(metrics.NrfNotRegistered)
+	} else {
+		metrics.SetNrfRegistrationState(metrics.NrfRegistered)
+	}
+	health.SetReadiness(health.CheckNrfRegistration, err)
+}
+
 func startKeepAliveTimer(profileHeartbeatTimer int32, plmnConfig []models.PlmnId) {
 	keepAliveTimerMutex.Lock()
 	defer keepAliveTimerMutex.Unlock()
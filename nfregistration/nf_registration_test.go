@@ -11,6 +11,7 @@ package nfregistration
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -18,6 +19,8 @@ import (
 	"time"
 
 	"github.com/omec-project/ausf/consumer"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
 	"github.com/omec-project/openapi/v2/models"
 )
 
@@ -27,7 +30,7 @@ func startRegistrationServiceForTest(t *testing.T, ch <-chan []models.PlmnId) (c
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
-		StartNfRegistrationService(ctx, ch)
+		StartNfRegistrationService(ctx, ch, nil)
 	}()
 	return cancel, done
 }
@@ -341,6 +344,95 @@ func TestNfRegistrationService_WhenConfigChanged_ThenPreviousRegistrationIsCance
 	}
 }
 
+func TestNfRegistrationService_WhenReRegistrationTriggered_ThenRegistersWithCurrentConfig(t *testing.T) {
+	originalRegisterNf := registerNF
+	ch := make(chan []models.PlmnId, 1)
+	cancel, done := startRegistrationServiceForTest(t, ch)
+	defer func() {
+		cancel()
+		<-done
+		registerNF = originalRegisterNf
+	}()
+
+	registrations := make(chan []models.PlmnId, 2)
+	registerNF = func(registerCtx context.Context, newPlmnConfig []models.PlmnId) {
+		registrations <- newPlmnConfig
+	}
+
+	TriggerReRegistration()
+	select {
+	case <-registrations:
+		t.Fatal("expected no registration before any PLMN config is received")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	config := []models.PlmnId{{Mcc: "001", Mnc: "01"}}
+	ch <- config
+	select {
+	case <-registrations:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected a registration to the NRF")
+	}
+
+	TriggerReRegistration()
+	select {
+	case registered := <-registrations:
+		if !reflect.DeepEqual(registered, config) {
+			t.Errorf("Expected %+v config, received %+v", config, registered)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected a re-registration to the NRF")
+	}
+}
+
+func TestNfRegistrationService_WhenAusfInfoChanged_ThenReRegisters(t *testing.T) {
+	originalRegisterNf := registerNF
+	plmnChan := make(chan []models.PlmnId, 1)
+	ausfInfoChan := make(chan *factory.AusfInfo, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		StartNfRegistrationService(ctx, plmnChan, ausfInfoChan)
+	}()
+	defer func() {
+		cancel()
+		<-done
+		registerNF = originalRegisterNf
+		ausfContext.GetSelf().SetPolledAusfInfo(nil)
+	}()
+
+	registrations := make(chan []models.PlmnId, 2)
+	registerNF = func(registerCtx context.Context, newPlmnConfig []models.PlmnId) {
+		registrations <- newPlmnConfig
+	}
+	ausfInfo := &factory.AusfInfo{RoutingIndicators: []string{"0012"}}
+
+	ausfInfoChan <- ausfInfo
+	select {
+	case <-registrations:
+		t.Fatal("expected no registration before any PLMN config is received")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if routingIndicators := ausfContext.GetSelf().GetAusfInfo().RoutingIndicators; !reflect.DeepEqual(routingIndicators, []string{"0012"}) {
+		t.Errorf("expected the polled AUSF info to be applied, got %v", routingIndicators)
+	}
+
+	plmnChan <- []models.PlmnId{{Mcc: "001", Mnc: "01"}}
+	select {
+	case <-registrations:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected a registration to the NRF")
+	}
+
+	ausfInfoChan <- &factory.AusfInfo{RoutingIndicators: []string{"0034"}}
+	select {
+	case <-registrations:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected a re-registration to the NRF")
+	}
+}
+
 func TestHeartbeatNF_Success(t *testing.T) {
 	keepAliveTimer = time.NewTimer(60 * time.Second)
 	calledRegister := false
@@ -480,3 +572,252 @@ func TestStartKeepAliveTimer_UsesProfileTimerOnlyWhenGreaterThanZero(t *testing.
 		})
 	}
 }
+
+func TestSetUndiscoverable_StopsHeartbeatAndPatchesNfStatus(t *testing.T) {
+	keepAliveTimer = time.NewTimer(60 * time.Second)
+	originalSendUpdateNFInstance := consumer.SendUpdateNFInstance
+	defer func() {
+		consumer.SendUpdateNFInstance = originalSendUpdateNFInstance
+	}()
+
+	var patched []models.PatchItem
+	consumer.SendUpdateNFInstance = func(patchItem []models.PatchItem) (*models.NFProfile, *models.ProblemDetails, error) {
+		patched = patchItem
+		return &models.NFProfile{}, nil, nil
+	}
+	if err := SetUndiscoverable(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if keepAliveTimer != nil {
+		t.Error("expected the heartbeat timer to be stopped")
+	}
+	if len(patched) != 1 || patched[0].Path != "/nfStatus" || patched[0].Value != models.NFSTATUS_UNDISCOVERABLE {
+		t.Errorf("expected nfStatus to be replaced with UNDISCOVERABLE, got %+v", patched)
+	}
+
+	consumer.SendUpdateNFInstance = func(patchItem []models.PatchItem) (*models.NFProfile, *models.ProblemDetails, error) {
+		return nil, models.NewProblemDetails(), nil
+	}
+	if err := SetUndiscoverable(); err == nil {
+		t.Error("expected an error when the NRF rejects the update")
+	}
+}
+
+func TestSetUndiscoverable_WaitsForTheCancelledRegistration(t *testing.T) {
+	originalSendRegisterNFInstance := consumer.SendRegisterNFInstance
+	originalSendUpdateNFInstance := consumer.SendUpdateNFInstance
+	defer func() {
+		consumer.SendRegisterNFInstance = originalSendRegisterNFInstance
+		consumer.SendUpdateNFInstance = originalSendUpdateNFInstance
+		withKeepAliveTimerLock(stopKeepAliveTimer)
+	}()
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	registering := make(chan struct{})
+	release := make(chan struct{})
+	consumer.SendRegisterNFInstance = func(plmnConfig []models.PlmnId) (*models.NFProfile, string, error) {
+		record("register")
+		close(registering)
+		<-release
+		return &models.NFProfile{}, "", nil
+	}
+	consumer.SendUpdateNFInstance = func(patchItem []models.PatchItem) (*models.NFProfile, *models.ProblemDetails, error) {
+		record("undiscoverable")
+		return &models.NFProfile{}, nil, nil
+	}
+
+	registerCtx, cancelRegistration := context.WithCancel(context.Background())
+	registered := make(chan struct{})
+	go func() {
+		defer close(registered)
+		registerNF(registerCtx, []models.PlmnId{{Mcc: "001", Mnc: "01"}})
+	}()
+	<-registering
+	cancelRegistration()
+	undiscoverable := make(chan error)
+	go func() { undiscoverable <- SetUndiscoverable() }()
+	select {
+	case <-undiscoverable:
+		t.Fatal("expected the AUSF to be set undiscoverable only once the registration in progress is over")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-registered
+	if err := <-undiscoverable; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(calls, []string{"register", "undiscoverable"}) {
+		t.Errorf("expected the registration to be followed by the undiscoverable status only, got %v", calls)
+	}
+	withKeepAliveTimerLock(func() {
+		if keepAliveTimer != nil {
+			t.Error("expected the heartbeat started by the registration to be stopped")
+		}
+	})
+}
+
+func TestHeartbeatNF_ReportsStatusAndLoad(t *testing.T) {
+	keepAliveTimer = time.NewTimer(60 * time.Second)
+	originalSendUpdateNFInstance := consumer.SendUpdateNFInstance
+	defer func() {
+		consumer.SendUpdateNFInstance = originalSendUpdateNFInstance
+		if keepAliveTimer != nil {
+			keepAliveTimer.Stop()
+		}
+	}()
+
+	var patched []models.PatchItem
+	consumer.SendUpdateNFInstance = func(patchItem []models.PatchItem) (*models.NFProfile, *models.ProblemDetails, error) {
+		patched = patchItem
+		return &models.NFProfile{}, nil, nil
+	}
+	heartbeatNF(nil)
+
+	paths := make([]string, 0, len(patched))
+	for _, item := range patched {
+		if item.Op != models.PATCHOPERATION_REPLACE {
+			t.Errorf("expected %s to be replaced, got %s", item.Path, item.Op)
+		}
+		paths = append(paths, item.Path)
+	}
+	expectedPaths := []string{"/nfStatus", "/load", "/loadTimeStamp"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Fatalf("expected the heartbeat to patch %v, got %v", expectedPaths, paths)
+	}
+	if load, ok := patched[1].Value.(int32); !ok || load < 0 || load > 100 {
+		t.Errorf("expected a load between 0 and 100, got %v", patched[1].Value)
+	}
+}
+
+func TestRegisterNF_FailsOverToTheNextNrf(t *testing.T) {
+	self := ausfContext.GetSelf()
+	originalNrfUri, originalNrfUris := self.NrfUri, self.NrfUris
+	originalSendRegisterNFInstance := consumer.SendRegisterNFInstance
+	defer func() {
+		consumer.SendRegisterNFInstance = originalSendRegisterNFInstance
+		self.NrfUri, self.NrfUris = originalNrfUri, originalNrfUris
+		withKeepAliveTimerLock(stopKeepAliveTimer)
+	}()
+	self.NrfUris = []string{"https://nrf-1:29510", "https://nrf-2:29510"}
+	self.NrfUri = self.NrfUris[0]
+
+	var attempts []string
+	consumer.SendRegisterNFInstance = func(plmnConfig []models.PlmnId) (*models.NFProfile, string, error) {
+		nrfUri := self.GetNrfUri()
+		attempts = append(attempts, nrfUri)
+		if nrfUri == "https://nrf-1:29510" {
+			return nil, "", fmt.Errorf("%w: connection refused", consumer.ErrNrfUnavailable)
+		}
+		self.SetNrfResourceUri("https://nrf-2-resource:29510")
+		return models.NewNFProfileWithDefaults(), "https://nrf-2-resource:29510", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	registerNF(ctx, []models.PlmnId{{Mcc: "001", Mnc: "01"}})
+
+	if !reflect.DeepEqual(attempts, self.NrfUris) {
+		t.Errorf("expected registration attempts at %v, got %v", self.NrfUris, attempts)
+	}
+	if self.GetNrfUri() != "https://nrf-2:29510" {
+		t.Errorf("expected the secondary NRF to stay in use, got %s", self.GetNrfUri())
+	}
+	if self.GetNrfResourceUri() != "https://nrf-2-resource:29510" {
+		t.Errorf("expected heartbeats to be sent to the NF instance resource, got %s", self.GetNrfResourceUri())
+	}
+	if alternates := self.AlternateNrfUris(self.GetNrfUri()); !reflect.DeepEqual(alternates, []string{"https://nrf-1:29510"}) {
+		t.Errorf("expected the primary NRF to be the alternate, got %v", alternates)
+	}
+}
+
+func TestRegisterNF_StaysWithTheNrfThatRejectsTheRegistration(t *testing.T) {
+	self := ausfContext.GetSelf()
+	originalNrfUri, originalNrfUris := self.NrfUri, self.NrfUris
+	originalSendRegisterNFInstance := consumer.SendRegisterNFInstance
+	defer func() {
+		consumer.SendRegisterNFInstance = originalSendRegisterNFInstance
+		self.NrfUri, self.NrfUris = originalNrfUri, originalNrfUris
+		withKeepAliveTimerLock(stopKeepAliveTimer)
+	}()
+	self.NrfUris = []string{"https://nrf-1:29510", "https://nrf-2:29510"}
+	self.NrfUri = self.NrfUris[0]
+
+	var attempts []string
+	consumer.SendRegisterNFInstance = func(plmnConfig []models.PlmnId) (*models.NFProfile, string, error) {
+		attempts = append(attempts, self.GetNrfUri())
+		if len(attempts) == 1 {
+			return nil, "", errors.New("unexpected status code returned by the NRF 400")
+		}
+		return models.NewNFProfileWithDefaults(), "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	registerNF(ctx, []models.PlmnId{{Mcc: "001", Mnc: "01"}})
+
+	expected := []string{"https://nrf-1:29510", "https://nrf-1:29510"}
+	if !reflect.DeepEqual(attempts, expected) {
+		t.Errorf("expected registration attempts at %v, got %v", expected, attempts)
+	}
+}
+
+func TestHeartbeatNF_FailsBackToThePreferredNrf(t *testing.T) {
+	self := ausfContext.GetSelf()
+	originalNrfUri, originalNrfUris := self.NrfUri, self.NrfUris
+	originalSendRegisterNFInstance := consumer.SendRegisterNFInstance
+	originalSendUpdateNFInstance := consumer.SendUpdateNFInstance
+	originalNrfFailbackInterval := nrfFailbackInterval
+	defer func() {
+		consumer.SendRegisterNFInstance = originalSendRegisterNFInstance
+		consumer.SendUpdateNFInstance = originalSendUpdateNFInstance
+		nrfFailbackInterval = originalNrfFailbackInterval
+		self.NrfUri, self.NrfUris = originalNrfUri, originalNrfUris
+		self.SetNrfResourceUri("")
+		withKeepAliveTimerLock(stopKeepAliveTimer)
+	}()
+	nrfFailbackInterval = 0
+	self.NrfUris = []string{"https://nrf-1:29510", "https://nrf-2:29510"}
+	self.NrfUri = self.NrfUris[1]
+	self.SetNrfResourceUri("https://nrf-2-resource:29510")
+
+	preferredNrfUp := false
+	var registrations, updates []string
+	consumer.SendRegisterNFInstance = func(plmnConfig []models.PlmnId) (*models.NFProfile, string, error) {
+		registrations = append(registrations, self.GetNrfUri())
+		if !preferredNrfUp {
+			return nil, "", fmt.Errorf("%w: connection refused", consumer.ErrNrfUnavailable)
+		}
+		return models.NewNFProfileWithDefaults(), "", nil
+	}
+	consumer.SendUpdateNFInstance = func(patchItem []models.PatchItem) (*models.NFProfile, *models.ProblemDetails, error) {
+		updates = append(updates, self.GetNrfResourceUri())
+		return &models.NFProfile{}, nil, nil
+	}
+
+	withKeepAliveTimerLock(func() { keepAliveTimer = time.NewTimer(time.Minute) })
+	heartbeatNF(nil)
+	if !reflect.DeepEqual(registrations, []string{"https://nrf-1:29510"}) {
+		t.Errorf("expected the preferred NRF to be probed, got %v", registrations)
+	}
+	if !reflect.DeepEqual(updates, []string{"https://nrf-2-resource:29510"}) {
+		t.Errorf("expected the heartbeat to stay with the secondary NRF, got %v", updates)
+	}
+
+	preferredNrfUp = true
+	registrations, updates = nil, nil
+	heartbeatNF(nil)
+	if !reflect.DeepEqual(registrations, []string{"https://nrf-1:29510"}) || len(updates) != 0 {
+		t.Errorf("expected a registration with the preferred NRF instead of the heartbeat, got %v and %v", registrations, updates)
+	}
+	if nrfUri := self.GetNrfUri(); nrfUri != "https://nrf-1:29510" {
+		t.Errorf("expected the preferred NRF to be in use again, got %s", nrfUri)
+	}
+}
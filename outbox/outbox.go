@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Outbox package queues the authentication events the AUSF owes the UDM
+ * (ConfirmAuth and DeleteAuth) and delivers them in the background with retries.
+ */
+
+package outbox
+
+import (
+	"context"
+	"crypto/cipher"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+type Kind string
+
+const (
+	KindConfirmAuth Kind = "ConfirmAuth"
+	KindDeleteAuth  Kind = "DeleteAuth"
+)
+
+const (
+	DefaultMaxAttempts   = 10
+	DefaultRetryInterval = 1 * time.Second
+	DefaultMaxInterval   = 60 * time.Second
+)
+
+// Event is an authentication event waiting to be delivered to the UDM
+type Event struct {
+	ID                 string          `json:"id"`
+	Kind               Kind            `json:"kind"`
+	Supi               string          `json:"supi"`
+	AuthEventID        string          `json:"authEventId,omitempty"`
+	AuthType           models.AuthType `json:"authType"`
+	Success            bool            `json:"success"`
+	ServingNetworkName string          `json:"servingNetworkName"`
+	TimeStamp          time.Time       `json:"timeStamp"`
+	Attempts           int             `json:"attempts"`
+	NextAttempt        time.Time       `json:"nextAttempt"`
+}
+
+// DeliverFunc sends a single event to the UDM
+type DeliverFunc func(Event) error
+
+type Options struct {
+	MaxAttempts   int
+	RetryInterval time.Duration
+	MaxInterval   time.Duration
+	// StorePath is the file pending events are persisted to. Persistence is disabled when empty.
+	StorePath string
+	// StoreKeyPath is the file holding the key the SUPIs are encrypted with in the store.
+	// It defaults to StorePath with a .key suffix and is generated when missing.
+	StoreKeyPath string
+}
+
+type Outbox struct {
+	mu      sync.Mutex
+	pending map[string]*Event
+	// dirty is set when pending changed since it was last persisted
+	dirty   bool
+	deliver DeliverFunc
+	opts    Options
+	wakeup  chan struct{}
+	now     func() time.Time
+
+	keyOnce sync.Once
+	aead    cipher.AEAD
+	keyErr  error
+}
+
+func New(deliver DeliverFunc, opts Options) *Outbox {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRetryInterval
+	}
+	if opts.MaxInterval < opts.RetryInterval {
+		opts.MaxInterval = max(DefaultMaxInterval, opts.RetryInterval)
+	}
+	if opts.StorePath != "" && opts.StoreKeyPath == "" {
+		opts.StoreKeyPath = opts.StorePath + ".key"
+	}
+	return &Outbox{
+		pending: make(map[string]*Event),
+		deliver: deliver,
+		opts:    opts,
+		wakeup:  make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// Enqueue adds an event to the outbox. It is attempted as soon as the outbox runs, and
+// persisted by the delivery goroutine rather than on the caller's request path.
+func (o *Outbox) Enqueue(ev Event) {
+	if ev.ID == "" {
+		ev.ID = uuid.New().String()
+	}
+	if ev.TimeStamp.IsZero() {
+		ev.TimeStamp = o.now()
+	}
+	ev.NextAttempt = o.now()
+	o.mu.Lock()
+	o.pending[ev.ID] = &ev
+	o.dirty = true
+	o.mu.Unlock()
+	logger.ProducerLog.Debugf("queued %s event %s for %s", ev.Kind, ev.ID, ausfContext.MaskSupi(ev.Supi))
+	o.notify()
+}
+
+// Pending returns a snapshot of the events not yet delivered, oldest first
+func (o *Outbox) Pending() []Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := make([]Event, 0, len(o.pending))
+	for _, ev := range o.pending {
+		events = append(events, *ev)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].TimeStamp.Before(events[j].TimeStamp) })
+	return events
+}
+
+// Run delivers pending events until the context is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	for {
+		// the queued events are stored before their first attempt
+		o.persist()
+		o.deliverDue()
+		o.persist()
+		wait := o.nextWait()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			o.persist()
+			logger.ProducerLog.Infof("auth event outbox shutting down with %d pending events", len(o.Pending()))
+			return
+		case <-o.wakeup:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (o *Outbox) notify() {
+	select {
+	case o.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (o *Outbox) nextWait() time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	wait := o.opts.MaxInterval
+	now := o.now()
+	for _, ev := range o.pending {
+		if d := ev.NextAttempt.Sub(now); d < wait {
+			wait = max(d, 0)
+		}
+	}
+	return wait
+}
+
+func (o *Outbox) deliverDue() {
+	now := o.now()
+	for _, ev := range o.Pending() {
+		if ev.NextAttempt.After(now) {
+			continue
+		}
+		err := o.deliver(ev)
+		o.mu.Lock()
+		current, ok := o.pending[ev.ID]
+		if !ok {
+			o.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			delete(o.pending, ev.ID)
+			logger.ProducerLog.Debugf("delivered %s event %s for %s", ev.Kind, ev.ID, ausfContext.MaskSupi(ev.Supi))
+		} else {
+			current.Attempts++
+			if current.Attempts >= o.opts.MaxAttempts {
+				delete(o.pending, ev.ID)
+				logger.ProducerLog.Errorf("dropping %s event %s for %s after %d attempts: %v",
+					ev.Kind, ev.ID, ausfContext.MaskSupi(ev.Supi), current.Attempts, err)
+			} else {
+				current.NextAttempt = o.now().Add(o.retryDelay(current.Attempts))
+				logger.ProducerLog.Warnf("delivery of %s event %s for %s failed (attempt %d/%d): %v",
+					ev.Kind, ev.ID, ausfContext.MaskSupi(ev.Supi), current.Attempts, o.opts.MaxAttempts, err)
+			}
+		}
+		o.dirty = true
+		o.mu.Unlock()
+	}
+}
+
+func (o *Outbox) retryDelay(attempts int) time.Duration {
+	delay := o.opts.RetryInterval
+	for i := 1; i < attempts && delay < o.opts.MaxInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, o.opts.MaxInterval)
+}
@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ * Auth Event Outbox Unit Tests
+ *
+ */
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type recordingDeliverer struct {
+	mu        sync.Mutex
+	failures  int
+	delivered []Event
+	attempts  int
+}
+
+func (r *recordingDeliverer) deliver(ev Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.attempts <= r.failures {
+		return errors.New("mock UDM failure")
+	}
+	r.delivered = append(r.delivered, ev)
+	return nil
+}
+
+func (r *recordingDeliverer) deliveredCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.delivered)
+}
+
+func (r *recordingDeliverer) attemptCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts
+}
+
+func runOutboxForTest(t *testing.T, o *Outbox) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitForCondition(t *testing.T, timeout time.Duration, condition func() bool, errMessage string) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal(errMessage)
+}
+
+// runOutboxUntilAttempted runs o until the deliverer was called once, then stops it
+func runOutboxUntilAttempted(t *testing.T, o *Outbox, deliverer *recordingDeliverer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.Run(ctx)
+	}()
+	waitForCondition(t, time.Second, func() bool { return deliverer.attemptCount() > 0 }, "expected a delivery attempt")
+	cancel()
+	<-done
+}
+
+func TestOutbox_DeliversQueuedEvent(t *testing.T) {
+	deliverer := &recordingDeliverer{}
+	o := New(deliverer.deliver, Options{})
+	runOutboxForTest(t, o)
+
+	o.Enqueue(Event{Kind: KindConfirmAuth, Supi: "imsi-001010000000001", AuthType: models.AUTHTYPE__5_G_AKA, Success: true})
+
+	waitForCondition(t, time.Second, func() bool { return deliverer.deliveredCount() == 1 }, "expected event to be delivered")
+	if len(o.Pending()) != 0 {
+		t.Errorf("expected no pending events, got %d", len(o.Pending()))
+	}
+}
+
+func TestOutbox_RetriesFailedDelivery(t *testing.T) {
+	deliverer := &recordingDeliverer{failures: 2}
+	o := New(deliverer.deliver, Options{RetryInterval: 10 * time.Millisecond, MaxInterval: 20 * time.Millisecond})
+	runOutboxForTest(t, o)
+
+	o.Enqueue(Event{Kind: KindDeleteAuth, Supi: "imsi-001010000000001", AuthEventID: "suci-001"})
+
+	waitForCondition(t, time.Second, func() bool { return deliverer.deliveredCount() == 1 }, "expected event to be delivered after retries")
+	if deliverer.attemptCount() != 3 {
+		t.Errorf("expected 3 attempts, got %d", deliverer.attemptCount())
+	}
+}
+
+func TestOutbox_DropsEventAfterMaxAttempts(t *testing.T) {
+	deliverer := &recordingDeliverer{failures: 100}
+	o := New(deliverer.deliver, Options{MaxAttempts: 2, RetryInterval: 10 * time.Millisecond})
+	runOutboxForTest(t, o)
+
+	o.Enqueue(Event{Kind: KindConfirmAuth, Supi: "imsi-001010000000001"})
+
+	waitForCondition(t, time.Second, func() bool { return len(o.Pending()) == 0 }, "expected event to be dropped")
+	if deliverer.attemptCount() != 2 {
+		t.Errorf("expected 2 attempts, got %d", deliverer.attemptCount())
+	}
+}
+
+func TestOutbox_MasksTheSupiInLogs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	originalLog := logger.ProducerLog
+	logger.ProducerLog = zap.New(core).Sugar()
+	t.Cleanup(func() { logger.ProducerLog = originalLog })
+	deliverer := &recordingDeliverer{failures: 100}
+	o := New(deliverer.deliver, Options{MaxAttempts: 2, RetryInterval: 10 * time.Millisecond})
+	runOutboxForTest(t, o)
+
+	const supi = "imsi-001010000000001"
+	o.Enqueue(Event{Kind: KindConfirmAuth, Supi: supi})
+
+	waitForCondition(t, time.Second, func() bool { return len(o.Pending()) == 0 }, "expected event to be dropped")
+	for _, entry := range logs.All() {
+		if strings.Contains(entry.Message, supi) {
+			t.Errorf("expected the SUPI to be masked, got %q", entry.Message)
+		}
+	}
+	if logs.FilterMessageSnippet(ausfContext.MaskSupi(supi)).Len() != 3 {
+		t.Errorf("expected the queued, failed and dropped events to be logged with the masked SUPI, got %d entries", logs.Len())
+	}
+}
+
+func TestOutbox_PersistsPendingEventsAcrossRestarts(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "outbox.json")
+	failing := &recordingDeliverer{failures: 100}
+	first := New(failing.deliver, Options{StorePath: storePath, RetryInterval: time.Minute})
+	first.Enqueue(Event{Kind: KindConfirmAuth, Supi: "imsi-001010000000001", AuthType: models.AUTHTYPE_EAP_AKA_PRIME})
+	runOutboxUntilAttempted(t, first, failing)
+
+	deliverer := &recordingDeliverer{}
+	second := New(deliverer.deliver, Options{StorePath: storePath})
+	if err := second.Load(); err != nil {
+		t.Fatalf("failed to load outbox store: %v", err)
+	}
+	if len(second.Pending()) != 1 {
+		t.Fatalf("expected 1 restored event, got %d", len(second.Pending()))
+	}
+	runOutboxForTest(t, second)
+
+	waitForCondition(t, time.Second, func() bool { return deliverer.deliveredCount() == 1 }, "expected restored event to be delivered")
+	if got := deliverer.delivered[0]; got.AuthType != models.AUTHTYPE_EAP_AKA_PRIME || got.Supi != "imsi-001010000000001" {
+		t.Errorf("expected the restored event to match the queued one, got %+v", got)
+	}
+
+	third := New(deliverer.deliver, Options{StorePath: storePath})
+	waitForCondition(t, time.Second, func() bool {
+		content, err := os.ReadFile(storePath)
+		return err == nil && string(content) == "[]"
+	}, "expected the delivered event to be removed from the store")
+	if err := third.Load(); err != nil {
+		t.Fatalf("failed to load outbox store: %v", err)
+	}
+	if len(third.Pending()) != 0 {
+		t.Errorf("expected delivered event to be removed from the store, got %d pending", len(third.Pending()))
+	}
+}
+
+func TestOutbox_EncryptsTheSupiAtRest(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "outbox.json")
+	failing := &recordingDeliverer{failures: 100}
+	o := New(failing.deliver, Options{StorePath: storePath, RetryInterval: time.Minute})
+	o.Enqueue(Event{Kind: KindConfirmAuth, Supi: "imsi-001010000000001"})
+	runOutboxUntilAttempted(t, o, failing)
+
+	content, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("failed to read outbox store: %v", err)
+	}
+	if strings.Contains(string(content), "001010000000001") {
+		t.Errorf("expected the SUPI to be encrypted in the store, got %s", content)
+	}
+	if info, err := os.Stat(storePath + ".key"); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a generated key file readable by the owner only, got %v (%v)", info, err)
+	}
+
+	otherKey := filepath.Join(dir, "other.key")
+	if err := os.WriteFile(otherKey, []byte(strings.Repeat("ab", storeKeySize)), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := New(failing.deliver, Options{StorePath: storePath, StoreKeyPath: otherKey}).Load(); err == nil {
+		t.Error("expected the store not to be restored with another key")
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package outbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/omec-project/ausf/logger"
+)
+
+const storeKeySize = 32
+
+// storedEvent is the persisted form of an Event, whose SUPI is encrypted
+type storedEvent struct {
+	Event
+	Supi string `json:"supi"`
+}
+
+// Load restores the events persisted by a previous run; they are attempted again as
+// soon as the outbox runs
+func (o *Outbox) Load() error {
+	if o.opts.StorePath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(o.opts.StorePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read outbox store: %w", err)
+	}
+	var stored []storedEvent
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return fmt.Errorf("failed to parse outbox store: %w", err)
+	}
+	aead, err := o.storeCipher()
+	if err != nil {
+		return err
+	}
+	events := make([]Event, 0, len(stored))
+	for _, s := range stored {
+		ev := s.Event
+		if ev.Supi, err = openSupi(aead, ev.ID, s.Supi); err != nil {
+			return fmt.Errorf("failed to decrypt event %s of the outbox store: %w", ev.ID, err)
+		}
+		ev.NextAttempt = o.now()
+		events = append(events, ev)
+	}
+	o.mu.Lock()
+	for i := range events {
+		o.pending[events[i].ID] = &events[i]
+	}
+	o.mu.Unlock()
+	logger.ProducerLog.Infof("restored %d pending auth events from %s", len(events), o.opts.StorePath)
+	o.notify()
+	return nil
+}
+
+// persist rewrites the store when the pending events changed. It is only called from
+// the delivery goroutine, so the file is written without holding o.mu.
+func (o *Outbox) persist() {
+	if o.opts.StorePath == "" {
+		return
+	}
+	o.mu.Lock()
+	if !o.dirty {
+		o.mu.Unlock()
+		return
+	}
+	o.dirty = false
+	events := make([]Event, 0, len(o.pending))
+	for _, ev := range o.pending {
+		events = append(events, *ev)
+	}
+	o.mu.Unlock()
+
+	if err := o.writeStore(events); err != nil {
+		logger.ProducerLog.Errorf("failed to persist the outbox store: %v", err)
+		o.mu.Lock()
+		o.dirty = true
+		o.mu.Unlock()
+	}
+}
+
+func (o *Outbox) writeStore(events []Event) error {
+	aead, err := o.storeCipher()
+	if err != nil {
+		return err
+	}
+	stored := make([]storedEvent, 0, len(events))
+	for _, ev := range events {
+		sealed, err := sealSupi(aead, ev.ID, ev.Supi)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, storedEvent{Event: ev, Supi: sealed})
+	}
+	content, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(o.opts.StorePath), 0o750); err != nil {
+		return fmt.Errorf("failed to create outbox store directory: %w", err)
+	}
+	tmpPath := o.opts.StorePath + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write outbox store: %w", err)
+	}
+	if err := os.Rename(tmpPath, o.opts.StorePath); err != nil {
+		return fmt.Errorf("failed to replace outbox store: %w", err)
+	}
+	return nil
+}
+
+// storeCipher returns the AES-GCM cipher of the store key, generating the key on first use
+func (o *Outbox) storeCipher() (cipher.AEAD, error) {
+	o.keyOnce.Do(func() {
+		key, err := loadOrCreateKey(o.opts.StoreKeyPath)
+		if err != nil {
+			o.keyErr = err
+			return
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			o.keyErr = err
+			return
+		}
+		o.aead, o.keyErr = cipher.NewGCM(block)
+	})
+	return o.aead, o.keyErr
+}
+
+func loadOrCreateKey(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key := make([]byte, storeKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create outbox key directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write outbox key: %w", err)
+		}
+		logger.ProducerLog.Infof("generated the outbox store key %s", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != storeKeySize {
+		return nil, fmt.Errorf("outbox key %s must hold %d hex-encoded bytes", path, storeKeySize)
+	}
+	return key, nil
+}
+
+// sealSupi encrypts the SUPI of an event, bound to the event ID so that it cannot be
+// moved to another event of the store
+func sealSupi(aead cipher.AEAD, id, supi string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(supi), []byte(id))), nil
+}
+
+func openSupi(aead cipher.AEAD, id, sealed string) (string, error) {
+	content, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(content) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted SUPI")
+	}
+	supi, err := aead.Open(nil, content[:aead.NonceSize()], content[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return string(supi), nil
+}
@@ -10,53 +10,171 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/health"
 	"github.com/omec-project/ausf/logger"
 	"github.com/omec-project/openapi/v2/models"
 )
 
 const (
-	initialPollingInterval = 5 * time.Second
-	pollingMaxBackoff      = 40 * time.Second
+	initialPollingInterval = factory.DEFAULT_POLLING_INTERVAL * time.Second
+	pollingMaxBackoff      = factory.DEFAULT_POLLING_MAX_BACKOFF * time.Second
 	pollingBackoffFactor   = 2
+	pollingRequestTimeout  = 5 * time.Second
 	pollingPath            = "/nfconfig/plmn"
+	ausfPolicyPath         = "/nfconfig/ausf-policy"
+	// consecutive polling failures after which the AUSF is reported not ready
+	pollingFailureThreshold = 3
 )
 
+var currentWebuiUri atomic.Pointer[string]
+
+// SetWebuiUri changes the webconsole polled by the running polling service
+func SetWebuiUri(webuiUri string) {
+	currentWebuiUri.Store(&webuiUri)
+}
+
+// WebuiUri returns the webconsole polled by the running polling service
+func WebuiUri() string {
+	if webuiUri := currentWebuiUri.Load(); webuiUri != nil {
+		return *webuiUri
+	}
+	return ""
+}
+
+// PolicyChannels receive the parts of the AUSF policy polled from the webconsole
+// when they change
+type PolicyChannels struct {
+	ServingNetworks  chan<- []string                 // allowed serving networks, to the context
+	PlmnAuthPolicies chan<- []factory.PlmnAuthPolicy // per-PLMN authentication settings, to the producer
+	AusfInfo         chan<- *factory.AusfInfo        // AUSF info of the NF profile, to the NRF registration
+}
+
 type nfConfigPoller struct {
 	plmnConfigChan    chan<- []models.PlmnId
 	currentPlmnConfig []models.PlmnId
+	policyChannels    PolicyChannels
+	currentPolicy     factory.AusfPolicy
+	validators        map[string]cacheValidators // by endpoint
 	client            *http.Client
+	streamClient      *http.Client
+	streaming         atomic.Bool // updates are pushed by the webconsole, polling is suspended
+	// time between the attempts to open the stream again
+	streamReconnectInterval time.Duration
+}
+
+// cacheValidators of the last response of an endpoint are sent back with the next
+// request, so that the webconsole answers 304 Not Modified when nothing changed
+type cacheValidators struct {
+	etag         string
+	lastModified string
+}
+
+// pollingSettings are the polling interval, the maximum backoff and the jitter, in
+// percent, of the configUpdates section
+var pollingSettings = func(cfg *factory.ConfigUpdates) (interval, maxBackoff time.Duration, jitter int) {
+	interval, maxBackoff, jitter = initialPollingInterval, pollingMaxBackoff, factory.DEFAULT_POLLING_JITTER
+	if cfg != nil {
+		if cfg.PollingInterval > 0 {
+			interval = time.Duration(cfg.PollingInterval) * time.Second
+		}
+		if cfg.PollingMaxBackoff > 0 {
+			maxBackoff = time.Duration(cfg.PollingMaxBackoff) * time.Second
+		}
+		if cfg.PollingJitter > 0 {
+			jitter = cfg.PollingJitter
+		}
+	}
+	return interval, max(maxBackoff, interval), jitter
+}
+
+// withJitter shortens interval by a random amount of up to jitter percent, so that
+// the AUSF replicas do not poll the webconsole in lockstep
+func withJitter(interval time.Duration, jitter int) time.Duration {
+	if spread := int64(interval) * int64(jitter) / 100; spread > 0 {
+		return interval - time.Duration(rand.Int64N(spread+1))
+	}
+	return interval
 }
 
 // StartPollingService initializes the polling service and starts it. The polling service
 // continuously makes a HTTP GET request to the webconsole and updates the network configuration
-func StartPollingService(ctx context.Context, webuiUri string, plmnConfigChan chan<- []models.PlmnId) {
-	poller := nfConfigPoller{
-		plmnConfigChan:    plmnConfigChan,
-		currentPlmnConfig: []models.PlmnId{},
-		client:            &http.Client{Timeout: initialPollingInterval},
+// and the AUSF policy. In push mode the updates are received over a stream instead, and the
+// webconsole is only polled while the stream is down.
+func StartPollingService(ctx context.Context, webuiUri string, cfg *factory.ConfigUpdates, plmnConfigChan chan<- []models.PlmnId, policyChannels PolicyChannels) {
+	newNfConfigPoller(plmnConfigChan, policyChannels).run(ctx, webuiUri, cfg)
+}
+
+func newNfConfigPoller(plmnConfigChan chan<- []models.PlmnId, policyChannels PolicyChannels) *nfConfigPoller {
+	return &nfConfigPoller{
+		plmnConfigChan:          plmnConfigChan,
+		currentPlmnConfig:       []models.PlmnId{},
+		policyChannels:          policyChannels,
+		client:                  &http.Client{Timeout: pollingRequestTimeout},
+		streamClient:            &http.Client{},
+		streamReconnectInterval: defaultStreamReconnectInterval,
 	}
-	interval := initialPollingInterval
+}
+
+// run polls the webconsole, or receives its stream in push mode, until ctx is done. It
+// returns once the stream is closed.
+func (p *nfConfigPoller) run(ctx context.Context, webuiUri string, cfg *factory.ConfigUpdates) {
+	baseInterval, maxBackoff, jitter := pollingSettings(cfg)
+	interval := baseInterval
+	failures := 0
+	SetWebuiUri(webuiUri)
 	pollingEndpoint := webuiUri + pollingPath
-	logger.PollConfigLog.Infof("Started polling service on %s every %v", pollingEndpoint, initialPollingInterval)
+	var events <-chan configEvent
+	if cfg != nil && cfg.Mode == factory.CONFIG_UPDATES_MODE_PUSH {
+		eventChan := make(chan configEvent)
+		events = eventChan
+		streamDone := make(chan struct{})
+		go func() {
+			defer close(streamDone)
+			p.runStream(ctx, eventChan)
+		}()
+		defer func() { <-streamDone }()
+	}
+	logger.PollConfigLog.Infof("Started polling service on %s every %v (jitter %d%%, max backoff %v)",
+		pollingEndpoint, baseInterval, jitter, maxBackoff)
 	for {
 		select {
 		case <-ctx.Done():
 			logger.PollConfigLog.Infoln("Polling service shutting down")
 			return
-		case <-time.After(interval):
-			newPlmnConfig, err := fetchPlmnConfig(&poller, pollingEndpoint)
+		case event := <-events:
+			p.handleConfigEvent(ctx, event)
+		case <-time.After(withJitter(interval, jitter)):
+			if p.streaming.Load() {
+				interval = baseInterval
+				failures = 0
+				continue
+			}
+			if endpoint := *currentWebuiUri.Load() + pollingPath; endpoint != pollingEndpoint {
+				logger.PollConfigLog.Infof("Polling endpoint changed to %s", endpoint)
+				pollingEndpoint = endpoint
+			}
+			newPlmnConfig, err := fetchPlmnConfig(p, pollingEndpoint)
 			if err != nil {
-				interval = minDuration(interval*time.Duration(pollingBackoffFactor), pollingMaxBackoff)
+				interval = minDuration(interval*time.Duration(pollingBackoffFactor), maxBackoff)
 				logger.PollConfigLog.Errorf("Polling error. Retrying in %v: %+v", interval, err)
+				if failures++; failures >= pollingFailureThreshold {
+					health.SetReadiness(health.CheckConfigPolling, fmt.Errorf("%d consecutive polling failures: %w", failures, err))
+				}
 				continue
 			}
-			interval = initialPollingInterval
-			poller.handlePolledPlmnConfig(newPlmnConfig)
+			interval = baseInterval
+			failures = 0
+			health.SetReadiness(health.CheckConfigPolling, nil)
+			p.handlePolledPlmnConfig(ctx, newPlmnConfig)
+			p.pollAusfPolicy(ctx, *currentWebuiUri.Load()+ausfPolicyPath)
 		}
 	}
 }
@@ -66,7 +184,7 @@ var fetchPlmnConfig = func(p *nfConfigPoller, endpoint string) ([]models.PlmnId,
 }
 
 func (p *nfConfigPoller) fetchPlmnConfig(pollingEndpoint string) ([]models.PlmnId, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), initialPollingInterval)
+	ctx, cancel := context.WithTimeout(context.Background(), pollingRequestTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pollingEndpoint, nil)
@@ -74,6 +192,7 @@ func (p *nfConfigPoller) fetchPlmnConfig(pollingEndpoint string) ([]models.PlmnI
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
+	p.setConditionalHeaders(req, pollingEndpoint)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -81,13 +200,12 @@ func (p *nfConfigPoller) fetchPlmnConfig(pollingEndpoint string) ([]models.PlmnI
 	}
 	defer resp.Body.Close()
 
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "application/json") {
-		return nil, fmt.Errorf("unexpected Content-Type: got %s, want application/json", contentType)
-	}
-
 	switch resp.StatusCode {
 	case http.StatusOK:
+		contentType := resp.Header.Get("Content-Type")
+		if !strings.Contains(contentType, "application/json") {
+			return nil, fmt.Errorf("unexpected Content-Type: got %s, want application/json", contentType)
+		}
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response body: %w", err)
@@ -97,8 +215,11 @@ func (p *nfConfigPoller) fetchPlmnConfig(pollingEndpoint string) ([]models.PlmnI
 		if err := json.Unmarshal(body, &config); err != nil {
 			return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 		}
+		p.saveValidators(pollingEndpoint, resp)
 		return config, nil
 
+	case http.StatusNotModified:
+		return p.currentPlmnConfig, nil
 	case http.StatusBadRequest, http.StatusInternalServerError:
 		return nil, fmt.Errorf("server returned %d error code", resp.StatusCode)
 	default:
@@ -106,14 +227,144 @@ func (p *nfConfigPoller) fetchPlmnConfig(pollingEndpoint string) ([]models.PlmnI
 	}
 }
 
-func (p *nfConfigPoller) handlePolledPlmnConfig(newPlmnConfig []models.PlmnId) {
+// setConditionalHeaders makes req conditional on the validators of the last response
+// of endpoint
+func (p *nfConfigPoller) setConditionalHeaders(req *http.Request, endpoint string) {
+	validators := p.validators[endpoint]
+	if validators.etag != "" {
+		req.Header.Set("If-None-Match", validators.etag)
+	}
+	if validators.lastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.lastModified)
+	}
+}
+
+// saveValidators keeps the validators of a response that was applied, or forgets the
+// previous ones when it has none
+func (p *nfConfigPoller) saveValidators(endpoint string, resp *http.Response) {
+	validators := cacheValidators{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}
+	if validators == (cacheValidators{}) {
+		delete(p.validators, endpoint)
+		return
+	}
+	if p.validators == nil {
+		p.validators = make(map[string]cacheValidators)
+	}
+	p.validators[endpoint] = validators
+}
+
+// handlePolledPlmnConfig hands the PLMN config to the NRF registration when it changed.
+// The hand-over is abandoned when ctx is done, as its receiver stops then.
+func (p *nfConfigPoller) handlePolledPlmnConfig(ctx context.Context, newPlmnConfig []models.PlmnId) {
 	if reflect.DeepEqual(p.currentPlmnConfig, newPlmnConfig) {
 		logger.PollConfigLog.Debugf("PLMN config did not change %+v", newPlmnConfig)
 		return
 	}
 	p.currentPlmnConfig = newPlmnConfig
 	logger.PollConfigLog.Infof("PLMN config changed. New PLMN ID list: %+v", p.currentPlmnConfig)
-	p.plmnConfigChan <- p.currentPlmnConfig
+	select {
+	case p.plmnConfigChan <- p.currentPlmnConfig:
+	case <-ctx.Done():
+	}
+}
+
+var fetchAusfPolicy = func(p *nfConfigPoller, endpoint string) (factory.AusfPolicy, error) {
+	return p.fetchAusfPolicy(endpoint)
+}
+
+// fetchAusfPolicy returns the AUSF policy served by the webconsole, or the empty policy
+// when it serves none
+func (p *nfConfigPoller) fetchAusfPolicy(endpoint string) (factory.AusfPolicy, error) {
+	var policy factory.AusfPolicy
+	ctx, cancel := context.WithTimeout(context.Background(), pollingRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return policy, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	p.setConditionalHeaders(req, endpoint)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return policy, fmt.Errorf("HTTP GET %v failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return p.currentPolicy, nil
+	case http.StatusNoContent, http.StatusNotFound:
+		delete(p.validators, endpoint)
+		return policy, nil
+	default:
+		return policy, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.Contains(contentType, "application/json") {
+		return policy, fmt.Errorf("unexpected Content-Type: got %s, want application/json", contentType)
+	}
+	if policy, err = decodeAusfPolicy(resp.Body); err != nil {
+		return policy, err
+	}
+	p.saveValidators(endpoint, resp)
+	return policy, nil
+}
+
+func decodeAusfPolicy(r io.Reader) (factory.AusfPolicy, error) {
+	var policy factory.AusfPolicy
+	if err := json.NewDecoder(r).Decode(&policy); err != nil {
+		return policy, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	if err := factory.ValidateAusfPolicy(&policy); err != nil {
+		return factory.AusfPolicy{}, err
+	}
+	return policy, nil
+}
+
+// pollAusfPolicy fetches the AUSF policy and hands the parts that changed to their
+// subsystems. The policy is kept when it cannot be fetched.
+func (p *nfConfigPoller) pollAusfPolicy(ctx context.Context, endpoint string) {
+	policy, err := fetchAusfPolicy(p, endpoint)
+	if err != nil {
+		logger.PollConfigLog.Warnf("AUSF policy not updated: %v", err)
+		return
+	}
+	p.handlePolledAusfPolicy(ctx, policy)
+}
+
+// handlePolledAusfPolicy hands the parts of the AUSF policy that changed to their
+// subsystems, until ctx is done
+func (p *nfConfigPoller) handlePolledAusfPolicy(ctx context.Context, newPolicy factory.AusfPolicy) {
+	if reflect.DeepEqual(p.currentPolicy, newPolicy) {
+		logger.PollConfigLog.Debugf("AUSF policy did not change %+v", newPolicy)
+		return
+	}
+	current := p.currentPolicy
+	p.currentPolicy = newPolicy
+	logger.PollConfigLog.Infof("AUSF policy changed: %+v", newPolicy)
+	if !reflect.DeepEqual(current.AllowedServingNetworks, newPolicy.AllowedServingNetworks) {
+		select {
+		case p.policyChannels.ServingNetworks <- newPolicy.AllowedServingNetworks:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if !reflect.DeepEqual(current.PlmnPolicies, newPolicy.PlmnPolicies) {
+		select {
+		case p.policyChannels.PlmnAuthPolicies <- newPolicy.PlmnPolicies:
+		case <-ctx.Done():
+			return
+		}
+	}
+	if !reflect.DeepEqual(current.AusfInfo, newPolicy.AusfInfo) {
+		select {
+		case p.policyChannels.AusfInfo <- newPolicy.AusfInfo:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 func minDuration(a, b time.Duration) time.Duration {
@@ -21,16 +21,22 @@ import (
 	"testing"
 	"time"
 
+	"github.com/omec-project/ausf/factory"
 	"github.com/omec-project/openapi/v2/models"
 )
 
 func startPollingServiceForTest(t *testing.T, ch chan<- []models.PlmnId) (context.CancelFunc, <-chan struct{}) {
 	t.Helper()
+	originalFetchAusfPolicy := fetchAusfPolicy
+	fetchAusfPolicy = func(poller *nfConfigPoller, endpoint string) (factory.AusfPolicy, error) {
+		return factory.AusfPolicy{}, nil
+	}
+	t.Cleanup(func() { fetchAusfPolicy = originalFetchAusfPolicy })
 	ctx, cancel := context.WithCancel(context.Background())
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
-		StartPollingService(ctx, "http://dummy", ch)
+		StartPollingService(ctx, "http://dummy", nil, ch, PolicyChannels{})
 	}()
 	return cancel, done
 }
@@ -86,6 +92,41 @@ func TestStartPollingService_RetryAfterFailure(t *testing.T) {
 	t.Logf("Tried %v times", callCount.Load())
 }
 
+func TestStartPollingService_PollsUpdatedWebuiUri(t *testing.T) {
+	originalFetchPlmnConfig := fetchPlmnConfig
+
+	endpoints := make(chan string, 10)
+	fetchPlmnConfig = func(poller *nfConfigPoller, pollingEndpoint string) ([]models.PlmnId, error) {
+		endpoints <- pollingEndpoint
+		return []models.PlmnId{}, nil
+	}
+	currentWebuiUri.Store(nil)
+	plmnChan := make(chan []models.PlmnId, 1)
+	cancel, done := startPollingServiceForTest(t, plmnChan)
+	defer func() {
+		cancel()
+		<-done
+		fetchPlmnConfig = originalFetchPlmnConfig
+	}()
+
+	for deadline := time.Now().Add(time.Second); currentWebuiUri.Load() == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected polling service to start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	SetWebuiUri("http://new-webui:5001")
+
+	select {
+	case endpoint := <-endpoints:
+		if expected := "http://new-webui:5001" + pollingPath; endpoint != expected {
+			t.Errorf("Expected polling endpoint %s, got %s", expected, endpoint)
+		}
+	case <-time.After(initialPollingInterval + time.Second):
+		t.Error("Timeout waiting for polling request")
+	}
+}
+
 func TestHandlePolledPlmnConfig_ConfigChanged_ConfigurationIsUpdatedAndSendToChannel(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -111,7 +152,7 @@ func TestHandlePolledPlmnConfig_ConfigChanged_ConfigurationIsUpdatedAndSendToCha
 				currentPlmnConfig: []models.PlmnId{{Mcc: "001", Mnc: "01"}},
 				plmnConfigChan:    ch,
 			}
-			poller.handlePolledPlmnConfig(tc.newPlmnConfig)
+			poller.handlePolledPlmnConfig(context.Background(), tc.newPlmnConfig)
 
 			if !reflect.DeepEqual(poller.currentPlmnConfig, tc.newPlmnConfig) {
 				t.Errorf("Expected PLMN config to be updated to %v, got %v", tc.newPlmnConfig, poller.currentPlmnConfig)
@@ -149,7 +190,7 @@ func TestHandlePolledPlmnConfig_ConfigDidNotChanged_ConfigIsNotSendToChannel(t *
 				currentPlmnConfig: tc.newPlmnConfig,
 				plmnConfigChan:    ch,
 			}
-			poller.handlePolledPlmnConfig(tc.newPlmnConfig)
+			poller.handlePolledPlmnConfig(context.Background(), tc.newPlmnConfig)
 
 			if !reflect.DeepEqual(poller.currentPlmnConfig, tc.newPlmnConfig) {
 				t.Errorf("Expected PLMN list to remain unchanged, got %v", poller.currentPlmnConfig)
@@ -165,6 +206,33 @@ func TestHandlePolledPlmnConfig_ConfigDidNotChanged_ConfigIsNotSendToChannel(t *
 	}
 }
 
+func TestHandlePolledConfig_ReturnsOnShutdownWhenNothingReceives(t *testing.T) {
+	poller := nfConfigPoller{
+		plmnConfigChan: make(chan []models.PlmnId),
+		policyChannels: PolicyChannels{
+			ServingNetworks:  make(chan []string),
+			PlmnAuthPolicies: make(chan []factory.PlmnAuthPolicy),
+			AusfInfo:         make(chan *factory.AusfInfo),
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		poller.handlePolledPlmnConfig(ctx, []models.PlmnId{{Mcc: "001", Mnc: "01"}})
+		poller.handlePolledAusfPolicy(ctx, factory.AusfPolicy{
+			AllowedServingNetworks: []string{"5G:mnc001.mcc001.3gppnetwork.org"},
+			AusfInfo:               &factory.AusfInfo{RoutingIndicators: []string{"0001"}},
+		})
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the pending sends to be abandoned once the polling service is cancelled")
+	}
+}
+
 func TestFetchPlmnConfig(t *testing.T) {
 	validPlmnList := []models.PlmnId{
 		{Mcc: "001", Mnc: "01"},
@@ -219,6 +287,14 @@ func TestFetchPlmnConfig(t *testing.T) {
 			responseBody:  "",
 			expectedError: "unexpected status code: 418",
 		},
+		{
+			name:           "304 Not Modified without body",
+			statusCode:     http.StatusNotModified,
+			contentType:    "",
+			responseBody:   "",
+			expectedError:  "",
+			expectedResult: []models.PlmnId{{Mcc: "001", Mnc: "01"}},
+		},
 		{
 			name:          "200 OK with invalid JSON",
 			statusCode:    http.StatusOK,
@@ -271,3 +347,175 @@ func TestFetchPlmnConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestPollAusfPolicy_SendsTheChangedParts(t *testing.T) {
+	var served atomic.Pointer[string]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := served.Load()
+		if body == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(*body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	serve := func(body string) { served.Store(&body) }
+
+	servingNetworks := make(chan []string, 1)
+	plmnPolicies := make(chan []factory.PlmnAuthPolicy, 1)
+	ausfInfo := make(chan *factory.AusfInfo, 1)
+	poller := nfConfigPoller{
+		client: server.Client(),
+		policyChannels: PolicyChannels{
+			ServingNetworks:  servingNetworks,
+			PlmnAuthPolicies: plmnPolicies,
+			AusfInfo:         ausfInfo,
+		},
+	}
+	endpoint := server.URL + ausfPolicyPath
+	expectSent := func(expectServingNetworks, expectPlmnPolicies, expectAusfInfo bool) {
+		t.Helper()
+		if sent := len(servingNetworks) == 1; sent != expectServingNetworks {
+			t.Errorf("expected serving networks sent: %v, got %v", expectServingNetworks, sent)
+		}
+		if sent := len(plmnPolicies) == 1; sent != expectPlmnPolicies {
+			t.Errorf("expected PLMN policies sent: %v, got %v", expectPlmnPolicies, sent)
+		}
+		if sent := len(ausfInfo) == 1; sent != expectAusfInfo {
+			t.Errorf("expected AUSF info sent: %v, got %v", expectAusfInfo, sent)
+		}
+	}
+
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(false, false, false)
+
+	serve(`{"allowedServingNetworks":["5G:mnc001.mcc001.3gppnetwork.org"],` +
+		`"plmnPolicies":[{"plmnId":{"mcc":"001","mnc":"01"},"preferredAuthMethod":"5G_AKA","akmaEnabled":true}],` +
+		`"ausfInfo":{"supiRanges":[{"start":"001010000000000","end":"001010000099999"}]}}`)
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(true, true, true)
+	if names := <-servingNetworks; !reflect.DeepEqual(names, []string{"5G:mnc001.mcc001.3gppnetwork.org"}) {
+		t.Errorf("unexpected serving networks %v", names)
+	}
+	if policies := <-plmnPolicies; len(policies) != 1 || !policies[0].AkmaEnabled || policies[0].PlmnId.Mnc != "01" {
+		t.Errorf("unexpected PLMN policies %+v", policies)
+	}
+	if info := <-ausfInfo; info == nil || len(info.SupiRanges) != 1 {
+		t.Errorf("unexpected AUSF info %+v", info)
+	}
+
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(false, false, false)
+
+	serve(`{"allowedServingNetworks":["5G:mnc001.mcc001.3gppnetwork.org"],` +
+		`"plmnPolicies":[{"plmnId":{"mcc":"001","mnc":"01"},"preferredAuthMethod":"EAP_AKA_PRIME"}],` +
+		`"ausfInfo":{"supiRanges":[{"start":"001010000000000","end":"001010000099999"}]}}`)
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(false, true, false)
+	<-plmnPolicies
+
+	serve(`{"plmnPolicies":[{"plmnId":{"mcc":"001","mnc":"01"},"preferredAuthMethod":"EAP_AKA"}]}`)
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(false, false, false)
+
+	served.Store(nil)
+	poller.pollAusfPolicy(context.Background(), endpoint)
+	expectSent(true, true, true)
+	if names, policies, info := <-servingNetworks, <-plmnPolicies, <-ausfInfo; names != nil || policies != nil || info != nil {
+		t.Errorf("expected the policy to be cleared, got %v, %v and %v", names, policies, info)
+	}
+}
+
+func TestFetch_SendsConditionalRequests(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Sun, 18 Oct 2026 10:00:00 GMT"
+	var notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Errorf("unexpected conditional headers %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		body := `[{"mcc":"001","mnc":"01"}]`
+		if r.URL.Path == ausfPolicyPath {
+			body = `{"allowedServingNetworks":["5G:mnc001.mcc001.3gppnetwork.org"]}`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+	servingNetworks := make(chan []string, 1)
+	poller := nfConfigPoller{
+		plmnConfigChan: make(chan []models.PlmnId, 1),
+		policyChannels: PolicyChannels{ServingNetworks: servingNetworks},
+		client:         server.Client(),
+	}
+
+	for range 2 {
+		config, err := fetchPlmnConfig(&poller, server.URL+pollingPath)
+		if err != nil {
+			t.Fatalf("fetchPlmnConfig: %v", err)
+		}
+		poller.handlePolledPlmnConfig(context.Background(), config)
+		poller.pollAusfPolicy(context.Background(), server.URL+ausfPolicyPath)
+	}
+	if notModified.Load() != 2 {
+		t.Errorf("expected both endpoints to answer 304 Not Modified to the second poll, got %d", notModified.Load())
+	}
+	if !reflect.DeepEqual(poller.currentPlmnConfig, []models.PlmnId{{Mcc: "001", Mnc: "01"}}) ||
+		len(poller.currentPolicy.AllowedServingNetworks) != 1 {
+		t.Errorf("expected the configuration to be kept, got %+v and %+v", poller.currentPlmnConfig, poller.currentPolicy)
+	}
+	if len(poller.plmnConfigChan) != 1 || len(servingNetworks) != 1 {
+		t.Error("expected the configuration to be sent once")
+	}
+}
+
+func TestPollingSettings(t *testing.T) {
+	testCases := []struct {
+		name               string
+		cfg                *factory.ConfigUpdates
+		expectedInterval   time.Duration
+		expectedMaxBackoff time.Duration
+		expectedJitter     int
+	}{
+		{"defaults", nil, initialPollingInterval, pollingMaxBackoff, factory.DEFAULT_POLLING_JITTER},
+		{"configured", &factory.ConfigUpdates{PollingInterval: 10, PollingMaxBackoff: 120, PollingJitter: 50},
+			10 * time.Second, 120 * time.Second, 50},
+		{"interval above the default backoff", &factory.ConfigUpdates{PollingInterval: 60},
+			60 * time.Second, 60 * time.Second, factory.DEFAULT_POLLING_JITTER},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interval, maxBackoff, jitter := pollingSettings(tc.cfg)
+			if interval != tc.expectedInterval || maxBackoff != tc.expectedMaxBackoff || jitter != tc.expectedJitter {
+				t.Errorf("expected %v, %v and %d%%, got %v, %v and %d%%", tc.expectedInterval, tc.expectedMaxBackoff,
+					tc.expectedJitter, interval, maxBackoff, jitter)
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	waits := make(map[time.Duration]bool)
+	for range 100 {
+		wait := withJitter(10*time.Second, 20)
+		if wait < 8*time.Second || wait > 10*time.Second {
+			t.Fatalf("expected a wait between 8s and 10s, got %v", wait)
+		}
+		waits[wait] = true
+	}
+	if len(waits) < 2 {
+		t.Error("expected randomized waits")
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polling
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/omec-project/ausf/health"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+const (
+	streamPath = "/nfconfig/stream"
+	// the stream is considered lost when nothing, not even a comment, is received for this long
+	streamIdleTimeout              = 60 * time.Second
+	maxStreamLineSize              = 1 << 20
+	defaultStreamReconnectInterval = initialPollingInterval
+
+	eventPlmnConfig = "plmn"
+	eventAusfPolicy = "ausf-policy"
+)
+
+var errStreamClosed = errors.New("stream closed by the webconsole")
+
+// configEvent is a server-sent event of the webconsole stream
+type configEvent struct {
+	name string
+	data []byte
+}
+
+// runStream keeps a stream of configuration updates open with the webconsole until ctx
+// is done, reconnecting whenever it is lost
+func (p *nfConfigPoller) runStream(ctx context.Context, events chan<- configEvent) {
+	for {
+		endpoint := *currentWebuiUri.Load() + streamPath
+		err := p.stream(ctx, endpoint, events)
+		p.streaming.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.PollConfigLog.Warnf("configuration stream %s lost, polling until it is reconnected in %v: %v",
+			endpoint, p.streamReconnectInterval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.streamReconnectInterval):
+		}
+	}
+}
+
+// stream receives the events of the webconsole stream at endpoint until the stream
+// is closed, goes idle or the webconsole URI changes
+func (p *nfConfigPoller) stream(ctx context.Context, endpoint string, events chan<- configEvent) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(streamIdleTimeout, func() {
+		cancel(fmt.Errorf("nothing received for %v", streamIdleTimeout))
+	})
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := p.streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP GET %v failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		return fmt.Errorf("unexpected Content-Type: got %s, want text/event-stream", contentType)
+	}
+
+	p.streaming.Store(true)
+	health.SetReadiness(health.CheckConfigPolling, nil)
+	logger.PollConfigLog.Infof("receiving configuration updates from %s", endpoint)
+	err = readEvents(resp.Body, func(event configEvent) error {
+		idle.Reset(streamIdleTimeout)
+		if *currentWebuiUri.Load()+streamPath != endpoint {
+			return errors.New("webconsole URI changed")
+		}
+		if event.name == "" && event.data == nil {
+			return nil // comment or keep-alive
+		}
+		select {
+		case events <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// readEvents parses a text/event-stream body, calling handle for every dispatched event
+// and with an empty event for every comment line
+func readEvents(body io.Reader, handle func(configEvent) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxStreamLineSize)
+	var event configEvent
+	var data [][]byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch {
+		case len(line) == 0:
+			if data != nil {
+				event.data = bytes.Join(data, []byte("\n"))
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event, data = configEvent{}, nil
+		case len(field) == 0:
+			if err := handle(configEvent{}); err != nil {
+				return err
+			}
+		case string(field) == "event":
+			event.name = string(value)
+		case string(field) == "data":
+			data = append(data, bytes.Clone(value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errStreamClosed
+}
+
+// handleConfigEvent applies a configuration update pushed by the webconsole, with the
+// same change detection as the polled ones
+func (p *nfConfigPoller) handleConfigEvent(ctx context.Context, event configEvent) {
+	switch event.name {
+	case eventPlmnConfig:
+		var config []models.PlmnId
+		if err := json.Unmarshal(event.data, &config); err != nil {
+			logger.PollConfigLog.Warnf("invalid pushed PLMN config: %v", err)
+			return
+		}
+		p.handlePolledPlmnConfig(ctx, config)
+	case eventAusfPolicy:
+		policy, err := decodeAusfPolicy(bytes.NewReader(event.data))
+		if err != nil {
+			logger.PollConfigLog.Warnf("AUSF policy not updated: %v", err)
+			return
+		}
+		p.handlePolledAusfPolicy(ctx, policy)
+	default:
+		logger.PollConfigLog.Debugf("ignoring configuration event %q", event.name)
+	}
+}
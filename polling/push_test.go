@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the configuration updates pushed by the webconsole
+ */
+
+package polling
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+/*
+ * Push Unit Tests
+ */
+
+func TestReadEvents(t *testing.T) {
+	body := ": keep-alive\n" +
+		"event: plmn\n" +
+		"data: [{\"mcc\":\"001\",\n" +
+		"data: \"mnc\":\"01\"}]\n" +
+		"\n" +
+		"event: ausf-policy\n" +
+		"data:{}\n" +
+		"\n" +
+		"event: incomplete\n"
+	var events []configEvent
+	err := readEvents(strings.NewReader(body), func(event configEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != errStreamClosed {
+		t.Errorf("expected the end of the stream to be reported, got %v", err)
+	}
+	expected := []configEvent{
+		{},
+		{name: "plmn", data: []byte("[{\"mcc\":\"001\",\n\"mnc\":\"01\"}]")},
+		{name: "ausf-policy", data: []byte("{}")},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %q, got %q", expected, events)
+	}
+}
+
+func TestStartPollingService_PushModeFallsBackToPolling(t *testing.T) {
+	var connections atomic.Int32
+	closeStream := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != streamPath || connections.Add(1) > 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: plmn\ndata: [{\"mcc\":\"001\",\"mnc\":\"01\"}]\n\n")
+		fmt.Fprint(w, "event: ausf-policy\ndata: {\"allowedServingNetworks\":[\"5G:mnc001.mcc001.3gppnetwork.org\"]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-closeStream:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	const interval = 20 * time.Millisecond
+	originalPollingSettings := pollingSettings
+	pollingSettings = func(*factory.ConfigUpdates) (time.Duration, time.Duration, int) { return interval, interval, 0 }
+
+	var polls atomic.Int32
+	originalFetchPlmnConfig := fetchPlmnConfig
+	originalFetchAusfPolicy := fetchAusfPolicy
+	fetchPlmnConfig = func(poller *nfConfigPoller, endpoint string) ([]models.PlmnId, error) {
+		polls.Add(1)
+		return []models.PlmnId{{Mcc: "001", Mnc: "01"}}, nil
+	}
+	fetchAusfPolicy = func(poller *nfConfigPoller, endpoint string) (factory.AusfPolicy, error) {
+		return factory.AusfPolicy{AllowedServingNetworks: []string{"5G:mnc001.mcc001.3gppnetwork.org"}}, nil
+	}
+	plmnChan := make(chan []models.PlmnId, 1)
+	servingNetworks := make(chan []string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		poller := newNfConfigPoller(plmnChan, PolicyChannels{ServingNetworks: servingNetworks})
+		poller.streamReconnectInterval = interval
+		poller.run(ctx, server.URL, &factory.ConfigUpdates{Mode: factory.CONFIG_UPDATES_MODE_PUSH})
+	}()
+	defer func() {
+		cancel()
+		<-done
+		fetchPlmnConfig = originalFetchPlmnConfig
+		fetchAusfPolicy = originalFetchAusfPolicy
+		pollingSettings = originalPollingSettings
+	}()
+
+	select {
+	case config := <-plmnChan:
+		if !reflect.DeepEqual(config, []models.PlmnId{{Mcc: "001", Mnc: "01"}}) {
+			t.Errorf("unexpected pushed PLMN config %+v", config)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the pushed PLMN config to be applied without waiting for a poll")
+	}
+	select {
+	case names := <-servingNetworks:
+		if !reflect.DeepEqual(names, []string{"5G:mnc001.mcc001.3gppnetwork.org"}) {
+			t.Errorf("unexpected pushed serving networks %v", names)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the pushed AUSF policy to be applied")
+	}
+
+	time.Sleep(10 * interval)
+	if polls.Load() != 0 {
+		t.Fatalf("expected no polling while the stream is up, got %d polls", polls.Load())
+	}
+
+	close(closeStream)
+	deadline := time.Now().Add(time.Second)
+	for (polls.Load() == 0 || connections.Load() < 2) && time.Now().Before(deadline) {
+		time.Sleep(interval)
+	}
+	if polls.Load() == 0 {
+		t.Error("expected the webconsole to be polled once the stream is lost")
+	}
+	if connections.Load() < 2 {
+		t.Error("expected the stream to be reconnected")
+	}
+	if len(plmnChan) != 0 || len(servingNetworks) != 0 {
+		t.Error("expected the polled configuration to be unchanged from the pushed one")
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package producer
+
+import (
+	"github.com/omec-project/ausf/consumer"
+	ausf_context "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+// ForceDeleteAuthContext removes an authentication context without informing the UDM.
+// It reports whether the context existed.
+func ForceDeleteAuthContext(authCtxID string) bool {
+	if !ausf_context.CheckIfSuciSupiPairExists(authCtxID) {
+		return false
+	}
+	supi := ausf_context.GetSupiFromSuciSupiMap(authCtxID)
+	deleteAuthContextLocally(authCtxID, supi)
+	logger.AdminLog.Infof("auth context %s force-deleted", ausf_context.MaskSupi(authCtxID))
+	return true
+}
+
+// CachedUdmUeauUrl returns the UDM UEAU URL currently used for new authentications
+func CachedUdmUeauUrl() string {
+	udmUrlMu.RLock()
+	defer udmUrlMu.RUnlock()
+	return ausf_context.GetSelf().UdmUeauUrl
+}
+
+// InvalidateUdmCache forgets the cached UDM URL and client, and the cached discovery
+// results, so the next request discovers the UDM again through the NRF
+func InvalidateUdmCache() {
+	invalidateUdmCache()
+	consumer.ClearDiscoveryCache()
+	logger.AdminLog.Infoln("cached UDM URL invalidated")
+}
+
+// AuthContextType returns the authentication method in progress for the UE context
+func AuthContextType(ausfUeContext *ausf_context.AusfUeContext) models.AuthType {
+	return authTypeFromContext(ausfUeContext)
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package producer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/outbox"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+var (
+	authEventOutbox        = outbox.New(deliverAuthEvent, outbox.Options{})
+	asyncAuthEventDelivery atomic.Bool
+	// authEventTimeout bounds every attempt to send an auth event to the UDM, so that a
+	// hung UDM neither blocks the AMF request nor the events queued behind it
+	authEventTimeout = 5 * time.Second
+)
+
+// InitAuthEventDelivery applies the authEventDelivery configuration and restores the
+// events persisted by a previous run. It must be called before StartAuthEventDelivery.
+func InitAuthEventDelivery(cfg *factory.AuthEventDelivery) error {
+	opts := outbox.Options{}
+	mode := factory.AUTH_EVENT_DELIVERY_SYNC
+	if cfg != nil {
+		if cfg.Mode != "" {
+			mode = cfg.Mode
+		}
+		opts.MaxAttempts = cfg.MaxAttempts
+		opts.RetryInterval = time.Duration(cfg.RetryInterval) * time.Second
+		opts.StorePath = cfg.PersistencePath
+		opts.StoreKeyPath = cfg.PersistenceKeyFile
+	}
+	switch mode {
+	case factory.AUTH_EVENT_DELIVERY_SYNC:
+		asyncAuthEventDelivery.Store(false)
+	case factory.AUTH_EVENT_DELIVERY_ASYNC:
+		asyncAuthEventDelivery.Store(true)
+	default:
+		return fmt.Errorf("unsupported authEventDelivery mode %q", mode)
+	}
+	logger.InitLog.Infof("auth event delivery mode: %s", mode)
+	authEventOutbox = outbox.New(deliverAuthEvent, opts)
+	return authEventOutbox.Load()
+}
+
+// StartAuthEventDelivery delivers the queued auth events to the UDM until the context is cancelled
+func StartAuthEventDelivery(ctx context.Context) {
+	authEventOutbox.Run(ctx)
+}
+
+// PendingAuthEvents returns the auth events that have not been delivered to the UDM yet
+func PendingAuthEvents() []outbox.Event {
+	return authEventOutbox.Pending()
+}
+
+func deliverAuthEvent(ev outbox.Event) error {
+	switch ev.Kind {
+	case outbox.KindConfirmAuth:
+		return sendAuthResultToUDM(context.Background(), ev.Supi, ev.AuthType, ev.Success, ev.ServingNetworkName, ev.TimeStamp)
+	case outbox.KindDeleteAuth:
+		return deleteAuthResultFromUDM(context.Background(), ev.Supi, ev.AuthEventID, ev.AuthType, ev.ServingNetworkName, ev.TimeStamp)
+	default:
+		return fmt.Errorf("unknown auth event kind %q", ev.Kind)
+	}
+}
+
+func queueAuthResultForUDM(id string, authType models.AuthType, success bool, servingNetworkName string) {
+	authEventOutbox.Enqueue(outbox.Event{
+		Kind:               outbox.KindConfirmAuth,
+		Supi:               id,
+		AuthType:           authType,
+		Success:            success,
+		ServingNetworkName: servingNetworkName,
+	})
+}
+
+func queueAuthResultDeletionForUDM(supi, authEventID string, authType models.AuthType, servingNetworkName string) {
+	authEventOutbox.Enqueue(outbox.Event{
+		Kind:               outbox.KindDeleteAuth,
+		Supi:               supi,
+		AuthEventID:        authEventID,
+		AuthType:           authType,
+		ServingNetworkName: servingNetworkName,
+	})
+}
+
+// informUDMOfAuthResult sends the confirmation result to the UDM. In async mode the
+// result is queued and the call never fails; in sync mode the UDM error is returned.
+func informUDMOfAuthResult(ctx context.Context, id string, authType models.AuthType, success bool, servingNetworkName string) error {
+	if asyncAuthEventDelivery.Load() {
+		queueAuthResultForUDM(id, authType, success, servingNetworkName)
+		return nil
+	}
+	return sendAuthResultToUDM(ctx, id, authType, success, servingNetworkName, time.Now())
+}
+
+// removeAuthResultFromUDM deletes the authentication result in the UDM. In async mode the
+// deletion is queued and the call never fails; in sync mode the UDM error is returned.
+func removeAuthResultFromUDM(ctx context.Context, supi, authEventID string, authType models.AuthType, servingNetworkName string) error {
+	if asyncAuthEventDelivery.Load() {
+		queueAuthResultDeletionForUDM(supi, authEventID, authType, servingNetworkName)
+		return nil
+	}
+	return deleteAuthResultFromUDM(ctx, supi, authEventID, authType, servingNetworkName, time.Now())
+}
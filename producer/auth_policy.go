@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package producer
+
+import (
+	"context"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+)
+
+var (
+	servingNetworkPlmnRegex = regexp.MustCompile(`^5G:mnc([0-9]{3})[.]mcc([0-9]{3})[.]`)
+
+	// plmnAuthPolicies holds the policies received from the webconsole, by MCC and 3-digit MNC
+	plmnAuthPolicies atomic.Pointer[map[string]factory.PlmnAuthPolicy]
+)
+
+func plmnKey(mcc, mnc string) string {
+	if len(mnc) == 2 {
+		mnc = "0" + mnc
+	}
+	return mcc + mnc
+}
+
+func setPlmnAuthPolicies(policies []factory.PlmnAuthPolicy) {
+	byPlmn := make(map[string]factory.PlmnAuthPolicy, len(policies))
+	for _, policy := range policies {
+		byPlmn[plmnKey(policy.PlmnId.Mcc, policy.PlmnId.Mnc)] = policy
+	}
+	plmnAuthPolicies.Store(&byPlmn)
+}
+
+// plmnAuthPolicy returns the policy of the PLMN of a serving network name, or the zero
+// policy when there is none
+func plmnAuthPolicy(servingNetworkName string) factory.PlmnAuthPolicy {
+	byPlmn := plmnAuthPolicies.Load()
+	match := servingNetworkPlmnRegex.FindStringSubmatch(servingNetworkName)
+	if byPlmn == nil || match == nil {
+		return factory.PlmnAuthPolicy{}
+	}
+	return (*byPlmn)[match[2]+match[1]]
+}
+
+// StartAuthPolicyService applies the per-PLMN authentication policies received from the
+// webconsole until ctx is done or ch is closed
+func StartAuthPolicyService(ctx context.Context, ch <-chan []factory.PlmnAuthPolicy) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case policies, ok := <-ch:
+			if !ok {
+				return
+			}
+			setPlmnAuthPolicies(policies)
+			logger.UeAuthPostLog.Infof("authentication policies updated for %d PLMNs", len(policies))
+		}
+	}
+}
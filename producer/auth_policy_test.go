@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the per-PLMN authentication policies
+ */
+
+package producer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	ausf_context "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/openapi/v2/Nudm_UEAU"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+/*
+ * Auth Policy Unit Tests
+ */
+
+func TestPlmnAuthPolicy(t *testing.T) {
+	defer plmnAuthPolicies.Store(nil)
+	if policy := plmnAuthPolicy("5G:mnc001.mcc001.3gppnetwork.org"); policy != (factory.PlmnAuthPolicy{}) {
+		t.Errorf("expected the zero policy before any policy is received, got %+v", policy)
+	}
+
+	setPlmnAuthPolicies([]factory.PlmnAuthPolicy{
+		{PlmnId: models.PlmnId{Mcc: "001", Mnc: "01"}, PreferredAuthMethod: "5G_AKA", AkmaEnabled: true},
+		{PlmnId: models.PlmnId{Mcc: "208", Mnc: "930"}, PreferredAuthMethod: "EAP_AKA_PRIME"},
+	})
+	testCases := []struct {
+		servingNetworkName string
+		expectedMethod     string
+		expectedAkma       bool
+	}{
+		{"5G:mnc001.mcc001.3gppnetwork.org", "5G_AKA", true},
+		{"5G:mnc930.mcc208.3gppnetwork.org", "EAP_AKA_PRIME", false},
+		{"5G:mnc002.mcc001.3gppnetwork.org", "", false},
+		{"not-a-serving-network", "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.servingNetworkName, func(t *testing.T) {
+			policy := plmnAuthPolicy(tc.servingNetworkName)
+			if policy.PreferredAuthMethod != tc.expectedMethod || policy.AkmaEnabled != tc.expectedAkma {
+				t.Errorf("expected %s with AKMA %v, got %+v", tc.expectedMethod, tc.expectedAkma, policy)
+			}
+		})
+	}
+}
+
+func TestUeAuthPostRequestProcedure_AuthenticatesAkmaSubscribers(t *testing.T) {
+	initProducerTestContext(t)
+	originalResolveUdmURL := resolveUdmURL
+	originalExecuteGenerateAuthData := executeGenerateAuthData
+	defer func() {
+		resolveUdmURL = originalResolveUdmURL
+		executeGenerateAuthData = originalExecuteGenerateAuthData
+		plmnAuthPolicies.Store(nil)
+	}()
+
+	resolveUdmURL = func(context.Context, string) string { return testUdmUrl }
+	executeGenerateAuthData = func(_ context.Context, _ *Nudm_UEAU.APIClient, supiOrSuci string, _ models.AuthenticationInfoRequest) (*models.AuthenticationInfoResult, *http.Response, error) {
+		result := models.NewAuthenticationInfoResult(models.AUTHTYPE__5_G_AKA)
+		result.SetSupi(supiOrSuci)
+		result.SetAkmaInd(true)
+		result.SetAuthenticationVector(models.Av5GHeAkaAsAuthenticationVector(models.NewAv5GHeAka(
+			models.AVTYPE__5_G_HE_AKA,
+			"00112233445566778899aabbccddeeff",
+			"00112233445566778899aabbccddeeff",
+			"00112233445566778899aabbccddeeff",
+			"00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff",
+		)))
+		return result, nil, nil
+	}
+	setPlmnAuthPolicies([]factory.PlmnAuthPolicy{{PlmnId: models.PlmnId{Mcc: "001", Mnc: "01"}, AkmaEnabled: true}})
+
+	for _, servingNetworkName := range []string{"5G:mnc001.mcc001.3gppnetwork.org", "5G:mnc002.mcc001.3gppnetwork.org"} {
+		t.Run(servingNetworkName, func(t *testing.T) {
+			supi := "imsi-001010000000020"
+			defer ausf_context.RemoveSuciSupiPairFromMap(supi)
+			defer ausf_context.RemoveAusfUeContextFromPool(supi)
+			_, _, problemDetails := UeAuthPostRequestProcedure(context.Background(), models.AuthenticationInfo{
+				ServingNetworkName: servingNetworkName,
+				SupiOrSuci:         supi,
+			})
+			if problemDetails != nil {
+				t.Fatalf("expected no problem details, got %+v", problemDetails)
+			}
+			if ausf_context.GetAusfUeContext(supi) == nil {
+				t.Errorf("expected an AUSF UE context for %s", supi)
+			}
+		})
+	}
+}
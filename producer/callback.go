@@ -43,14 +43,18 @@ func NfSubscriptionStatusNotifyProcedure(notificationData models.NotificationDat
 	nfInstanceId := nfInstanceURI[strings.LastIndex(nfInstanceURI, "/")+1:]
 
 	logger.ProducerLog.Infof("Received Subscription Status Notification from NRF: %v", notificationData.GetEvent())
-	// If nrf caching is enabled, go ahead and delete the entry from the cache.
-	// This will force the AUSF to do nf discovery and get the updated nf profile from the NRF.
+	switch notificationData.GetEvent() {
+	case models.NOTIFICATIONEVENTTYPE_NF_REGISTERED, models.NOTIFICATIONEVENTTYPE_NF_PROFILE_CHANGED:
+		removeFromDiscoveryCaches(nfInstanceId)
+		updateCachedUdm(notificationData, nfInstanceId)
+	case models.NOTIFICATIONEVENTTYPE_NF_DEREGISTERED:
+		removeFromDiscoveryCaches(nfInstanceId)
+	}
 	if notificationData.GetEvent() == models.NOTIFICATIONEVENTTYPE_NF_DEREGISTERED {
-		if ausfContext.GetSelf().EnableNrfCaching {
-			ok := NRFCacheRemoveNfProfileFromNrfCache(nfInstanceId)
-			logger.ProducerLog.Debugf("nfinstance %v deleted from cache: %v", nfInstanceId, ok)
-		}
-		if nfProfile, ok := notificationData.GetNfProfileOk(); ok && nfProfile != nil && nfProfile.GetNfType() == models.NFTYPE_UDM {
+		nfProfile, ok := notificationData.GetNfProfileOk()
+		if ok && nfProfile != nil && nfProfile.GetNfType() == models.NFTYPE_UDM {
+			invalidateUdmCache()
+		} else if _, inUse := cachedUdmProfileOf(nfInstanceId); inUse {
 			invalidateUdmCache()
 		}
 		if subscriptionId, ok := ausfContext.GetSelf().NfStatusSubscriptions.Load(nfInstanceId); ok {
@@ -71,3 +75,14 @@ func NfSubscriptionStatusNotifyProcedure(notificationData models.NotificationDat
 
 	return nil
 }
+
+// removeFromDiscoveryCaches deletes the profile of nfInstanceId from the discovery cache and,
+// if nrf caching is enabled, from the nrf cache. This will force the AUSF to do nf discovery
+// and get the updated nf profile from the NRF.
+func removeFromDiscoveryCaches(nfInstanceId string) {
+	consumer.InvalidateDiscoveryCache(nfInstanceId)
+	if ausfContext.GetSelf().IsNrfCachingEnabled() {
+		ok := NRFCacheRemoveNfProfileFromNrfCache(nfInstanceId)
+		logger.ProducerLog.Debugf("nfinstance %v deleted from cache: %v", nfInstanceId, ok)
+	}
+}
@@ -13,8 +13,11 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	neturl "net/url"
 	"strconv"
 	"sync"
 	"time"
@@ -23,32 +26,48 @@ import (
 	"github.com/omec-project/ausf/consumer"
 	ausf_context "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/resilience"
+	"github.com/omec-project/ausf/tracing"
 	"github.com/omec-project/openapi/v2/Nnrf_NFDiscovery"
 	"github.com/omec-project/openapi/v2/Nudm_UEAU"
 	"github.com/omec-project/openapi/v2/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
 )
 
 var (
-	udmUrlMu    sync.RWMutex // protects AUSFContext.UdmUeauUrl
+	udmUrlMu    sync.RWMutex // protects AUSFContext.UdmUeauUrl, udmServerName and cachedUdmProfile
 	udmClientMu sync.Mutex   // protects cachedUdmClient and cachedUdmClientURL
 
+	udmServerName    string                     // TLS server name of UdmUeauUrl, when it is built from an IP address
+	cachedUdmProfile *models.NFProfileDiscovery // profile of the UDM of UdmUeauUrl, kept up to date by the NRF notifications
+
 	cachedUdmClient    *Nudm_UEAU.APIClient
 	cachedUdmClientURL string
 )
 
 var (
 	resolveUdmURL           = GetUdmUrl
-	executeGenerateAuthData = func(client *Nudm_UEAU.APIClient, supiOrSuci string,
+	executeGenerateAuthData = func(ctx context.Context, client *Nudm_UEAU.APIClient, supiOrSuci string,
 		authInfoReq models.AuthenticationInfoRequest,
 	) (*models.AuthenticationInfoResult, *http.Response, error) {
-		apiGenerateAuthDataRequest := client.GenerateAuthDataAPI.GenerateAuthData(context.Background(), supiOrSuci)
+		apiGenerateAuthDataRequest := client.GenerateAuthDataAPI.GenerateAuthData(ctx, supiOrSuci)
 		apiGenerateAuthDataRequest = apiGenerateAuthDataRequest.AuthenticationInfoRequest(authInfoReq)
 		return client.GenerateAuthDataAPI.GenerateAuthDataExecute(apiGenerateAuthDataRequest)
 	}
-	executeDeleteAuth = func(client *Nudm_UEAU.APIClient, supi, authEventID string,
+	executeConfirmAuth = func(ctx context.Context, client *Nudm_UEAU.APIClient, authId string,
 		authEvent models.AuthEvent,
 	) (*http.Response, error) {
-		apiDeleteAuthRequest := client.DeleteAuthAPI.DeleteAuth(context.Background(), supi, authEventID)
+		apiConfirmAuthRequest := client.ConfirmAuthAPI.ConfirmAuth(ctx, authId)
+		apiConfirmAuthRequest = apiConfirmAuthRequest.AuthEvent(authEvent)
+		_, resp, err := client.ConfirmAuthAPI.ConfirmAuthExecute(apiConfirmAuthRequest)
+		return resp, err
+	}
+	executeDeleteAuth = func(ctx context.Context, client *Nudm_UEAU.APIClient, supi, authEventID string,
+		authEvent models.AuthEvent,
+	) (*http.Response, error) {
+		apiDeleteAuthRequest := client.DeleteAuthAPI.DeleteAuth(ctx, supi, authEventID)
 		apiDeleteAuthRequest = apiDeleteAuthRequest.AuthEvent(authEvent)
 		return client.DeleteAuthAPI.DeleteAuthExecute(apiDeleteAuthRequest)
 	}
@@ -285,7 +304,7 @@ func ConstructEapNoTypePkt(code radius.EapCode, pktID uint8) string {
 	return base64.StdEncoding.EncodeToString(b)
 }
 
-func GetUdmUrl(nrfUri string) string {
+func GetUdmUrl(ctx context.Context, nrfUri string) string {
 	self := ausf_context.GetSelf()
 	udmUrlMu.RLock()
 	cached := self.UdmUeauUrl
@@ -294,60 +313,177 @@ func GetUdmUrl(nrfUri string) string {
 		return cached
 	}
 
+	ctx, span := tracing.Start(ctx, "discover UDM UEAU")
+	defer span.End()
+	log := logger.FromContext(ctx, logger.UeAuthPostLog)
+
 	udmUrl := "https://localhost:29503" // default
+	var res *models.SearchResult
+	for _, uri := range append([]string{nrfUri}, self.AlternateNrfUris(nrfUri)...) {
+		if uri != nrfUri {
+			log.Warnf("[Search UDM UEAU] retrying with NRF %s", uri)
+		}
+		if res = searchUdmUeau(ctx, uri); res != nil && len(res.NfInstances) > 0 {
+			break
+		}
+	}
+	if res != nil && len(res.NfInstances) > 0 {
+		for _, udmInstance := range res.NfInstances {
+			url, serverName := selectUdmUeauUrl(udmInstance)
+			if url == "" {
+				continue
+			}
+			cacheUdm(url, serverName, &udmInstance)
+			span.SetAttributes(attribute.String("ausf.udm.url", url))
+			return url
+		}
+		log.Errorln("[search UDM UEAU] no usable UDM service endpoints found")
+	} else {
+		log.Errorln("[search UDM UEAU] len(NfInstances) = 0")
+	}
+	span.SetStatus(codes.Error, "no UDM UEAU instance discovered")
+	return udmUrl
+}
+
+// searchUdmUeau discovers the UDM UEAU instances through the NRF cache, when enabled, and
+// directly through the NRF at nrfUri when the cache returns none
+func searchUdmUeau(ctx context.Context, nrfUri string) *models.SearchResult {
+	log := logger.FromContext(ctx, logger.UeAuthPostLog)
 	configureSearchUDMRequest := func(request Nnrf_NFDiscovery.ApiSearchNFInstancesRequest) Nnrf_NFDiscovery.ApiSearchNFInstancesRequest {
 		return request.ServiceNames([]models.ServiceName{models.SERVICENAME_NUDM_UEAU})
 	}
-	res, err := consumer.SendSearchNFInstances(nrfUri, models.NFTYPE_UDM, models.NFTYPE_AUSF, configureSearchUDMRequest)
+	res, err := consumer.SendSearchNFInstances(ctx, nrfUri, models.NFTYPE_UDM, models.NFTYPE_AUSF, configureSearchUDMRequest)
 	if err != nil {
-		logger.UeAuthPostLog.Errorln("[Search UDM UEAU] ", err.Error())
+		log.Errorln("[Search UDM UEAU] ", err.Error())
 	}
 	if res == nil || len(res.NfInstances) == 0 {
-		directRes, directErr := consumer.SendNfDiscoveryToNrf(context.Background(), nrfUri, models.NFTYPE_UDM, models.NFTYPE_AUSF, configureSearchUDMRequest)
+		directRes, directErr := consumer.SendNfDiscoveryToNrf(ctx, nrfUri, models.NFTYPE_UDM, models.NFTYPE_AUSF, configureSearchUDMRequest)
 		if directErr != nil {
-			logger.UeAuthPostLog.Errorln("[Direct Search UDM UEAU] ", directErr.Error())
+			log.Errorln("[Direct Search UDM UEAU] ", directErr.Error())
 		}
 		if directRes != nil {
 			res = directRes
 		}
 	}
-	if res != nil && len(res.NfInstances) > 0 {
-		for _, udmInstance := range res.NfInstances {
-			for _, ueauService := range udmInstance.NfServices {
-				if ueauService.GetServiceName() != models.SERVICENAME_NUDM_UEAU {
-					continue
-				}
-				if apiPrefix, ok := ueauService.GetApiPrefixOk(); ok && apiPrefix != nil && *apiPrefix != "" {
-					udmUrlMu.Lock()
-					self.UdmUeauUrl = *apiPrefix
-					udmUrlMu.Unlock()
-					return *apiPrefix
-				}
-				for _, ueauEndPoint := range ueauService.IpEndPoints {
-					if ueauEndPoint.GetIpv4Address() == "" || ueauEndPoint.GetPort() == 0 {
-						continue
-					}
-					url := string(ueauService.GetScheme()) + "://" + ueauEndPoint.GetIpv4Address() + ":" + strconv.Itoa(int(ueauEndPoint.GetPort()))
-					udmUrlMu.Lock()
-					self.UdmUeauUrl = url
-					udmUrlMu.Unlock()
-					return url
+	return res
+}
+
+// CheckUdmResolvable reports whether a UDM UEAU instance is known, discovering one through
+// the NRF if none is cached yet. It is used by the readiness probe.
+func CheckUdmResolvable() error {
+	if CachedUdmUeauUrl() != "" {
+		return nil
+	}
+	GetUdmUrl(context.Background(), ausf_context.GetSelf().GetNrfUri())
+	if CachedUdmUeauUrl() == "" {
+		return errors.New("no UDM UEAU instance discovered")
+	}
+	return nil
+}
+
+// selectUdmUeauUrl picks the Nudm_UEAU URL of a discovered UDM. An apiPrefix wins, then the
+// FQDN of the service, then its IP endpoints. When the URL points at an IP literal and the UDM
+// advertises an FQDN, the FQDN is returned as the TLS server name so that SNI and certificate
+// verification match the UDM's certificate.
+func selectUdmUeauUrl(udmInstance models.NFProfileDiscovery) (url string, serverName string) {
+	for _, ueauService := range udmInstance.NfServices {
+		if ueauService.GetServiceName() != models.SERVICENAME_NUDM_UEAU {
+			continue
+		}
+		fqdn := ueauService.GetFqdn()
+		if fqdn == "" {
+			fqdn = udmInstance.GetFqdn()
+		}
+		if apiPrefix, ok := ueauService.GetApiPrefixOk(); ok && apiPrefix != nil && *apiPrefix != "" {
+			return *apiPrefix, tlsServerName(*apiPrefix, fqdn)
+		}
+		if serviceFqdn := ueauService.GetFqdn(); serviceFqdn != "" {
+			for _, ueauEndPoint := range ueauService.IpEndPoints {
+				if ueauEndPoint.GetPort() != 0 {
+					return string(ueauService.GetScheme()) + "://" + net.JoinHostPort(serviceFqdn, strconv.Itoa(int(ueauEndPoint.GetPort()))), ""
 				}
 			}
+			return string(ueauService.GetScheme()) + "://" + serviceFqdn, ""
+		}
+		for _, ueauEndPoint := range ueauService.IpEndPoints {
+			if url := buildUdmUeauUrl(ueauService.GetScheme(), ueauEndPoint, fqdn); url != "" {
+				return url, tlsServerName(url, fqdn)
+			}
 		}
-		logger.UeAuthPostLog.Errorln("[search UDM UEAU] no usable UDM service endpoints found")
-	} else {
-		logger.UeAuthPostLog.Errorln("[search UDM UEAU] len(NfInstances) = 0")
 	}
-	return udmUrl
+	return "", ""
+}
+
+// tlsServerName returns fqdn when the host of rawUrl is an IP literal, and an empty string otherwise
+func tlsServerName(rawUrl, fqdn string) string {
+	if fqdn == "" {
+		return ""
+	}
+	parsedUrl, err := neturl.Parse(rawUrl)
+	if err != nil || net.ParseIP(parsedUrl.Hostname()) == nil {
+		return ""
+	}
+	return fqdn
+}
+
+// cacheUdm makes url, reached with the TLS server name serverName, the UEAU endpoint of
+// the UDM in use, whose profile is kept up to date by the NRF notifications
+func cacheUdm(url, serverName string, profile *models.NFProfileDiscovery) {
+	udmUrlMu.Lock()
+	ausf_context.GetSelf().UdmUeauUrl = url
+	udmServerName = serverName
+	cachedUdmProfile = profile
+	udmUrlMu.Unlock()
+}
+
+// udmServerNameOf returns the TLS server name of udmUrl when it is the cached UDM URL
+func udmServerNameOf(udmUrl string) string {
+	udmUrlMu.RLock()
+	defer udmUrlMu.RUnlock()
+	if udmUrl != ausf_context.GetSelf().UdmUeauUrl {
+		return ""
+	}
+	return udmServerName
+}
+
+// buildUdmUeauUrl builds the UDM UEAU URL of a discovered service endpoint. The IPv4 address
+// is preferred, then the IPv6 address, then the FQDN of the service (or of its NF profile).
+// It returns an empty string when the endpoint is not usable.
+func buildUdmUeauUrl(scheme models.UriScheme, endPoint models.IpEndPoint, fqdn string) string {
+	if endPoint.GetPort() == 0 {
+		return ""
+	}
+	host := endPoint.GetIpv4Address()
+	if host == "" {
+		host = endPoint.GetIpv6Address()
+	}
+	if host == "" {
+		host = fqdn
+	}
+	if host == "" {
+		return ""
+	}
+	return string(scheme) + "://" + net.JoinHostPort(host, strconv.Itoa(int(endPoint.GetPort())))
 }
 
 // invalidateUdmCache clears URL + client caches so the next request triggers fresh NRF discovery.
 func invalidateUdmCache() {
 	udmUrlMu.Lock()
-	ausf_context.GetSelf().UdmUeauUrl = ""
+	clearCachedUdmLocked()
 	udmUrlMu.Unlock()
+	clearCachedUdmClient()
+}
+
+// clearCachedUdmLocked forgets the UDM in use; udmUrlMu must be held
+func clearCachedUdmLocked() {
+	ausf_context.GetSelf().UdmUeauUrl = ""
+	udmServerName = ""
+	cachedUdmProfile = nil
+}
 
+// clearCachedUdmClient forgets the client of the UDM in use. It must not be called with
+// udmUrlMu held, as createClientToUdmUeau takes udmUrlMu with udmClientMu held.
+func clearCachedUdmClient() {
 	udmClientMu.Lock()
 	cachedUdmClient = nil
 	cachedUdmClientURL = ""
@@ -366,21 +502,38 @@ func createClientToUdmUeau(udmUrl string) *Nudm_UEAU.APIClient {
 		apiRootVar.DefaultValue = udmUrl
 		serverConfig.Variables["apiRoot"] = apiRootVar
 	}
+	configuration.HTTPClient = resilience.HTTPClient()
+	if serverName := udmServerNameOf(udmUrl); serverName != "" {
+		configuration.HTTPClient = resilience.HTTPClientWithServerName(serverName)
+	}
 	cachedUdmClient = Nudm_UEAU.NewAPIClient(configuration)
 	cachedUdmClientURL = udmUrl
 	return cachedUdmClient
 }
 
-func sendAuthResultToUDM(id string, authType models.AuthType, success bool, servingNetworkName, udmUrl string) error {
+func newAuthEventForUDM(authType models.AuthType, success bool, servingNetworkName string, timeStamp time.Time) *models.AuthEvent {
 	if servingNetworkName == "" {
 		servingNetworkName = "5G:NSWO"
 	}
-	authEvent := models.NewAuthEvent(ausf_context.GetSelf().NfId, success, time.Now(), authType, servingNetworkName)
+	return models.NewAuthEvent(ausf_context.GetSelf().NfId, success, timeStamp, authType, servingNetworkName)
+}
 
-	client := createClientToUdmUeau(udmUrl)
-	apiConfirmAuthRequest := client.ConfirmAuthAPI.ConfirmAuth(context.Background(), id)
-	apiConfirmAuthRequest = apiConfirmAuthRequest.AuthEvent(*authEvent)
-	_, resp, confirmAuthErr := client.ConfirmAuthAPI.ConfirmAuthExecute(apiConfirmAuthRequest)
+// sendAuthResultToUDM sends the confirmation result to the UDM in use when it is sent,
+// rather than to the UDM the authentication was started with, which the NRF
+// notifications may have changed or evicted since
+func sendAuthResultToUDM(ctx context.Context, id string, authType models.AuthType, success bool, servingNetworkName string,
+	timeStamp time.Time,
+) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, authEventTimeout)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "nudm-ueau ConfirmAuth",
+		attribute.String("ausf.auth_type", string(authType)), attribute.Bool("ausf.auth_success", success))
+	defer func() { tracing.End(span, err) }()
+
+	authEvent := newAuthEventForUDM(authType, success, servingNetworkName, timeStamp)
+
+	client := createClientToUdmUeau(resolveUdmURL(ctx, ausf_context.GetSelf().GetNrfUri()))
+	resp, confirmAuthErr := executeConfirmAuth(ctx, client, id, *authEvent)
 	if resp != nil && resp.Body != nil {
 		defer func() {
 			if rspCloseErr := resp.Body.Close(); rspCloseErr != nil {
@@ -391,15 +544,21 @@ func sendAuthResultToUDM(id string, authType models.AuthType, success bool, serv
 	return confirmAuthErr
 }
 
-func deleteAuthResultFromUDM(supi, authEventID string, authType models.AuthType, servingNetworkName, udmUrl string) error {
-	if servingNetworkName == "" {
-		servingNetworkName = "5G:NSWO"
-	}
-	authEvent := models.NewAuthEvent(ausf_context.GetSelf().NfId, false, time.Now(), authType, servingNetworkName)
+// deleteAuthResultFromUDM deletes the authentication result in the UDM in use when it is
+// sent, as sendAuthResultToUDM
+func deleteAuthResultFromUDM(ctx context.Context, supi, authEventID string, authType models.AuthType, servingNetworkName string,
+	timeStamp time.Time,
+) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, authEventTimeout)
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "nudm-ueau DeleteAuth", attribute.String("ausf.auth_type", string(authType)))
+	defer func() { tracing.End(span, err) }()
+
+	authEvent := newAuthEventForUDM(authType, false, servingNetworkName, timeStamp)
 	authEvent.SetAuthRemovalInd(true)
 
-	client := createClientToUdmUeau(udmUrl)
-	resp, deleteAuthErr := executeDeleteAuth(client, supi, authEventID, *authEvent)
+	client := createClientToUdmUeau(resolveUdmURL(ctx, ausf_context.GetSelf().GetNrfUri()))
+	resp, deleteAuthErr := executeDeleteAuth(ctx, client, supi, authEventID, *authEvent)
 	if resp != nil && resp.Body != nil {
 		defer func() {
 			if rspCloseErr := resp.Body.Close(); rspCloseErr != nil {
@@ -410,17 +569,9 @@ func deleteAuthResultFromUDM(supi, authEventID string, authType models.AuthType,
 	return deleteAuthErr
 }
 
-func logConfirmFailureAndInformUDM(id string, authType models.AuthType, servingNetworkName, errStr, udmUrl string) {
-	switch authType {
-	case models.AUTHTYPE__5_G_AKA:
-		logger.Auth5gAkaComfirmLog.Infoln(errStr)
-		if sendErr := sendAuthResultToUDM(id, authType, false, servingNetworkName, udmUrl); sendErr != nil {
-			logger.Auth5gAkaComfirmLog.Infoln(sendErr.Error())
-		}
-	case models.AUTHTYPE_EAP_AKA_PRIME:
-		logger.EapAuthComfirmLog.Infoln(errStr)
-		if sendErr := sendAuthResultToUDM(id, authType, false, servingNetworkName, udmUrl); sendErr != nil {
-			logger.EapAuthComfirmLog.Infoln(sendErr.Error())
-		}
-	}
+// logConfirmFailureAndInformUDM logs a failed confirmation and queues the failure result for the UDM.
+// The confirmation response does not depend on the UDM, so delivery always goes through the outbox.
+func logConfirmFailureAndInformUDM(log *zap.SugaredLogger, id string, authType models.AuthType, servingNetworkName, errStr string) {
+	log.Infoln(errStr)
+	queueAuthResultForUDM(id, authType, false, servingNetworkName)
 }
@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ausf_context "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/openapi/v2/models"
+)
+
+// cachedUdmProfileOf returns the profile of the UDM in use when it is the NF instance
+// nfInstanceId
+func cachedUdmProfileOf(nfInstanceId string) (models.NFProfileDiscovery, bool) {
+	udmUrlMu.RLock()
+	defer udmUrlMu.RUnlock()
+	if cachedUdmProfile == nil || cachedUdmProfile.GetNfInstanceId() != nfInstanceId {
+		return models.NFProfileDiscovery{}, false
+	}
+	return *cachedUdmProfile, true
+}
+
+// updateCachedUdm applies an NF_REGISTERED or NF_PROFILE_CHANGED notification of the UDM
+// in use to its profile, and selects its UEAU endpoint again. The UDM is evicted, and
+// discovered again on the next authentication, when it is suspended, undiscoverable or
+// no longer has a usable endpoint.
+func updateCachedUdm(notificationData models.NotificationData, nfInstanceId string) {
+	udmUrlMu.Lock()
+	evicted := updateCachedUdmLocked(notificationData, nfInstanceId)
+	udmUrlMu.Unlock()
+	if evicted {
+		clearCachedUdmClient()
+	}
+}
+
+// updateCachedUdmLocked is updateCachedUdm with udmUrlMu held, so that a discovery cannot
+// replace the UDM in use between the check of its instance ID and the update. It returns
+// whether the UDM was evicted.
+func updateCachedUdmLocked(notificationData models.NotificationData, nfInstanceId string) (evicted bool) {
+	if cachedUdmProfile == nil || cachedUdmProfile.GetNfInstanceId() != nfInstanceId {
+		return false
+	}
+	profile := *cachedUdmProfile
+	var err error
+	if notifiedProfile, ok := notificationData.GetNfProfileOk(); ok && notifiedProfile != nil {
+		profile, err = convertProfile[models.NFProfileDiscovery](notifiedProfile)
+	} else if len(notificationData.ProfileChanges) > 0 {
+		profile, err = applyProfileChanges(profile, notificationData.ProfileChanges)
+	} else {
+		return false
+	}
+	if err != nil {
+		logger.ProducerLog.Warnf("profile of UDM %s not updated, the UDM will be discovered again: %v", nfInstanceId, err)
+		clearCachedUdmLocked()
+		return true
+	}
+
+	switch status := profile.GetNfStatus(); status {
+	case models.NFSTATUS_SUSPENDED, models.NFSTATUS_UNDISCOVERABLE:
+		logger.ProducerLog.Infof("UDM %s is %s, the UDM will be discovered again", nfInstanceId, status)
+		clearCachedUdmLocked()
+		return true
+	}
+	url, serverName := selectUdmUeauUrl(profile)
+	if url == "" {
+		logger.ProducerLog.Infof("UDM %s has no usable UEAU endpoint left, the UDM will be discovered again", nfInstanceId)
+		clearCachedUdmLocked()
+		return true
+	}
+	self := ausf_context.GetSelf()
+	if url != self.UdmUeauUrl {
+		logger.ProducerLog.Infof("UEAU endpoint of UDM %s changed to %s", nfInstanceId, url)
+	}
+	self.UdmUeauUrl, udmServerName, cachedUdmProfile = url, serverName, &profile
+	return false
+}
+
+// convertProfile converts between the NF profile models through their JSON encoding
+func convertProfile[T any](profile any) (T, error) {
+	var converted T
+	encoded, err := json.Marshal(profile)
+	if err != nil {
+		return converted, err
+	}
+	err = json.Unmarshal(encoded, &converted)
+	return converted, err
+}
+
+// applyProfileChanges applies the profileChanges of a notification, JSON pointers to the
+// changed attributes of the profile (TS 29.510 clause 6.1.6.2.17), to profile
+func applyProfileChanges(profile models.NFProfileDiscovery, changes []models.ChangeItem) (models.NFProfileDiscovery, error) {
+	document, err := convertProfile[any](profile)
+	if err != nil {
+		return profile, err
+	}
+	for _, change := range changes {
+		if document, err = applyChange(document, change); err != nil {
+			return profile, fmt.Errorf("%s %s: %w", change.Op, change.Path, err)
+		}
+	}
+	return convertProfile[models.NFProfileDiscovery](document)
+}
+
+func applyChange(document any, change models.ChangeItem) (any, error) {
+	path, err := parsePointer(change.Path)
+	if err != nil {
+		return document, err
+	}
+	switch change.Op {
+	case models.CHANGETYPE_ADD, models.CHANGETYPE_REPLACE:
+		return patchAt(document, path, change.Op, change.NewValue)
+	case models.CHANGETYPE_REMOVE:
+		return patchAt(document, path, change.Op, nil)
+	case models.CHANGETYPE_MOVE:
+		from, err := parsePointer(change.GetFrom())
+		if err != nil {
+			return document, err
+		}
+		value, err := valueAt(document, from)
+		if err != nil {
+			return document, err
+		}
+		if document, err = patchAt(document, from, models.CHANGETYPE_REMOVE, nil); err != nil {
+			return document, err
+		}
+		return patchAt(document, path, models.CHANGETYPE_ADD, value)
+	default:
+		return document, fmt.Errorf("unsupported operation")
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, fmt.Errorf("the whole profile cannot be changed")
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func valueAt(document any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := document.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+			document = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			document = node[index]
+		default:
+			return nil, fmt.Errorf("%s not found", token)
+		}
+	}
+	return document, nil
+}
+
+// patchAt adds, replaces or removes the value at path and returns the patched document
+func patchAt(document any, path []string, op models.ChangeType, value any) (any, error) {
+	token, rest := path[0], path[1:]
+	switch node := document.(type) {
+	case map[string]any:
+		if len(rest) > 0 {
+			child, ok := node[token]
+			if !ok {
+				return document, fmt.Errorf("%s not found", token)
+			}
+			child, err := patchAt(child, rest, op, value)
+			node[token] = child
+			return node, err
+		}
+		if _, ok := node[token]; !ok && op != models.CHANGETYPE_ADD {
+			return document, fmt.Errorf("%s not found", token)
+		}
+		if op == models.CHANGETYPE_REMOVE {
+			delete(node, token)
+		} else {
+			node[token] = value
+		}
+		return node, nil
+	case []any:
+		if len(rest) > 0 {
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return document, err
+			}
+			child, err := patchAt(node[index], rest, op, value)
+			node[index] = child
+			return node, err
+		}
+		if op == models.CHANGETYPE_ADD {
+			if token == "-" {
+				return append(node, value), nil
+			}
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return document, err
+			}
+			return append(node[:index], append([]any{value}, node[index:]...)...), nil
+		}
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return document, err
+		}
+		if op == models.CHANGETYPE_REMOVE {
+			return append(node[:index], node[index+1:]...), nil
+		}
+		node[index] = value
+		return node, nil
+	default:
+		return document, fmt.Errorf("%s not found", token)
+	}
+}
+
+func arrayIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > maxIndex {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return index, nil
+}
@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the handling of the NF status notifications of the NRF
+ */
+
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	ausf_context "github.com/omec-project/ausf/context"
+	"github.com/omec-project/openapi/v2"
+	"github.com/omec-project/openapi/v2/Nnrf_NFDiscovery"
+	"github.com/omec-project/openapi/v2/Nudm_UEAU"
+	"github.com/omec-project/openapi/v2/models"
+	nrfCache "github.com/omec-project/openapi/v2/nrfcache"
+)
+
+const testUdmProfile = `{"nfInstanceId":"udm-1","nfType":"UDM","nfStatus":"REGISTERED","nfServices":[{` +
+	`"serviceInstanceId":"ueau","serviceName":"nudm-ueau","scheme":"http","nfServiceStatus":"REGISTERED",` +
+	`"versions":[{"apiVersionInUri":"v1","apiFullVersion":"1.0.0"}],` +
+	`"ipEndPoints":[{"ipv4Address":"10.0.0.1","port":29503}]}]}`
+
+func cacheUdmForTest(t *testing.T) {
+	t.Helper()
+	initProducerTestContext(t)
+	var profile models.NFProfileDiscovery
+	if err := json.Unmarshal([]byte(testUdmProfile), &profile); err != nil {
+		t.Fatalf("invalid test profile: %v", err)
+	}
+	cacheUdm("http://10.0.0.1:29503", "", &profile)
+	t.Cleanup(invalidateUdmCache)
+}
+
+func notificationForTest(event models.NotificationEventType, nfInstanceId string) models.NotificationData {
+	return *models.NewNotificationData(event, "http://nrf:29510/nnrf-nfm/v1/nf-instances/"+nfInstanceId)
+}
+
+func notifiedProfileForTest(t *testing.T, profile string) *models.NotificationDataAllOfNfProfile {
+	t.Helper()
+	var notified models.NotificationDataAllOfNfProfile
+	if err := json.Unmarshal([]byte(profile), &notified); err != nil {
+		t.Fatalf("invalid notified profile: %v", err)
+	}
+	return &notified
+}
+
+/*
+ * NF Status Notification Unit Tests
+ */
+
+func TestNfStatusNotify_NfRegisteredUpdatesTheUdmInUse(t *testing.T) {
+	cacheUdmForTest(t)
+	notification := notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_REGISTERED, "udm-1")
+	notification.SetNfProfile(*notifiedProfileForTest(t, strings.Replace(testUdmProfile, "10.0.0.1", "10.0.0.2", 1)))
+
+	if problemDetails := NfSubscriptionStatusNotifyProcedure(notification); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if url := CachedUdmUeauUrl(); url != "http://10.0.0.2:29503" {
+		t.Errorf("expected the UDM endpoint of the registered profile, got %q", url)
+	}
+}
+
+func TestNfStatusNotify_NfProfileChanged(t *testing.T) {
+	testCases := []struct {
+		name         string
+		nfInstanceId string
+		nfProfile    string
+		changes      []models.ChangeItem
+		expectedUrl  string
+	}{
+		{
+			name:         "endpoint replaced",
+			nfInstanceId: "udm-1",
+			changes: []models.ChangeItem{{
+				Op: models.CHANGETYPE_REPLACE, Path: "/nfServices/0/ipEndPoints/0/ipv4Address", NewValue: "10.0.0.3",
+			}},
+			expectedUrl: "http://10.0.0.3:29503",
+		},
+		{
+			name:         "endpoint added before the current one",
+			nfInstanceId: "udm-1",
+			changes: []models.ChangeItem{{
+				Op: models.CHANGETYPE_ADD, Path: "/nfServices/0/ipEndPoints/0",
+				NewValue: map[string]any{"ipv4Address": "10.0.0.4", "port": 29503},
+			}},
+			expectedUrl: "http://10.0.0.4:29503",
+		},
+		{
+			name:         "full profile",
+			nfInstanceId: "udm-1",
+			nfProfile:    strings.Replace(testUdmProfile, "29503", "8000", 1),
+			expectedUrl:  "http://10.0.0.1:8000",
+		},
+		{
+			name:         "suspended",
+			nfInstanceId: "udm-1",
+			changes:      []models.ChangeItem{{Op: models.CHANGETYPE_REPLACE, Path: "/nfStatus", NewValue: "SUSPENDED"}},
+			expectedUrl:  "",
+		},
+		{
+			name:         "undiscoverable",
+			nfInstanceId: "udm-1",
+			nfProfile:    strings.Replace(testUdmProfile, `"nfStatus":"REGISTERED"`, `"nfStatus":"UNDISCOVERABLE"`, 1),
+			expectedUrl:  "",
+		},
+		{
+			name:         "endpoints removed",
+			nfInstanceId: "udm-1",
+			changes:      []models.ChangeItem{{Op: models.CHANGETYPE_REMOVE, Path: "/nfServices/0/ipEndPoints"}},
+			expectedUrl:  "",
+		},
+		{
+			name:         "change that cannot be applied",
+			nfInstanceId: "udm-1",
+			changes:      []models.ChangeItem{{Op: models.CHANGETYPE_REPLACE, Path: "/nfServices/3/scheme", NewValue: "https"}},
+			expectedUrl:  "",
+		},
+		{
+			name:         "other NF instance",
+			nfInstanceId: "udm-2",
+			changes:      []models.ChangeItem{{Op: models.CHANGETYPE_REPLACE, Path: "/nfStatus", NewValue: "SUSPENDED"}},
+			expectedUrl:  "http://10.0.0.1:29503",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cacheUdmForTest(t)
+			notification := notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_PROFILE_CHANGED, tc.nfInstanceId)
+			if tc.nfProfile != "" {
+				notification.SetNfProfile(*notifiedProfileForTest(t, tc.nfProfile))
+			}
+			notification.ProfileChanges = tc.changes
+
+			if problemDetails := NfSubscriptionStatusNotifyProcedure(notification); problemDetails != nil {
+				t.Fatalf("unexpected problem details %+v", problemDetails)
+			}
+			if url := CachedUdmUeauUrl(); url != tc.expectedUrl {
+				t.Errorf("expected the UDM endpoint %q, got %q", tc.expectedUrl, url)
+			}
+			if _, cached := cachedUdmProfileOf("udm-1"); cached != (tc.expectedUrl != "") {
+				t.Errorf("expected the UDM profile cached: %v", tc.expectedUrl != "")
+			}
+		})
+	}
+}
+
+func TestNfStatusNotify_NfDeregisteredEvictsTheUdmInUse(t *testing.T) {
+	cacheUdmForTest(t)
+	if problemDetails := NfSubscriptionStatusNotifyProcedure(
+		notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_DEREGISTERED, "udm-2")); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if CachedUdmUeauUrl() == "" {
+		t.Fatal("expected the deregistration of another NF instance to keep the UDM in use")
+	}
+
+	if problemDetails := NfSubscriptionStatusNotifyProcedure(
+		notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_DEREGISTERED, "udm-1")); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if url := CachedUdmUeauUrl(); url != "" {
+		t.Errorf("expected the deregistered UDM to be evicted, got %q", url)
+	}
+}
+
+func TestApplyChange(t *testing.T) {
+	document := func() any {
+		return map[string]any{"a": []any{"x", "y"}, "b": map[string]any{"c/d": 1.0, "e~f": 2.0}}
+	}
+	testCases := []struct {
+		name          string
+		change        models.ChangeItem
+		expected      any
+		expectedError bool
+	}{
+		{"add member", models.ChangeItem{Op: models.CHANGETYPE_ADD, Path: "/g", NewValue: true},
+			map[string]any{"a": []any{"x", "y"}, "b": map[string]any{"c/d": 1.0, "e~f": 2.0}, "g": true}, false},
+		{"append", models.ChangeItem{Op: models.CHANGETYPE_ADD, Path: "/a/-", NewValue: "z"},
+			map[string]any{"a": []any{"x", "y", "z"}, "b": map[string]any{"c/d": 1.0, "e~f": 2.0}}, false},
+		{"replace escaped", models.ChangeItem{Op: models.CHANGETYPE_REPLACE, Path: "/b/c~1d", NewValue: 3.0},
+			map[string]any{"a": []any{"x", "y"}, "b": map[string]any{"c/d": 3.0, "e~f": 2.0}}, false},
+		{"remove element", models.ChangeItem{Op: models.CHANGETYPE_REMOVE, Path: "/a/0"},
+			map[string]any{"a": []any{"y"}, "b": map[string]any{"c/d": 1.0, "e~f": 2.0}}, false},
+		{"move", models.ChangeItem{Op: models.CHANGETYPE_MOVE, Path: "/a/0", From: openapi.PtrString("/b/e~0f")},
+			map[string]any{"a": []any{2.0, "x", "y"}, "b": map[string]any{"c/d": 1.0}}, false},
+		{"replace missing", models.ChangeItem{Op: models.CHANGETYPE_REPLACE, Path: "/h", NewValue: 1.0}, nil, true},
+		{"index out of range", models.ChangeItem{Op: models.CHANGETYPE_REMOVE, Path: "/a/2"}, nil, true},
+		{"whole document", models.ChangeItem{Op: models.CHANGETYPE_REPLACE, Path: "", NewValue: 1.0}, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patched, err := applyChange(document(), tc.change)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error, got %v", patched)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(patched, tc.expected) {
+				t.Errorf("expected %v, got %v (%v)", tc.expected, patched, err)
+			}
+		})
+	}
+}
+
+func TestDeleteAuthenticationResult_SentToTheUdmInUse(t *testing.T) {
+	cacheUdmForTest(t)
+	originalExecuteDeleteAuth := executeDeleteAuth
+	t.Cleanup(func() { executeDeleteAuth = originalExecuteDeleteAuth })
+	authCtxID, supi := "imsi-001010000000030", "imsi-001010000000030"
+	ausf_context.AddSuciSupiPairToMap(authCtxID, supi)
+	ausf_context.AddAusfUeContextToPool(&ausf_context.AusfUeContext{
+		Supi: supi, ServingNetworkName: "5G:mnc001.mcc001.3gppnetwork.org", UdmUeauUrl: "http://10.0.0.1:29503",
+	})
+	t.Cleanup(func() {
+		ausf_context.RemoveSuciSupiPairFromMap(authCtxID)
+		ausf_context.RemoveAusfUeContextFromPool(supi)
+	})
+	var udmUrl string
+	executeDeleteAuth = func(_ context.Context, client *Nudm_UEAU.APIClient, _, _ string, _ models.AuthEvent) (*http.Response, error) {
+		udmUrl = client.GetConfig().Servers[0].Variables["apiRoot"].DefaultValue
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+	}
+
+	notification := notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_PROFILE_CHANGED, "udm-1")
+	notification.ProfileChanges = []models.ChangeItem{{
+		Op: models.CHANGETYPE_REPLACE, Path: "/nfServices/0/ipEndPoints/0/ipv4Address", NewValue: "10.0.0.2",
+	}}
+	if problemDetails := NfSubscriptionStatusNotifyProcedure(notification); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if problemDetails := DeleteAuthenticationResultProcedure(context.Background(), authCtxID, models.AUTHTYPE__5_G_AKA); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if udmUrl != "http://10.0.0.2:29503" {
+		t.Errorf("expected the deletion to be sent to the UDM in use, got %q", udmUrl)
+	}
+}
+
+func TestNfStatusNotify_SuspendedUdmIsNotDiscoveredFromTheNrfCache(t *testing.T) {
+	initProducerTestContext(t)
+	invalidateUdmCache()
+	t.Cleanup(invalidateUdmCache)
+	self := ausf_context.GetSelf()
+	originalEnableNrfCaching := self.EnableNrfCaching
+	self.EnableNrfCaching = true
+	t.Cleanup(func() { self.EnableNrfCaching = originalEnableNrfCaching })
+
+	profiles := []string{testUdmProfile, strings.ReplaceAll(strings.Replace(testUdmProfile, "udm-1", "udm-2", 1), "10.0.0.1", "10.0.0.2")}
+	queries := 0
+	nrfCache.InitNrfCaching(time.Minute, func(context.Context, string, models.NFType, models.NFType,
+		Nnrf_NFDiscovery.ApiSearchNFInstancesRequest,
+	) (*models.SearchResult, error) {
+		var profile models.NFProfileDiscovery
+		if err := json.Unmarshal([]byte(profiles[min(queries, len(profiles)-1)]), &profile); err != nil {
+			t.Fatalf("invalid test profile: %v", err)
+		}
+		queries++
+		return &models.SearchResult{NfInstances: []models.NFProfileDiscovery{profile}}, nil
+	})
+
+	const nrfUri = "http://nrf:29510"
+	if url := GetUdmUrl(context.Background(), nrfUri); url != "http://10.0.0.1:29503" {
+		t.Fatalf("expected the UDM returned by the NRF, got %q", url)
+	}
+	notification := notificationForTest(models.NOTIFICATIONEVENTTYPE_NF_PROFILE_CHANGED, "udm-1")
+	notification.ProfileChanges = []models.ChangeItem{{Op: models.CHANGETYPE_REPLACE, Path: "/nfStatus", NewValue: "SUSPENDED"}}
+	if problemDetails := NfSubscriptionStatusNotifyProcedure(notification); problemDetails != nil {
+		t.Fatalf("unexpected problem details %+v", problemDetails)
+	}
+	if url := GetUdmUrl(context.Background(), nrfUri); url != "http://10.0.0.2:29503" {
+		t.Errorf("expected the suspended UDM to be discovered again from the NRF, got %q", url)
+	}
+	if queries != 2 {
+		t.Errorf("expected the NRF to be queried again after the suspension, got %d queries", queries)
+	}
+}
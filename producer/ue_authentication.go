@@ -5,6 +5,7 @@
 package producer
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -15,9 +16,11 @@ import (
 	"strings"
 
 	"github.com/bronze1man/radius"
+	"github.com/omec-project/ausf/audit"
 	ausf_context "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/logger"
 	stats "github.com/omec-project/ausf/metrics"
+	"github.com/omec-project/ausf/tracing"
 	"github.com/omec-project/openapi/v2"
 	"github.com/omec-project/openapi/v2/models"
 	"github.com/omec-project/openapi/v2/utils"
@@ -31,6 +34,37 @@ const (
 	AV_GENERATION_PROBLEM_ERROR          = "AV_GENERATION_PROBLEM"
 )
 
+// Confirmation failure causes reported by ausf_auth_confirmations_total
+const (
+	confirmCauseContextNotFound = "CONTEXT_NOT_FOUND"
+	confirmCauseResMismatch     = "RES_MISMATCH"
+	confirmCauseEapParseError   = "EAP_PACKET_PARSE_ERROR"
+	confirmCauseEapCodeError    = "EAP_CODE_ERROR"
+	confirmCauseEapDecodeError  = "EAP_DECODE_ERROR"
+	confirmCauseAlreadyFailed   = "ALREADY_FAILED"
+)
+
+// recordConfirmation counts and audits a confirmation outcome; an empty cause means success
+func recordConfirmation(ctx context.Context, decision audit.Event, cause string) {
+	stats.IncrementAuthConfirmationStats(decision.AuthType, cause == "", cause)
+	decision.Action = audit.ActionConfirm
+	decision.Result, decision.Cause = audit.ResultSuccess, cause
+	if cause != "" {
+		decision.Result = audit.ResultFailure
+	}
+	audit.Emit(ctx, decision)
+}
+
+// recordDecision audits the outcome of a procedure: a failure with the cause of
+// problemDetails when set, else result
+func recordDecision(ctx context.Context, decision audit.Event, result audit.Result, problemDetails *models.ProblemDetails) {
+	decision.Result = result
+	if problemDetails != nil {
+		decision.Result, decision.Cause = audit.ResultFailure, problemDetails.GetCause()
+	}
+	audit.Emit(ctx, decision)
+}
+
 // EAP constants
 const (
 	EAPCodeRequest  = 1
@@ -59,13 +93,13 @@ func GenerateRandomNumber() (uint8, error) {
 	return uint8(randomNumber.Int64()), nil
 }
 
-func HandleEapAuthComfirmRequest(request *httpwrapper.Request) *httpwrapper.Response {
-	logger.EapAuthComfirmLog.Infoln("EapAuthConfirmRequest")
+func HandleEapAuthComfirmRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
+	logger.FromContext(ctx, logger.EapAuthComfirmLog).Infoln("EapAuthConfirmRequest")
 
 	updateEapSession := request.Body.(models.EapSession)
 	eapSessionID := request.Params["authCtxId"]
 
-	response, problemDetails := EapAuthComfirmRequestProcedure(updateEapSession, eapSessionID)
+	response, problemDetails := EapAuthComfirmRequestProcedure(ctx, updateEapSession, eapSessionID)
 
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
@@ -76,12 +110,12 @@ func HandleEapAuthComfirmRequest(request *httpwrapper.Request) *httpwrapper.Resp
 	return httpwrapper.NewResponse(http.StatusForbidden, nil, problemDetails)
 }
 
-func HandleAuth5gAkaComfirmRequest(request *httpwrapper.Request) *httpwrapper.Response {
-	logger.Auth5gAkaComfirmLog.Infoln("Auth5gAkaComfirmRequest")
+func HandleAuth5gAkaComfirmRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
+	logger.FromContext(ctx, logger.Auth5gAkaComfirmLog).Infoln("Auth5gAkaComfirmRequest")
 	updateConfirmationData := request.Body.(models.ConfirmationData)
 	ConfirmationDataResponseID := request.Params["authCtxId"]
 
-	response, problemDetails := Auth5gAkaComfirmRequestProcedure(updateConfirmationData, ConfirmationDataResponseID)
+	response, problemDetails := Auth5gAkaComfirmRequestProcedure(ctx, updateConfirmationData, ConfirmationDataResponseID)
 	if response != nil {
 		return httpwrapper.NewResponse(http.StatusOK, nil, response)
 	} else if problemDetails != nil {
@@ -91,11 +125,11 @@ func HandleAuth5gAkaComfirmRequest(request *httpwrapper.Request) *httpwrapper.Re
 	return httpwrapper.NewResponse(http.StatusForbidden, nil, problemDetails)
 }
 
-func HandleUeAuthPostRequest(request *httpwrapper.Request) *httpwrapper.Response {
-	logger.UeAuthPostLog.Infoln("HandleUeAuthPostRequest")
+func HandleUeAuthPostRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
+	logger.FromContext(ctx, logger.UeAuthPostLog).Infoln("HandleUeAuthPostRequest")
 	updateAuthenticationInfo := request.Body.(models.AuthenticationInfo)
 
-	response, locationURI, problemDetails := UeAuthPostRequestProcedure(updateAuthenticationInfo)
+	response, locationURI, problemDetails := UeAuthPostRequestProcedure(ctx, updateAuthenticationInfo)
 	respHeader := make(http.Header)
 	respHeader.Set("Location", locationURI)
 
@@ -111,25 +145,25 @@ func HandleUeAuthPostRequest(request *httpwrapper.Request) *httpwrapper.Response
 	return httpwrapper.NewResponse(http.StatusForbidden, nil, problemDetails)
 }
 
-func HandleDelete5gAkaAuthenticationResultRequest(request *httpwrapper.Request) *httpwrapper.Response {
-	problemDetails := DeleteAuthenticationResultProcedure(request.Params["authCtxId"], models.AUTHTYPE__5_G_AKA)
+func HandleDelete5gAkaAuthenticationResultRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
+	problemDetails := DeleteAuthenticationResultProcedure(ctx, request.Params["authCtxId"], models.AUTHTYPE__5_G_AKA)
 	if problemDetails != nil {
 		return httpwrapper.NewResponse(int(problemDetails.GetStatus()), nil, problemDetails)
 	}
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, nil)
 }
 
-func HandleDeleteEapAuthenticationResultRequest(request *httpwrapper.Request) *httpwrapper.Response {
-	problemDetails := DeleteAuthenticationResultProcedure(request.Params["authCtxId"], models.AUTHTYPE_EAP_AKA_PRIME)
+func HandleDeleteEapAuthenticationResultRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
+	problemDetails := DeleteAuthenticationResultProcedure(ctx, request.Params["authCtxId"], models.AUTHTYPE_EAP_AKA_PRIME)
 	if problemDetails != nil {
 		return httpwrapper.NewResponse(int(problemDetails.GetStatus()), nil, problemDetails)
 	}
 	return httpwrapper.NewResponse(http.StatusNoContent, nil, nil)
 }
 
-func HandleUeAuthenticationsDeregisterRequest(request *httpwrapper.Request) *httpwrapper.Response {
+func HandleUeAuthenticationsDeregisterRequest(ctx context.Context, request *httpwrapper.Request) *httpwrapper.Response {
 	deregistrationInfo := request.Body.(models.DeregistrationInfo)
-	problemDetails := DeregisterAuthContextProcedure(deregistrationInfo)
+	problemDetails := DeregisterAuthContextProcedure(ctx, deregistrationInfo)
 	if problemDetails != nil {
 		return httpwrapper.NewResponse(int(problemDetails.GetStatus()), nil, problemDetails)
 	}
@@ -153,19 +187,27 @@ func authTypeFromContext(ausfCurrentContext *ausf_context.AusfUeContext) models.
 	return models.AUTHTYPE__5_G_AKA
 }
 
-func DeleteAuthenticationResultProcedure(authCtxID string, authType models.AuthType) *models.ProblemDetails {
+func DeleteAuthenticationResultProcedure(ctx context.Context, authCtxID string, authType models.AuthType) (problemDetails *models.ProblemDetails) {
+	decision := audit.Event{Action: audit.ActionDeleteAuthResult, AuthCtxID: authCtxID, AuthType: string(authType)}
+	defer func() {
+		recordDecision(ctx, decision, audit.ResultSuccess, problemDetails)
+	}()
+
 	if !ausf_context.CheckIfSuciSupiPairExists(authCtxID) {
 		return utils.ProblemDetailsUserNotFound()
 	}
 
 	currentSupi := ausf_context.GetSupiFromSuciSupiMap(authCtxID)
+	decision.Supi = currentSupi
 	if !ausf_context.CheckIfAusfUeContextExists(currentSupi) {
 		return utils.ProblemDetailsUserNotFound()
 	}
 
 	ausfCurrentContext := ausf_context.GetAusfUeContext(currentSupi)
-	if err := deleteAuthResultFromUDM(currentSupi, authCtxID, authType, ausfCurrentContext.ServingNetworkName,
-		ausfCurrentContext.UdmUeauUrl); err != nil {
+	decision.ServingNetworkName = ausfCurrentContext.ServingNetworkName
+	if err := removeAuthResultFromUDM(ctx, currentSupi, authCtxID, authType, ausfCurrentContext.ServingNetworkName); err != nil {
+		logger.FromContext(ctx, logger.UeAuthPostLog).With("supi", ausf_context.MaskSupi(currentSupi), "authType", string(authType)).
+			Errorf("deleting the authentication result in the UDM failed: %v", err)
 		return utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, "", UPSTREAM_SERVER_ERROR)
 	}
 
@@ -173,8 +215,13 @@ func DeleteAuthenticationResultProcedure(authCtxID string, authType models.AuthT
 	return nil
 }
 
-func DeregisterAuthContextProcedure(deregistrationInfo models.DeregistrationInfo) *models.ProblemDetails {
+func DeregisterAuthContextProcedure(ctx context.Context, deregistrationInfo models.DeregistrationInfo) (problemDetails *models.ProblemDetails) {
 	supi := deregistrationInfo.GetSupi()
+	decision := audit.Event{Action: audit.ActionDeregister, Supi: supi}
+	defer func() {
+		recordDecision(ctx, decision, audit.ResultSuccess, problemDetails)
+	}()
+
 	authCtxIDs := ausf_context.ListSuciSupiPairsForSupi(supi)
 
 	if len(authCtxIDs) == 0 {
@@ -190,17 +237,18 @@ func DeregisterAuthContextProcedure(deregistrationInfo models.DeregistrationInfo
 	}
 
 	servingNetworkName := ""
-	udmURL := resolveUdmURL(ausf_context.GetSelf().NrfUri)
 	authType := authTypeFromContext(ausfCurrentContext)
+	decision.AuthType = string(authType)
 	if ausfCurrentContext != nil {
 		servingNetworkName = ausfCurrentContext.ServingNetworkName
-		if ausfCurrentContext.UdmUeauUrl != "" {
-			udmURL = ausfCurrentContext.UdmUeauUrl
-		}
+		decision.ServingNetworkName = servingNetworkName
 	}
 
 	for _, authCtxID := range authCtxIDs {
-		if err := deleteAuthResultFromUDM(supi, authCtxID, authType, servingNetworkName, udmURL); err != nil {
+		if err := removeAuthResultFromUDM(ctx, supi, authCtxID, authType, servingNetworkName); err != nil {
+			logger.FromContext(ctx, logger.UeAuthPostLog).With("authCtxId", ausf_context.MaskSupi(authCtxID),
+				"supi", ausf_context.MaskSupi(supi), "authType", string(authType)).
+				Errorf("deleting the authentication result in the UDM failed: %v", err)
 			return utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, "", UPSTREAM_SERVER_ERROR)
 		}
 	}
@@ -212,22 +260,30 @@ func DeregisterAuthContextProcedure(deregistrationInfo models.DeregistrationInfo
 	return nil
 }
 
-func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationInfo) (*models.UEAuthenticationCtx,
-	string, *models.ProblemDetails,
+func UeAuthPostRequestProcedure(ctx context.Context, updateAuthenticationInfo models.AuthenticationInfo) (responseBody *models.UEAuthenticationCtx,
+	locationURI string, problemDetails *models.ProblemDetails,
 ) {
-	responseBody := models.NewUEAuthenticationCtxWithDefaults()
+	responseBody = models.NewUEAuthenticationCtxWithDefaults()
 	var authInfoReq models.AuthenticationInfoRequest
 
 	supiOrSuci := updateAuthenticationInfo.SupiOrSuci
+	ctx = logger.WithFields(ctx, "authCtxId", ausf_context.MaskSupi(supiOrSuci))
+	log := logger.FromContext(ctx, logger.UeAuthPostLog)
 
 	snName := updateAuthenticationInfo.ServingNetworkName
+	decision := audit.Event{Action: audit.ActionAuthenticate, AuthCtxID: supiOrSuci, ServingNetworkName: snName}
+	defer func() {
+		recordDecision(ctx, decision, audit.ResultChallenge, problemDetails)
+	}()
+
 	servingNetworkAuthorized := ausf_context.IsServingNetworkAuthorized(snName)
 	if !servingNetworkAuthorized {
-		problemDetails := utils.ProblemDetailsWithCause("Serving network not authorized", http.StatusForbidden, "", SERVING_NETWORK_NOT_AUTHORIZED_ERROR)
-		logger.UeAuthPostLog.Infoln("403 forbidden: serving network NOT AUTHORIZED")
+		problemDetails = utils.ProblemDetailsWithCause("Serving network not authorized", http.StatusForbidden, "", SERVING_NETWORK_NOT_AUTHORIZED_ERROR)
+		log.Infoln("403 forbidden: serving network NOT AUTHORIZED")
+		stats.IncrementServingNetworkRejectStats(snName)
 		return nil, "", problemDetails
 	}
-	logger.UeAuthPostLog.Infoln("serving network authorized")
+	log.Infoln("serving network authorized")
 
 	responseBody.SetServingNetworkName(snName)
 	authInfoReq.ServingNetworkName = snName
@@ -235,29 +291,32 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 	authInfoReq.AusfInstanceId = self.GetSelfID()
 
 	if updateAuthenticationInfo.ResynchronizationInfo != nil {
-		logger.UeAuthPostLog.Warnln("Auts:", updateAuthenticationInfo.ResynchronizationInfo.Auts)
+		stats.IncrementResynchronizationStats(snName)
+		log.Warnln("Auts:", updateAuthenticationInfo.ResynchronizationInfo.Auts)
 		ausfCurrentSupi := ausf_context.GetSupiFromSuciSupiMap(supiOrSuci)
-		logger.UeAuthPostLog.Warnln(ausfCurrentSupi)
+		log.Warnln(ausf_context.MaskSupi(ausfCurrentSupi))
 		ausfCurrentContext := ausf_context.GetAusfUeContext(ausfCurrentSupi)
-		logger.UeAuthPostLog.Warnln(ausfCurrentContext.Rand)
+		log.Warnln(ausfCurrentContext.Rand)
 		updateAuthenticationInfo.ResynchronizationInfo.Rand = ausfCurrentContext.Rand
-		logger.UeAuthPostLog.Warnln("Rand:", updateAuthenticationInfo.ResynchronizationInfo.Rand)
+		log.Warnln("Rand:", updateAuthenticationInfo.ResynchronizationInfo.Rand)
 		authInfoReq.ResynchronizationInfo = updateAuthenticationInfo.ResynchronizationInfo
 	}
 
-	udmUrl := resolveUdmURL(self.NrfUri)
+	udmUrl := resolveUdmURL(ctx, self.GetNrfUri())
 	client := createClientToUdmUeau(udmUrl)
-	authInfoResult, rsp, err := executeGenerateAuthData(client, supiOrSuci, authInfoReq)
+	generateCtx, span := tracing.Start(ctx, "nudm-ueau GenerateAuthData")
+	authInfoResult, rsp, err := executeGenerateAuthData(generateCtx, client, supiOrSuci, authInfoReq)
+	tracing.End(span, err)
 	defer func() {
 		if rsp == nil || rsp.Body == nil {
 			return
 		}
 		if rspCloseErr := rsp.Body.Close(); rspCloseErr != nil {
-			logger.UeAuthPostLog.Errorf("GenerateAuthDataApi response body cannot close: %+v", rspCloseErr)
+			log.Errorf("GenerateAuthDataApi response body cannot close: %+v", rspCloseErr)
 		}
 	}()
 	if err != nil {
-		logger.UeAuthPostLog.Infoln(err.Error())
+		log.Infoln(err.Error())
 		if authInfoResult == nil || authInfoResult.AuthenticationVector == nil {
 			return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "", AV_GENERATION_PROBLEM_ERROR)
 		} else {
@@ -266,40 +325,46 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 	}
 
 	ueid := authInfoResult.GetSupi()
+	decision.Supi, decision.AuthType = ueid, string(authInfoResult.AuthType)
+	log = log.With("supi", ausf_context.MaskSupi(ueid), "authType", string(authInfoResult.AuthType))
 	ausfUeContext := ausf_context.NewAusfUeContext(ueid)
 	ausfUeContext.ServingNetworkName = snName
-	ausfUeContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_ONGOING
+	ausfUeContext.StartAuthentication()
 	ausfUeContext.UdmUeauUrl = udmUrl
+	policy := plmnAuthPolicy(snName)
+	if policy.PreferredAuthMethod != "" && policy.PreferredAuthMethod != string(authInfoResult.AuthType) {
+		log.Warnf("UDM selected %s whereas %s is preferred in this PLMN", authInfoResult.AuthType, policy.PreferredAuthMethod)
+	}
 
-	locationURI := self.Url + "/nausf-auth/v1/ue-authentications/" + supiOrSuci
+	locationURI = self.Url + "/nausf-auth/v1/ue-authentications/" + supiOrSuci
 	putLink := locationURI
 	switch authInfoResult.AuthType {
 	case models.AUTHTYPE__5_G_AKA:
-		logger.UeAuthPostLog.Infoln("use 5G AKA auth method")
+		log.Infoln("use 5G AKA auth method")
 		putLink += "/5g-aka-confirmation"
 
 		// Derive HXRES* from XRES*
 		concat := authInfoResult.AuthenticationVector.Av5GHeAka.GetRand() + authInfoResult.AuthenticationVector.Av5GHeAka.GetXresStar()
 		hxresStarBytes, err := hex.DecodeString(concat)
 		if err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("decode error: %+v", err)
+			log.Warnf("decode error: %+v", err)
 			return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "Failed to derive HXRES*", AV_GENERATION_PROBLEM_ERROR)
 		}
 		hxresStarAll := sha256.Sum256(hxresStarBytes)
 		hxresStar := hex.EncodeToString(hxresStarAll[16:]) // last 128 bits
-		logger.Auth5gAkaComfirmLog.Infof("XresStar = %s", authInfoResult.AuthenticationVector.Av5GHeAka.GetXresStar())
+		log.Infof("XresStar = %s", authInfoResult.AuthenticationVector.Av5GHeAka.GetXresStar())
 
 		// Derive Kseaf from Kausf
 		Kausf := authInfoResult.AuthenticationVector.Av5GHeAka.GetKausf()
 		ausfDecode, err := hex.DecodeString(Kausf)
 		if err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("AUSF decode failed: %+v", err)
+			log.Warnf("AUSF decode failed: %+v", err)
 			return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "Failed to decode Kausf", AV_GENERATION_PROBLEM_ERROR)
 		}
 		P0 := []byte(snName)
 		Kseaf, err := ueauth.GetKDFValue(ausfDecode, ueauth.FC_FOR_KSEAF_DERIVATION, P0, ueauth.KDFLen(P0))
 		if err != nil {
-			logger.Auth5gAkaComfirmLog.Error(err)
+			log.Error(err)
 			return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "Failed to derive Kseaf", AV_GENERATION_PROBLEM_ERROR)
 		}
 		ausfUeContext.XresStar = authInfoResult.AuthenticationVector.Av5GHeAka.GetXresStar()
@@ -317,7 +382,7 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 
 		responseBody.SetVar5gAuthData(av5gAkaCtx)
 	case models.AUTHTYPE_EAP_AKA_PRIME:
-		logger.UeAuthPostLog.Infoln("use EAP-AKA' auth method")
+		log.Infoln("use EAP-AKA' auth method")
 		putLink += "/eap-session"
 
 		identity := ueid
@@ -336,13 +401,13 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 		Kausf := EMSK[0:32]
 		ausfUeContext.Kausf = Kausf
 		if ausfDecode, err := hex.DecodeString(Kausf); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("AUSF decode failed: %+v", err)
+			log.Warnf("AUSF decode failed: %+v", err)
 			return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "Failed to decode Kausf", AV_GENERATION_PROBLEM_ERROR)
 		} else {
 			P0 := []byte(snName)
 			Kseaf, err := ueauth.GetKDFValue(ausfDecode, ueauth.FC_FOR_KSEAF_DERIVATION, P0, ueauth.KDFLen(P0))
 			if err != nil {
-				logger.Auth5gAkaComfirmLog.Error(err)
+				log.Error(err)
 				return nil, "", utils.ProblemDetailsWithCause("AV generation problem", http.StatusInternalServerError, "Failed to derive Kseaf", AV_GENERATION_PROBLEM_ERROR)
 			}
 			ausfUeContext.Kseaf = hex.EncodeToString(Kseaf)
@@ -351,34 +416,34 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 		var eapPkt radius.EapPacket
 		randIdentifier, err := GenerateRandomNumber()
 		if err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("generate random number failed: %+v", err)
+			log.Warnf("generate random number failed: %+v", err)
 		}
 		eapPkt.Identifier = randIdentifier
 		eapPkt.Code = radius.EapCode(1)
 		eapPkt.Type = radius.EapType(50) // according to RFC5448 6.1
 		var atRand, atAutn, atKdf, atKdfInput, atMAC string
 		if atRandTmp, err := EapEncodeAttribute("AT_RAND", RAND); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("EAP encode RAND failed: %+v", err)
+			log.Warnf("EAP encode RAND failed: %+v", err)
 		} else {
 			atRand = atRandTmp
 		}
 		if atAutnTmp, err := EapEncodeAttribute("AT_AUTN", AUTN); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("EAP encode AUTN failed: %+v", err)
+			log.Warnf("EAP encode AUTN failed: %+v", err)
 		} else {
 			atAutn = atAutnTmp
 		}
 		if atKdfTmp, err := EapEncodeAttribute("AT_KDF", snName); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("EAP encode KDF failed: %+v", err)
+			log.Warnf("EAP encode KDF failed: %+v", err)
 		} else {
 			atKdf = atKdfTmp
 		}
 		if atKdfInputTmp, err := EapEncodeAttribute("AT_KDF_INPUT", snName); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("EAP encode KDF failed: %+v", err)
+			log.Warnf("EAP encode KDF failed: %+v", err)
 		} else {
 			atKdfInput = atKdfInputTmp
 		}
 		if atMACTmp, err := EapEncodeAttribute("AT_MAC", ""); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("EAP encode MAC failed: %+v", err)
+			log.Warnf("EAP encode MAC failed: %+v", err)
 		} else {
 			atMAC = atMACTmp
 		}
@@ -391,7 +456,7 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 		atMacNum := fmt.Sprintf("%02x", ausf_context.AT_MAC_ATTRIBUTE)
 		var atMACfirstRow []byte
 		if atMACfirstRowTmp, err := hex.DecodeString(atMacNum + "05" + "0000"); err != nil {
-			logger.Auth5gAkaComfirmLog.Warnf("MAC decode failed: %+v", err)
+			log.Warnf("MAC decode failed: %+v", err)
 		} else {
 			atMACfirstRow = atMACfirstRowTmp
 		}
@@ -407,12 +472,16 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 		}
 		responseBody.SetVar5gAuthData(uEAuthenticationCtx5gAuthData)
 	default:
-		logger.UeAuthPostLog.Warnf("unsupported auth type: %s", authInfoResult.AuthType)
+		log.Warnf("unsupported auth type: %s", authInfoResult.AuthType)
 		return nil, "", utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, fmt.Sprintf("unsupported auth type: %s", authInfoResult.AuthType), UPSTREAM_SERVER_ERROR)
 	}
 
+	if authInfoResult.GetAkmaInd() && policy.AkmaEnabled {
+		log.Debugln("AKMA subscriber: no AKMA keys are derived, AAnF is not supported")
+	}
+
 	ausf_context.AddAusfUeContextToPool(ausfUeContext)
-	logger.UeAuthPostLog.Infof("add SuciSupiPair (%s, %s) to map", supiOrSuci, ueid)
+	log.Infoln("add SuciSupiPair to map")
 	ausf_context.AddSuciSupiPairToMap(supiOrSuci, ueid)
 
 	putLinkPtr := models.NewLink()
@@ -425,51 +494,61 @@ func UeAuthPostRequestProcedure(updateAuthenticationInfo models.AuthenticationIn
 	return responseBody, locationURI, nil
 }
 
-// func Auth5gAkaComfirmRequestProcedure(updateConfirmationData models.ConfirmationData,
+// func Auth5gAkaComfirmRequestProcedure(ctx context.Context, updateConfirmationData models.ConfirmationData,
 //	ConfirmationDataResponseID string) (response *models.ConfirmationDataResponse,
 //  problemDetails *models.ProblemDetails) {
 
-func Auth5gAkaComfirmRequestProcedure(updateConfirmationData models.ConfirmationData,
+func Auth5gAkaComfirmRequestProcedure(ctx context.Context, updateConfirmationData models.ConfirmationData,
 	ConfirmationDataResponseID string,
 ) (*models.ConfirmationDataResponse, *models.ProblemDetails) {
+	log := logger.FromContext(ctx, logger.Auth5gAkaComfirmLog).With("authType", string(models.AUTHTYPE__5_G_AKA))
+	decision := audit.Event{AuthCtxID: ConfirmationDataResponseID, AuthType: string(models.AUTHTYPE__5_G_AKA)}
 	responseBody := models.NewConfirmationDataResponse(models.AUTHRESULT_AUTHENTICATION_FAILURE)
 	success := false
 	responseBody.AuthResult = models.AUTHRESULT_AUTHENTICATION_FAILURE
 
 	if !ausf_context.CheckIfSuciSupiPairExists(ConfirmationDataResponseID) {
-		logger.Auth5gAkaComfirmLog.Infof("supiSuciPair does not exist, confirmation failed (queried by %s)",
-			ConfirmationDataResponseID)
+		log.Infoln("supiSuciPair does not exist, confirmation failed")
+		recordConfirmation(ctx, decision, confirmCauseContextNotFound)
 		return nil, utils.ProblemDetailsUserNotFound()
 	}
 
 	currentSupi := ausf_context.GetSupiFromSuciSupiMap(ConfirmationDataResponseID)
+	decision.Supi = currentSupi
+	log = log.With("supi", ausf_context.MaskSupi(currentSupi))
 	if !ausf_context.CheckIfAusfUeContextExists(currentSupi) {
-		logger.Auth5gAkaComfirmLog.Infof("SUPI does not exist, confirmation failed (queried by %s)", currentSupi)
+		log.Infoln("SUPI does not exist, confirmation failed")
+		recordConfirmation(ctx, decision, confirmCauseContextNotFound)
 		return nil, utils.ProblemDetailsUserNotFound()
 	}
 
 	ausfCurrentContext := ausf_context.GetAusfUeContext(currentSupi)
 	servingNetworkName := ausfCurrentContext.ServingNetworkName
+	decision.ServingNetworkName = servingNetworkName
 
 	// Compare the received RES* with the stored XRES*
-	logger.Auth5gAkaComfirmLog.Infof("res*: %s, Xres*: %s", updateConfirmationData.GetResStar(), ausfCurrentContext.XresStar)
+	log.Infof("res*: %s, Xres*: %s", updateConfirmationData.GetResStar(), ausfCurrentContext.XresStar)
 	if strings.Compare(updateConfirmationData.GetResStar(), ausfCurrentContext.XresStar) == 0 {
-		ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_SUCCESS
+		ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_SUCCESS)
 		responseBody.AuthResult = models.AUTHRESULT_AUTHENTICATION_SUCCESS
 		success = true
-		logger.Auth5gAkaComfirmLog.Infoln("5G AKA confirmation succeeded")
+		log.Infoln("5G AKA confirmation succeeded")
 		responseBody.SetKseaf(ausfCurrentContext.Kseaf)
 	} else {
-		ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_FAILURE
+		ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_FAILURE)
 		responseBody.AuthResult = models.AUTHRESULT_AUTHENTICATION_FAILURE
-		logConfirmFailureAndInformUDM(ConfirmationDataResponseID, models.AUTHTYPE__5_G_AKA, servingNetworkName,
-			"5G AKA confirmation failed", ausfCurrentContext.UdmUeauUrl)
+		logConfirmFailureAndInformUDM(log, ConfirmationDataResponseID, models.AUTHTYPE__5_G_AKA, servingNetworkName,
+			"5G AKA confirmation failed")
+		recordConfirmation(ctx, decision, confirmCauseResMismatch)
 	}
 
-	if sendErr := sendAuthResultToUDM(currentSupi, models.AUTHTYPE__5_G_AKA, success, servingNetworkName,
-		ausfCurrentContext.UdmUeauUrl); sendErr != nil {
-		logger.Auth5gAkaComfirmLog.Infoln(sendErr.Error())
-		return nil, utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, "", UPSTREAM_SERVER_ERROR)
+	if success {
+		if sendErr := informUDMOfAuthResult(ctx, currentSupi, models.AUTHTYPE__5_G_AKA, true, servingNetworkName); sendErr != nil {
+			log.Infoln(sendErr.Error())
+			recordConfirmation(ctx, decision, UPSTREAM_SERVER_ERROR)
+			return nil, utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, "", UPSTREAM_SERVER_ERROR)
+		}
+		recordConfirmation(ctx, decision, "")
 	}
 
 	responseBody.SetSupi(currentSupi)
@@ -477,47 +556,56 @@ func Auth5gAkaComfirmRequestProcedure(updateConfirmationData models.Confirmation
 }
 
 // return response, problemDetails
-func EapAuthComfirmRequestProcedure(updateEapSession models.EapSession, eapSessionID string) (*models.EapSession,
+func EapAuthComfirmRequestProcedure(ctx context.Context, updateEapSession models.EapSession, eapSessionID string) (*models.EapSession,
 	*models.ProblemDetails,
 ) {
+	log := logger.FromContext(ctx, logger.EapAuthComfirmLog).With("authType", string(models.AUTHTYPE_EAP_AKA_PRIME))
+	decision := audit.Event{AuthCtxID: eapSessionID, AuthType: string(models.AUTHTYPE_EAP_AKA_PRIME)}
 	responseBody := models.NewEapSessionWithDefaults()
 
 	if !ausf_context.CheckIfSuciSupiPairExists(eapSessionID) {
-		logger.EapAuthComfirmLog.Infoln("supiSuciPair does not exist, confirmation failed")
+		log.Infoln("supiSuciPair does not exist, confirmation failed")
+		recordConfirmation(ctx, decision, confirmCauseContextNotFound)
 		return nil, utils.ProblemDetailsUserNotFound()
 	}
 
 	currentSupi := ausf_context.GetSupiFromSuciSupiMap(eapSessionID)
+	decision.Supi = currentSupi
+	log = log.With("supi", ausf_context.MaskSupi(currentSupi))
 	if !ausf_context.CheckIfAusfUeContextExists(currentSupi) {
-		logger.EapAuthComfirmLog.Infoln("SUPI does not exist, confirmation failed")
+		log.Infoln("SUPI does not exist, confirmation failed")
+		recordConfirmation(ctx, decision, confirmCauseContextNotFound)
 		return nil, utils.ProblemDetailsUserNotFound()
 	}
 
 	ausfCurrentContext := ausf_context.GetAusfUeContext(currentSupi)
 	servingNetworkName := ausfCurrentContext.ServingNetworkName
+	decision.ServingNetworkName = servingNetworkName
 	var eapPayload []byte
 	if eapPayloadTmp, err := base64.StdEncoding.DecodeString(updateEapSession.GetEapPayload()); err != nil {
-		logger.EapAuthComfirmLog.Warnf("EAP payload decode failed: %+v", err)
+		log.Warnf("EAP payload decode failed: %+v", err)
 	} else {
 		eapPayload = eapPayloadTmp
 	}
 
 	eapContent, err := parseEAPPacket(eapPayload)
 	if err != nil {
-		logger.EapAuthComfirmLog.Warnf("EAP packet parsing failed: %+v", err)
+		log.Warnf("EAP packet parsing failed: %+v", err)
+		recordConfirmation(ctx, decision, confirmCauseEapParseError)
 		return nil, utils.ProblemDetailsWithCause("EAP packet parse error", http.StatusBadRequest, "", "EAP_PACKET_PARSE_ERROR")
 	}
 
 	if eapContent.Code != EAPCodeResponse {
-		logConfirmFailureAndInformUDM(eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
-			"eap packet code error", ausfCurrentContext.UdmUeauUrl)
-		ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_FAILURE
+		logConfirmFailureAndInformUDM(log, eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
+			"eap packet code error")
+		recordConfirmation(ctx, decision, confirmCauseEapCodeError)
+		ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_FAILURE)
 		responseBody.SetAuthResult(models.AUTHRESULT_AUTHENTICATION_ONGOING)
 		failEapAkaNoti := ConstructFailEapAkaNotification(eapContent.Identifier)
 		responseBody.SetEapPayload(failEapAkaNoti)
 		return responseBody, nil
 	}
-	switch ausfCurrentContext.AuthStatus {
+	switch ausfCurrentContext.GetAuthStatus() {
 	case models.AUTHRESULT_AUTHENTICATION_ONGOING:
 		responseBody.SetKSeaf(ausfCurrentContext.Kseaf)
 		responseBody.SetSupi(currentSupi)
@@ -525,29 +613,31 @@ func EapAuthComfirmRequestProcedure(updateEapSession models.EapSession, eapSessi
 		XRES := ausfCurrentContext.XRES
 		RES, decodeOK := decodeResMac(eapContent.TypeData, eapContent.Contents, Kautn)
 		if !decodeOK {
-			ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_FAILURE
+			ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_FAILURE)
 			responseBody.SetAuthResult(models.AUTHRESULT_AUTHENTICATION_ONGOING)
-			logConfirmFailureAndInformUDM(eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
-				"eap packet decode error", ausfCurrentContext.UdmUeauUrl)
+			logConfirmFailureAndInformUDM(log, eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
+				"eap packet decode error")
+			recordConfirmation(ctx, decision, confirmCauseEapDecodeError)
 			failEapAkaNoti := ConstructFailEapAkaNotification(eapContent.Identifier)
 			responseBody.SetEapPayload(failEapAkaNoti)
 		} else if XRES == string(RES) { // decodeOK && XRES == res, auth success
-			logger.EapAuthComfirmLog.Infoln("correct RES value, EAP-AKA' auth succeed")
+			log.Infoln("correct RES value, EAP-AKA' auth succeed")
 			responseBody.SetAuthResult(models.AUTHRESULT_AUTHENTICATION_SUCCESS)
 			eapSuccPkt := ConstructEapNoTypePkt(radius.EapCodeSuccess, eapContent.Identifier)
 			responseBody.SetEapPayload(eapSuccPkt)
-			udmUrl := ausfCurrentContext.UdmUeauUrl
-			if sendErr := sendAuthResultToUDM(eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, true, servingNetworkName,
-				udmUrl); sendErr != nil {
-				logger.EapAuthComfirmLog.Infoln(sendErr.Error())
+			if sendErr := informUDMOfAuthResult(ctx, eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, true, servingNetworkName); sendErr != nil {
+				log.Infoln(sendErr.Error())
+				recordConfirmation(ctx, decision, UPSTREAM_SERVER_ERROR)
 				return nil, utils.ProblemDetailsWithCause("Upstream server error", http.StatusInternalServerError, "", UPSTREAM_SERVER_ERROR)
 			}
-			ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_SUCCESS
+			ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_SUCCESS)
+			recordConfirmation(ctx, decision, "")
 		} else {
-			ausfCurrentContext.AuthStatus = models.AUTHRESULT_AUTHENTICATION_FAILURE
+			ausfCurrentContext.SetAuthStatus(models.AUTHRESULT_AUTHENTICATION_FAILURE)
 			responseBody.SetAuthResult(models.AUTHRESULT_AUTHENTICATION_ONGOING)
-			logConfirmFailureAndInformUDM(eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
-				"Wrong RES value, EAP-AKA' auth failed", ausfCurrentContext.UdmUeauUrl)
+			logConfirmFailureAndInformUDM(log, eapSessionID, models.AUTHTYPE_EAP_AKA_PRIME, servingNetworkName,
+				"Wrong RES value, EAP-AKA' auth failed")
+			recordConfirmation(ctx, decision, confirmCauseResMismatch)
 			failEapAkaNoti := ConstructFailEapAkaNotification(eapContent.Identifier)
 			responseBody.SetEapPayload(failEapAkaNoti)
 		}
@@ -556,6 +646,7 @@ func EapAuthComfirmRequestProcedure(updateEapSession models.EapSession, eapSessi
 		eapFailPkt := ConstructEapNoTypePkt(radius.EapCodeFailure, eapPayload[1])
 		responseBody.SetEapPayload(eapFailPkt)
 		responseBody.SetAuthResult(models.AUTHRESULT_AUTHENTICATION_FAILURE)
+		recordConfirmation(ctx, decision, confirmCauseAlreadyFailed)
 	}
 
 	return responseBody, nil
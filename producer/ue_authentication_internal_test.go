@@ -4,15 +4,31 @@
 package producer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/omec-project/ausf/audit"
 	ausf_context "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/load"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/outbox"
+	"github.com/omec-project/openapi/v2"
 	"github.com/omec-project/openapi/v2/Nudm_UEAU"
 	"github.com/omec-project/openapi/v2/models"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 const testUdmUrl = "https://udm.example"
@@ -32,6 +48,13 @@ func initProducerTestContext(t *testing.T) {
 	})
 }
 
+func useUdmForTest(t *testing.T) {
+	t.Helper()
+	originalResolveUdmURL := resolveUdmURL
+	resolveUdmURL = func(context.Context, string) string { return testUdmUrl }
+	t.Cleanup(func() { resolveUdmURL = originalResolveUdmURL })
+}
+
 func TestUeAuthPostRequestProcedure_UnsupportedAuthTypeDoesNotPersistContext(t *testing.T) {
 	initProducerTestContext(t)
 	originalResolveUdmURL := resolveUdmURL
@@ -43,14 +66,14 @@ func TestUeAuthPostRequestProcedure_UnsupportedAuthTypeDoesNotPersistContext(t *
 
 	supiOrSuci := "imsi-001010000000001"
 	resolvedSupi := "imsi-001010000000002"
-	resolveUdmURL = func(string) string { return testUdmUrl }
-	executeGenerateAuthData = func(_ *Nudm_UEAU.APIClient, _ string, _ models.AuthenticationInfoRequest) (*models.AuthenticationInfoResult, *http.Response, error) {
+	resolveUdmURL = func(context.Context, string) string { return testUdmUrl }
+	executeGenerateAuthData = func(_ context.Context, _ *Nudm_UEAU.APIClient, _ string, _ models.AuthenticationInfoRequest) (*models.AuthenticationInfoResult, *http.Response, error) {
 		result := models.NewAuthenticationInfoResult(models.AuthType("UNSUPPORTED"))
 		result.SetSupi(resolvedSupi)
 		return result, nil, nil
 	}
 
-	response, _, problemDetails := UeAuthPostRequestProcedure(models.AuthenticationInfo{
+	response, _, problemDetails := UeAuthPostRequestProcedure(context.Background(), models.AuthenticationInfo{
 		ServingNetworkName: "5G:mnc001.mcc001.3gppnetwork.org",
 		SupiOrSuci:         supiOrSuci,
 	})
@@ -80,8 +103,8 @@ func TestUeAuthPostRequestProcedure_InvalidKausfDoesNotPersistContext(t *testing
 
 	supiOrSuci := "imsi-001010000000003"
 	resolvedSupi := "imsi-001010000000004"
-	resolveUdmURL = func(string) string { return testUdmUrl }
-	executeGenerateAuthData = func(_ *Nudm_UEAU.APIClient, _ string, _ models.AuthenticationInfoRequest) (*models.AuthenticationInfoResult, *http.Response, error) {
+	resolveUdmURL = func(context.Context, string) string { return testUdmUrl }
+	executeGenerateAuthData = func(_ context.Context, _ *Nudm_UEAU.APIClient, _ string, _ models.AuthenticationInfoRequest) (*models.AuthenticationInfoResult, *http.Response, error) {
 		result := models.NewAuthenticationInfoResult(models.AUTHTYPE__5_G_AKA)
 		result.SetSupi(resolvedSupi)
 		vector := models.Av5GHeAkaAsAuthenticationVector(models.NewAv5GHeAka(
@@ -95,7 +118,7 @@ func TestUeAuthPostRequestProcedure_InvalidKausfDoesNotPersistContext(t *testing
 		return result, nil, nil
 	}
 
-	response, _, problemDetails := UeAuthPostRequestProcedure(models.AuthenticationInfo{
+	response, _, problemDetails := UeAuthPostRequestProcedure(context.Background(), models.AuthenticationInfo{
 		ServingNetworkName: "5G:mnc001.mcc001.3gppnetwork.org",
 		SupiOrSuci:         supiOrSuci,
 	})
@@ -116,6 +139,7 @@ func TestUeAuthPostRequestProcedure_InvalidKausfDoesNotPersistContext(t *testing
 
 func TestDeleteAuthenticationResultProcedureRemovesLocalState(t *testing.T) {
 	initProducerTestContext(t)
+	useUdmForTest(t)
 	originalExecuteDeleteAuth := executeDeleteAuth
 	defer func() {
 		executeDeleteAuth = originalExecuteDeleteAuth
@@ -133,7 +157,7 @@ func TestDeleteAuthenticationResultProcedureRemovesLocalState(t *testing.T) {
 	defer ausf_context.RemoveAusfUeContextFromPool(supi)
 
 	called := false
-	executeDeleteAuth = func(_ *Nudm_UEAU.APIClient, gotSupi, gotAuthEventID string, _ models.AuthEvent) (*http.Response, error) {
+	executeDeleteAuth = func(_ context.Context, _ *Nudm_UEAU.APIClient, gotSupi, gotAuthEventID string, _ models.AuthEvent) (*http.Response, error) {
 		called = true
 		if gotSupi != supi || gotAuthEventID != authCtxID {
 			t.Fatalf("unexpected delete auth inputs: supi=%s authEventID=%s", gotSupi, gotAuthEventID)
@@ -141,7 +165,7 @@ func TestDeleteAuthenticationResultProcedureRemovesLocalState(t *testing.T) {
 		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
 	}
 
-	problemDetails := DeleteAuthenticationResultProcedure(authCtxID, models.AUTHTYPE__5_G_AKA)
+	problemDetails := DeleteAuthenticationResultProcedure(context.Background(), authCtxID, models.AUTHTYPE__5_G_AKA)
 	if problemDetails != nil {
 		t.Fatalf("expected no problem details, got %+v", problemDetails)
 	}
@@ -158,6 +182,7 @@ func TestDeleteAuthenticationResultProcedureRemovesLocalState(t *testing.T) {
 
 func TestDeregisterAuthContextProcedureRemovesAllMatchingAuthContexts(t *testing.T) {
 	initProducerTestContext(t)
+	useUdmForTest(t)
 	originalExecuteDeleteAuth := executeDeleteAuth
 	defer func() {
 		executeDeleteAuth = originalExecuteDeleteAuth
@@ -178,7 +203,7 @@ func TestDeregisterAuthContextProcedureRemovesAllMatchingAuthContexts(t *testing
 	defer ausf_context.RemoveAusfUeContextFromPool(supi)
 
 	deletedAuthCtxIDs := make(map[string]struct{})
-	executeDeleteAuth = func(_ *Nudm_UEAU.APIClient, gotSupi, gotAuthEventID string, _ models.AuthEvent) (*http.Response, error) {
+	executeDeleteAuth = func(_ context.Context, _ *Nudm_UEAU.APIClient, gotSupi, gotAuthEventID string, _ models.AuthEvent) (*http.Response, error) {
 		if gotSupi != supi {
 			t.Fatalf("unexpected supi %s", gotSupi)
 		}
@@ -186,7 +211,7 @@ func TestDeregisterAuthContextProcedureRemovesAllMatchingAuthContexts(t *testing
 		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
 	}
 
-	problemDetails := DeregisterAuthContextProcedure(*models.NewDeregistrationInfo(supi))
+	problemDetails := DeregisterAuthContextProcedure(context.Background(), *models.NewDeregistrationInfo(supi))
 	if problemDetails != nil {
 		t.Fatalf("expected no problem details, got %+v", problemDetails)
 	}
@@ -227,3 +252,417 @@ func TestInvalidateUdmCache_ClearsBothCaches(t *testing.T) {
 		t.Errorf("cachedUdmClientURL not cleared: got %q", cachedUdmClientURL)
 	}
 }
+
+func TestAuth5gAkaComfirmRequestProcedure_AsyncDeliveryDoesNotFailOnUdmError(t *testing.T) {
+	initProducerTestContext(t)
+	useUdmForTest(t)
+	originalExecuteConfirmAuth := executeConfirmAuth
+	originalOutbox := authEventOutbox
+	defer func() {
+		executeConfirmAuth = originalExecuteConfirmAuth
+		authEventOutbox = originalOutbox
+		asyncAuthEventDelivery.Store(false)
+	}()
+	if err := InitAuthEventDelivery(&factory.AuthEventDelivery{Mode: factory.AUTH_EVENT_DELIVERY_ASYNC}); err != nil {
+		t.Fatalf("InitAuthEventDelivery: %v", err)
+	}
+
+	authCtxID := "suci-async-confirm"
+	supi := "imsi-001010000000020"
+	ausf_context.AddSuciSupiPairToMap(authCtxID, supi)
+	defer ausf_context.RemoveSuciSupiPairFromMap(authCtxID)
+	ausf_context.AddAusfUeContextToPool(&ausf_context.AusfUeContext{
+		Supi:               supi,
+		ServingNetworkName: "5G:mnc001.mcc001.3gppnetwork.org",
+		UdmUeauUrl:         testUdmUrl,
+		XresStar:           "xres-star",
+		AuthStatus:         models.AUTHRESULT_AUTHENTICATION_ONGOING,
+	})
+	defer ausf_context.RemoveAusfUeContextFromPool(supi)
+
+	executeConfirmAuth = func(_ context.Context, _ *Nudm_UEAU.APIClient, _ string, _ models.AuthEvent) (*http.Response, error) {
+		t.Fatal("ConfirmAuth must not be sent synchronously in async mode")
+		return nil, nil
+	}
+
+	confirmationData := models.NewConfirmationDataWithDefaults()
+	confirmationData.SetResStar("xres-star")
+	response, problemDetails := Auth5gAkaComfirmRequestProcedure(context.Background(), *confirmationData, authCtxID)
+	if problemDetails != nil {
+		t.Fatalf("expected no problem details, got %+v", problemDetails)
+	}
+	if response == nil || response.AuthResult != models.AUTHRESULT_AUTHENTICATION_SUCCESS {
+		t.Fatalf("expected successful confirmation, got %+v", response)
+	}
+	pending := PendingAuthEvents()
+	if len(pending) != 1 || pending[0].Supi != supi || !pending[0].Success {
+		t.Fatalf("expected one queued successful ConfirmAuth event for %s, got %+v", supi, pending)
+	}
+}
+
+func TestAuth5gAkaComfirmRequestProcedure_RacesWithTheLoadAndMetricsReporting(t *testing.T) {
+	initProducerTestContext(t)
+	useUdmForTest(t)
+	originalExecuteConfirmAuth := executeConfirmAuth
+	defer func() { executeConfirmAuth = originalExecuteConfirmAuth }()
+	executeConfirmAuth = func(_ context.Context, _ *Nudm_UEAU.APIClient, _ string, _ models.AuthEvent) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody}, nil
+	}
+
+	const authentications = 20
+	authCtxIDs := make([]string, authentications)
+	for i := range authCtxIDs {
+		supi := fmt.Sprintf("imsi-0010100000001%02d", i)
+		authCtxIDs[i] = "suci-race-" + supi
+		ueContext := ausf_context.NewAusfUeContext(supi)
+		ueContext.ServingNetworkName = "5G:mnc001.mcc001.3gppnetwork.org"
+		ueContext.XresStar = "xres-star"
+		ueContext.StartAuthentication()
+		ausf_context.AddSuciSupiPairToMap(authCtxIDs[i], supi)
+		ausf_context.AddAusfUeContextToPool(ueContext)
+		defer ausf_context.RemoveSuciSupiPairFromMap(authCtxIDs[i])
+		defer ausf_context.RemoveAusfUeContextFromPool(supi)
+	}
+
+	done := make(chan struct{})
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				load.Current()
+				ausf_context.PendingAuthContextCount()
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for _, authCtxID := range authCtxIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			confirmationData := models.NewConfirmationDataWithDefaults()
+			confirmationData.SetResStar("xres-star")
+			if _, problemDetails := Auth5gAkaComfirmRequestProcedure(context.Background(), *confirmationData, authCtxID); problemDetails != nil {
+				t.Errorf("unexpected problem details %+v", problemDetails)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-reported
+
+	if count := ausf_context.OngoingAuthContextCount(time.Hour); count != 0 {
+		t.Errorf("expected every authentication to be confirmed, got %d ongoing", count)
+	}
+}
+
+func TestDeliverAuthEvent_GivesUpOnAHungUdm(t *testing.T) {
+	initProducerTestContext(t)
+	useUdmForTest(t)
+	originalExecuteConfirmAuth := executeConfirmAuth
+	originalAuthEventTimeout := authEventTimeout
+	defer func() {
+		executeConfirmAuth = originalExecuteConfirmAuth
+		authEventTimeout = originalAuthEventTimeout
+	}()
+	authEventTimeout = 20 * time.Millisecond
+	executeConfirmAuth = func(ctx context.Context, _ *Nudm_UEAU.APIClient, _ string, _ models.AuthEvent) (*http.Response, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- deliverAuthEvent(outbox.Event{Kind: outbox.KindConfirmAuth, Supi: "imsi-001010000000040", AuthType: models.AUTHTYPE__5_G_AKA})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the attempt to time out, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the delivery attempt to be bounded by authEventTimeout")
+	}
+}
+
+func TestAuth5gAkaComfirmRequestProcedure_LogsCarryRequestFields(t *testing.T) {
+	initProducerTestContext(t)
+	core, logs := observer.New(zapcore.InfoLevel)
+	originalLog := logger.Auth5gAkaComfirmLog
+	logger.Auth5gAkaComfirmLog = zap.New(core).Sugar()
+	defer func() { logger.Auth5gAkaComfirmLog = originalLog }()
+
+	authCtxID := "suci-0-001-01-0000-0-0-0000000021"
+	supi := "imsi-001010000000021"
+	ausf_context.AddSuciSupiPairToMap(authCtxID, supi)
+	defer ausf_context.RemoveSuciSupiPairFromMap(authCtxID)
+	ausf_context.AddAusfUeContextToPool(&ausf_context.AusfUeContext{
+		Supi:       supi,
+		XresStar:   "xres-star",
+		AuthStatus: models.AUTHRESULT_AUTHENTICATION_ONGOING,
+	})
+	defer ausf_context.RemoveAusfUeContextFromPool(supi)
+
+	confirmationData := models.NewConfirmationDataWithDefaults()
+	confirmationData.SetResStar("wrong-res-star")
+	ctx := logger.WithFields(context.Background(), "requestId", "req-21", "authCtxId", ausf_context.MaskSupi(authCtxID))
+	Auth5gAkaComfirmRequestProcedure(ctx, *confirmationData, authCtxID)
+
+	failures := logs.FilterMessage("5G AKA confirmation failed").AllUntimed()
+	if len(failures) != 1 {
+		t.Fatalf("expected one confirmation failure log, got %d", len(failures))
+	}
+	fields := failures[0].ContextMap()
+	expected := map[string]string{
+		"requestId": "req-21",
+		"authCtxId": "suci-0-001-01-0000-0-0-********21",
+		"supi":      "imsi-00101********21",
+		"authType":  string(models.AUTHTYPE__5_G_AKA),
+	}
+	for key, value := range expected {
+		if got, ok := fields[key]; !ok || got != value {
+			t.Errorf("expected %s=%q, got %v", key, value, got)
+		}
+	}
+	for _, entry := range logs.AllUntimed() {
+		if strings.Contains(entry.Message, supi) || strings.Contains(entry.Message, authCtxID) {
+			t.Errorf("log message exposes the subscriber identity: %q", entry.Message)
+		}
+	}
+}
+
+func TestAuthDecisionsAreAudited(t *testing.T) {
+	initProducerTestContext(t)
+	useUdmForTest(t)
+	originalExecuteDeleteAuth := executeDeleteAuth
+	defer func() {
+		executeDeleteAuth = originalExecuteDeleteAuth
+	}()
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	keyFile := filepath.Join(t.TempDir(), "audit.key")
+	if err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0o600); err != nil {
+		t.Fatalf("failed to write the audit key: %v", err)
+	}
+	if err := audit.Init(&factory.Audit{Path: auditPath, KeyFile: keyFile}); err != nil {
+		t.Fatalf("audit.Init: %v", err)
+	}
+	defer func() {
+		if err := audit.Close(); err != nil {
+			t.Errorf("audit.Close: %v", err)
+		}
+	}()
+
+	authCtxID := "suci-0-001-01-0000-0-0-0000000022"
+	supi := "imsi-001010000000022"
+	servingNetworkName := "5G:mnc001.mcc001.3gppnetwork.org"
+	ausf_context.AddSuciSupiPairToMap(authCtxID, supi)
+	defer ausf_context.RemoveSuciSupiPairFromMap(authCtxID)
+	ausf_context.AddAusfUeContextToPool(&ausf_context.AusfUeContext{
+		Supi:               supi,
+		ServingNetworkName: servingNetworkName,
+		UdmUeauUrl:         testUdmUrl,
+		XresStar:           "xres-star",
+		AuthStatus:         models.AUTHRESULT_AUTHENTICATION_ONGOING,
+	})
+	defer ausf_context.RemoveAusfUeContextFromPool(supi)
+	executeDeleteAuth = func(_ context.Context, _ *Nudm_UEAU.APIClient, _, _ string, _ models.AuthEvent) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+	}
+
+	confirmationData := models.NewConfirmationDataWithDefaults()
+	confirmationData.SetResStar("wrong-res-star")
+	ctx := logger.WithFields(context.Background(), "requestId", "req-22")
+	Auth5gAkaComfirmRequestProcedure(ctx, *confirmationData, "suci-unknown")
+	Auth5gAkaComfirmRequestProcedure(ctx, *confirmationData, authCtxID)
+	if problemDetails := DeleteAuthenticationResultProcedure(ctx, authCtxID, models.AUTHTYPE__5_G_AKA); problemDetails != nil {
+		t.Fatalf("expected no problem details, got %+v", problemDetails)
+	}
+
+	content, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("failed to read the audit log: %v", err)
+	}
+	var records []audit.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var record audit.Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid audit record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	expected := []audit.Event{
+		{Action: audit.ActionConfirm, Result: audit.ResultFailure, Cause: confirmCauseContextNotFound,
+			AuthCtxID: "suci-unknown", AuthType: string(models.AUTHTYPE__5_G_AKA), RequestID: "req-22"},
+		{Action: audit.ActionConfirm, Result: audit.ResultFailure, Cause: confirmCauseResMismatch, Supi: supi,
+			AuthCtxID: authCtxID, ServingNetworkName: servingNetworkName, AuthType: string(models.AUTHTYPE__5_G_AKA), RequestID: "req-22"},
+		{Action: audit.ActionDeleteAuthResult, Result: audit.ResultSuccess, Supi: supi,
+			AuthCtxID: authCtxID, ServingNetworkName: servingNetworkName, AuthType: string(models.AUTHTYPE__5_G_AKA), RequestID: "req-22"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d audit records, got %d: %s", len(expected), len(records), content)
+	}
+	for i, record := range records {
+		if record.Event != expected[i] {
+			t.Errorf("record %d: expected %+v, got %+v", i+1, expected[i], record.Event)
+		}
+	}
+	key, err := audit.LoadKey(keyFile)
+	if err != nil {
+		t.Fatalf("audit.LoadKey: %v", err)
+	}
+	verifier := &audit.Verifier{Key: key}
+	if err := verifier.Verify(auditPath, bytes.NewReader(content)); err != nil {
+		t.Errorf("expected an intact audit chain: %v", err)
+	}
+}
+
+func TestBuildUdmUeauUrl(t *testing.T) {
+	testCases := []struct {
+		name     string
+		endPoint models.IpEndPoint
+		fqdn     string
+		want     string
+	}{
+		{
+			name:     "IPv4 endpoint",
+			endPoint: models.IpEndPoint{Ipv4Address: openapi.PtrString("10.0.13.1"), Port: openapi.PtrInt32(8090)},
+			want:     "https://10.0.13.1:8090",
+		},
+		{
+			name:     "IPv6 endpoint",
+			endPoint: models.IpEndPoint{Ipv6Address: openapi.PtrString("2001:db8::1"), Port: openapi.PtrInt32(8090)},
+			want:     "https://[2001:db8::1]:8090",
+		},
+		{
+			name: "IPv4 preferred over IPv6",
+			endPoint: models.IpEndPoint{
+				Ipv4Address: openapi.PtrString("10.0.13.1"),
+				Ipv6Address: openapi.PtrString("2001:db8::1"),
+				Port:        openapi.PtrInt32(8090),
+			},
+			want: "https://10.0.13.1:8090",
+		},
+		{
+			name:     "FQDN when endpoint has no address",
+			endPoint: models.IpEndPoint{Port: openapi.PtrInt32(8090)},
+			fqdn:     "udm.core.svc.cluster.local",
+			want:     "https://udm.core.svc.cluster.local:8090",
+		},
+		{
+			name:     "no address and no FQDN",
+			endPoint: models.IpEndPoint{Port: openapi.PtrInt32(8090)},
+			want:     "",
+		},
+		{
+			name:     "missing port",
+			endPoint: models.IpEndPoint{Ipv6Address: openapi.PtrString("2001:db8::1")},
+			want:     "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := buildUdmUeauUrl(models.URISCHEME_HTTPS, tc.endPoint, tc.fqdn); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectUdmUeauUrl(t *testing.T) {
+	endPoint := models.IpEndPoint{Ipv4Address: openapi.PtrString("10.0.13.1"), Port: openapi.PtrInt32(8090)}
+	testCases := []struct {
+		name           string
+		profileFqdn    string
+		service        models.NFService
+		wantUrl        string
+		wantServerName string
+	}{
+		{
+			name: "service FQDN preferred over IP endpoint",
+			service: models.NFService{
+				ServiceName: models.SERVICENAME_NUDM_UEAU,
+				Scheme:      models.URISCHEME_HTTPS,
+				Fqdn:        openapi.PtrString("udm.core.svc.cluster.local"),
+				IpEndPoints: []models.IpEndPoint{endPoint},
+			},
+			wantUrl: "https://udm.core.svc.cluster.local:8090",
+		},
+		{
+			name: "service FQDN without endpoints",
+			service: models.NFService{
+				ServiceName: models.SERVICENAME_NUDM_UEAU,
+				Scheme:      models.URISCHEME_HTTPS,
+				Fqdn:        openapi.PtrString("udm.core.svc.cluster.local"),
+			},
+			wantUrl: "https://udm.core.svc.cluster.local",
+		},
+		{
+			name:        "IP endpoint with profile FQDN sets SNI",
+			profileFqdn: "udm.example.org",
+			service: models.NFService{
+				ServiceName: models.SERVICENAME_NUDM_UEAU,
+				Scheme:      models.URISCHEME_HTTPS,
+				IpEndPoints: []models.IpEndPoint{endPoint},
+			},
+			wantUrl:        "https://10.0.13.1:8090",
+			wantServerName: "udm.example.org",
+		},
+		{
+			name: "apiPrefix with IP host and service FQDN sets SNI",
+			service: models.NFService{
+				ServiceName: models.SERVICENAME_NUDM_UEAU,
+				Scheme:      models.URISCHEME_HTTPS,
+				ApiPrefix:   openapi.PtrString("https://10.0.13.1:8090"),
+				Fqdn:        openapi.PtrString("udm.core.svc.cluster.local"),
+			},
+			wantUrl:        "https://10.0.13.1:8090",
+			wantServerName: "udm.core.svc.cluster.local",
+		},
+		{
+			name: "other services are ignored",
+			service: models.NFService{
+				ServiceName: models.SERVICENAME_NUDM_SDM,
+				Scheme:      models.URISCHEME_HTTPS,
+				IpEndPoints: []models.IpEndPoint{endPoint},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := models.NewNFProfileDiscovery("udm-instance", models.NFTYPE_UDM, models.NFSTATUS_REGISTERED)
+			if tc.profileFqdn != "" {
+				profile.SetFqdn(tc.profileFqdn)
+			}
+			profile.SetNfServices([]models.NFService{tc.service})
+			gotUrl, gotServerName := selectUdmUeauUrl(*profile)
+			if gotUrl != tc.wantUrl {
+				t.Errorf("expected URL %q, got %q", tc.wantUrl, gotUrl)
+			}
+			if gotServerName != tc.wantServerName {
+				t.Errorf("expected server name %q, got %q", tc.wantServerName, gotServerName)
+			}
+		})
+	}
+}
+
+func TestUdmServerName_KeptForTheCachedUrlOnly(t *testing.T) {
+	t.Cleanup(invalidateUdmCache)
+	cacheUdm("https://10.0.13.1:8090", "udm.example.org", nil)
+	if got := udmServerNameOf("https://10.0.13.1:8090"); got != "udm.example.org" {
+		t.Errorf("expected the server name of the cached URL, got %q", got)
+	}
+
+	cacheUdm("https://10.0.13.2:8090", "", nil)
+	if got := udmServerNameOf("https://10.0.13.1:8090"); got != "" {
+		t.Errorf("expected the server name of the previous URL to be forgotten, got %q", got)
+	}
+
+	cacheUdm("https://10.0.13.1:8090", "udm.example.org", nil)
+	invalidateUdmCache()
+	if got := udmServerNameOf("https://10.0.13.1:8090"); got != "" {
+		t.Errorf("expected the server name to be invalidated with the URL, got %q", got)
+	}
+}
@@ -5,6 +5,7 @@
 package producer_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -24,7 +25,7 @@ func TestGenerateRandomNumber(t *testing.T) {
 }
 
 func TestAuth5gAkaComfirmRequestProcedureReturnsNotFoundForMissingAuthContext(t *testing.T) {
-	response, problemDetails := producer.Auth5gAkaComfirmRequestProcedure(models.ConfirmationData{}, fmt.Sprintf("missing-%s", t.Name()))
+	response, problemDetails := producer.Auth5gAkaComfirmRequestProcedure(context.Background(), models.ConfirmationData{}, fmt.Sprintf("missing-%s", t.Name()))
 	if response != nil {
 		t.Fatalf("expected nil response, got %+v", response)
 	}
@@ -42,7 +43,7 @@ func TestAuth5gAkaComfirmRequestProcedureReturnsNotFoundForMissingUeContext(t *t
 	ausf_context.AddSuciSupiPairToMap(confirmationID, supi)
 	defer ausf_context.RemoveSuciSupiPairFromMap(confirmationID)
 
-	response, problemDetails := producer.Auth5gAkaComfirmRequestProcedure(models.ConfirmationData{}, confirmationID)
+	response, problemDetails := producer.Auth5gAkaComfirmRequestProcedure(context.Background(), models.ConfirmationData{}, confirmationID)
 	if response != nil {
 		t.Fatalf("expected nil response, got %+v", response)
 	}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/metrics"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerSettings configures every circuit breaker created by a BreakerSet
+type BreakerSettings struct {
+	Disabled         bool
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// DefaultBreakerSettings is used when the configuration does not provide a circuitBreaker section
+var DefaultBreakerSettings = BreakerSettings{
+	FailureThreshold: defaultFailureThreshold,
+	OpenTimeout:      defaultOpenTimeout,
+}
+
+// CircuitBreaker stops sending requests to a target after consecutive failures.
+// Once OpenTimeout has elapsed a single probe request is let through (half-open);
+// its outcome decides whether the breaker closes again or stays open.
+type CircuitBreaker struct {
+	mu            sync.Mutex
+	target        string
+	settings      BreakerSettings
+	state         State
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+	now           func() time.Time
+}
+
+func newCircuitBreaker(target string, settings BreakerSettings) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		target:   target,
+		settings: settings,
+		state:    StateClosed,
+		now:      time.Now,
+	}
+	metrics.SetCircuitBreakerState(target, float64(StateClosed))
+	return cb
+}
+
+// Allow reports whether a request may be sent to the target
+func (cb *CircuitBreaker) Allow() bool {
+	if cb.settings.Disabled {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case StateOpen:
+		if cb.now().Sub(cb.openedAt) < cb.settings.OpenTimeout {
+			return false
+		}
+		cb.setState(StateHalfOpen)
+		cb.probeInFlight = true
+		return true
+	case StateHalfOpen:
+		if cb.probeInFlight {
+			return false
+		}
+		cb.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// OnSuccess records a successful exchange with the target
+func (cb *CircuitBreaker) OnSuccess() {
+	if cb.settings.Disabled {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.probeInFlight = false
+	if cb.state != StateClosed {
+		cb.setState(StateClosed)
+	}
+}
+
+// OnFailure records a failed exchange with the target
+func (cb *CircuitBreaker) OnFailure() {
+	if cb.settings.Disabled {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probeInFlight = false
+	cb.failures++
+	if cb.state == StateHalfOpen || cb.failures >= cb.settings.FailureThreshold {
+		cb.openedAt = cb.now()
+		if cb.state != StateOpen {
+			cb.setState(StateOpen)
+		}
+	}
+}
+
+// State returns the current state of the breaker
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// setState must be called with cb.mu held
+func (cb *CircuitBreaker) setState(state State) {
+	logger.ConsumerLog.Infof("circuit breaker for %s changed from %s to %s", cb.target, cb.state, state)
+	cb.state = state
+	metrics.SetCircuitBreakerState(cb.target, float64(state))
+}
+
+// BreakerSet holds one circuit breaker per target
+type BreakerSet struct {
+	mu       sync.Mutex
+	settings BreakerSettings
+	breakers map[string]*CircuitBreaker
+}
+
+func NewBreakerSet(settings BreakerSettings) *BreakerSet {
+	return &BreakerSet{
+		settings: settings,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the breaker of a target, creating it on first use
+func (s *BreakerSet) Get(target string) *CircuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cb, ok := s.breakers[target]
+	if !ok {
+		cb = newCircuitBreaker(target, s.settings)
+		s.breakers[target] = cb
+	}
+	return cb
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package resilience
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 2 * time.Second
+	defaultMultiplier     = 2.0
+)
+
+// RetryPolicy describes how many times an idempotent request is attempted and
+// how long to wait between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// DefaultRetryPolicy is used when the configuration does not provide a retry section
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    defaultMaxAttempts,
+	InitialBackoff: defaultInitialBackoff,
+	MaxBackoff:     defaultMaxBackoff,
+	Multiplier:     defaultMultiplier,
+}
+
+// Backoff produces exponentially growing waiting times, capped at a maximum
+type Backoff struct {
+	initial    time.Duration
+	maximum    time.Duration
+	multiplier float64
+	next       time.Duration
+}
+
+func NewBackoff(initial, maximum time.Duration, multiplier float64) *Backoff {
+	if multiplier < 1 {
+		multiplier = defaultMultiplier
+	}
+	if maximum < initial {
+		maximum = initial
+	}
+	return &Backoff{
+		initial:    initial,
+		maximum:    maximum,
+		multiplier: multiplier,
+		next:       initial,
+	}
+}
+
+// Next returns the current waiting time and advances the backoff
+func (b *Backoff) Next() time.Duration {
+	current := b.next
+	b.next = time.Duration(float64(b.next) * b.multiplier)
+	if b.next > b.maximum {
+		b.next = b.maximum
+	}
+	return current
+}
+
+// Reset restarts the backoff from its initial waiting time
+func (b *Backoff) Reset() {
+	b.next = b.initial
+}
+
+func (p RetryPolicy) newBackoff() *Backoff {
+	return NewBackoff(p.InitialBackoff, p.MaxBackoff, p.Multiplier)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryableStatus reports whether a response status signals a transient upstream failure
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
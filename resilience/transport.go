@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Resilience package wraps the outbound SBI HTTP clients with retries and
+ * per-target circuit breakers.
+ */
+
+package resilience
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/metrics"
+	"github.com/omec-project/ausf/tracing"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Transport is a http.RoundTripper that retries idempotent requests on transient
+// failures and rejects requests to targets whose circuit breaker is open.
+type Transport struct {
+	Base     http.RoundTripper
+	Retry    RetryPolicy
+	Breakers *BreakerSet
+	sleep    func(*http.Request, time.Duration) error
+}
+
+func NewTransport(base http.RoundTripper, retry RetryPolicy, breakers *BreakerSet) *Transport {
+	if base == nil {
+		base = newBaseTransport("")
+	}
+	return &Transport{
+		Base:     base,
+		Retry:    retry,
+		Breakers: breakers,
+		sleep:    sleepWithContext,
+	}
+}
+
+// RoundTrip sends the request and records its latency, retries included, per SBI service
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.roundTrip(req)
+	status := 0
+	if err == nil {
+		status = res.StatusCode
+	}
+	metrics.ObserveOutboundRequest(sbiServiceName(req.URL.Path), req.Method, status, time.Since(start))
+	return res, err
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	breaker := t.Breakers.Get(req.URL.Host)
+	if !breaker.Allow() {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Host, ErrCircuitOpen)
+	}
+	attempts := 1
+	if isIdempotent(req.Method) && (req.Body == nil || req.GetBody != nil) && t.Retry.MaxAttempts > 1 {
+		attempts = t.Retry.MaxAttempts
+	}
+	res, err := t.retry(req, attempts, t.Retry.newBackoff())
+	// the breaker counts logical requests, so the retries of one request do not open it
+	if err == nil && !isRetryableStatus(res.StatusCode) {
+		breaker.OnSuccess()
+	} else {
+		breaker.OnFailure()
+	}
+	return res, err
+}
+
+// retry sends the request up to attempts times, backing off between the attempts
+func (t *Transport) retry(req *http.Request, attempts int, backoff *Backoff) (*http.Response, error) {
+	var (
+		res *http.Response
+		err error
+	)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		res, err = t.Base.RoundTrip(attemptReq)
+		if err == nil && !isRetryableStatus(res.StatusCode) {
+			return res, nil
+		}
+		if attempt == attempts {
+			break
+		}
+		if err != nil {
+			logger.ConsumerLog.Warnf("%s %s attempt %d/%d failed: %v", req.Method, req.URL, attempt, attempts, err)
+		} else {
+			logger.ConsumerLog.Warnf("%s %s attempt %d/%d returned %d", req.Method, req.URL, attempt, attempts, res.StatusCode)
+			if closeErr := res.Body.Close(); closeErr != nil {
+				logger.ConsumerLog.Errorf("response body cannot close: %+v", closeErr)
+			}
+		}
+		if sleepErr := t.sleep(req, backoff.Next()); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+	return res, err
+}
+
+// sbiServiceName returns the service name at the start of an SBI path, e.g. nudm-ueau
+// for /nudm-ueau/v1/..., which keeps the cardinality of the latency metric bounded.
+func sbiServiceName(path string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if service == "" {
+		return "unknown"
+	}
+	return service
+}
+
+func sleepWithContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var (
+	tlsConfigMu     sync.RWMutex
+	tlsClientConfig = func() *tls.Config {
+		return &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+)
+
+// SetTLSClientConfig installs the function returning the TLS configuration (client certificate,
+// trusted CAs, minimum version) of new outbound connections. It is called on every TLS dial,
+// so certificates reloaded from disk are used without rebuilding the clients.
+func SetTLSClientConfig(fn func() *tls.Config) {
+	tlsConfigMu.Lock()
+	defer tlsConfigMu.Unlock()
+	tlsClientConfig = fn
+}
+
+func currentTLSClientConfig() *tls.Config {
+	tlsConfigMu.RLock()
+	defer tlsConfigMu.RUnlock()
+	return tlsClientConfig()
+}
+
+// newBaseTransport clones http.DefaultTransport and dials TLS with the current client
+// configuration. serverName overrides the SNI and the name verified in the peer certificate.
+func newBaseTransport(serverName string) *http.Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	base.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		cfg := currentTLSClientConfig().Clone()
+		cfg.NextProtos = []string{"h2", "http/1.1"}
+		cfg.ServerName = serverName
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			cfg.ServerName = host
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: cfg}
+		return tlsDialer.DialContext(ctx, network, addr)
+	}
+	return base
+}
+
+var (
+	clientMu        sync.RWMutex
+	sharedTransport = NewTransport(nil, DefaultRetryPolicy, NewBreakerSet(DefaultBreakerSettings))
+	sharedClient    = newClient(sharedTransport)
+)
+
+// newClient returns a client sending through t, with a client span and W3C trace context
+// injected once per logical request rather than once per retry
+func newClient(t *Transport) *http.Client {
+	return &http.Client{Transport: tracing.Transport(t)}
+}
+
+// Init builds the shared resilient client from the resilience section of the configuration
+func Init(cfg *factory.Resilience) {
+	retry := DefaultRetryPolicy
+	breaker := DefaultBreakerSettings
+	if cfg != nil {
+		if r := cfg.Retry; r != nil {
+			if r.MaxAttempts > 0 {
+				retry.MaxAttempts = r.MaxAttempts
+			}
+			if r.InitialBackoffMs > 0 {
+				retry.InitialBackoff = time.Duration(r.InitialBackoffMs) * time.Millisecond
+			}
+			if r.MaxBackoffMs > 0 {
+				retry.MaxBackoff = time.Duration(r.MaxBackoffMs) * time.Millisecond
+			}
+			if r.Multiplier >= 1 {
+				retry.Multiplier = r.Multiplier
+			}
+		}
+		if cb := cfg.CircuitBreaker; cb != nil {
+			breaker.Disabled = cb.Disabled
+			if cb.FailureThreshold > 0 {
+				breaker.FailureThreshold = cb.FailureThreshold
+			}
+			if cb.OpenTimeout > 0 {
+				breaker.OpenTimeout = time.Duration(cb.OpenTimeout) * time.Second
+			}
+		}
+	}
+	logger.InitLog.Infof("outbound retry policy: %+v, circuit breaker: %+v", retry, breaker)
+
+	clientMu.Lock()
+	sharedTransport = NewTransport(nil, retry, NewBreakerSet(breaker))
+	sharedClient = newClient(sharedTransport)
+	clientMu.Unlock()
+}
+
+// HTTPClient returns the shared client used by the NRF and UDM consumers
+func HTTPClient() *http.Client {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return sharedClient
+}
+
+// HTTPClientWithServerName returns a client that shares the retry policy and circuit breakers
+// of HTTPClient and presents serverName as TLS SNI, verifying the peer certificate against it.
+func HTTPClientWithServerName(serverName string) *http.Client {
+	clientMu.RLock()
+	shared := sharedTransport
+	clientMu.RUnlock()
+	return newClient(NewTransport(newBaseTransport(serverName), shared.Retry, shared.Breakers))
+}
@@ -0,0 +1,274 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ * Resilience Unit Tests
+ *
+ */
+
+package resilience
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func newTestClient(retry RetryPolicy, settings BreakerSettings) (*http.Client, *BreakerSet) {
+	breakers := NewBreakerSet(settings)
+	transport := NewTransport(nil, retry, breakers)
+	transport.sleep = func(*http.Request, time.Duration) error { return nil }
+	return &http.Client{Transport: transport}, breakers
+}
+
+func TestTransport_RetriesIdempotentRequestOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}, DefaultBreakerSettings)
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"nfStatus":"REGISTERED"}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, res.StatusCode)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestTransport_DoesNotRetryNonIdempotentRequest(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(RetryPolicy{MaxAttempts: 3}, DefaultBreakerSettings)
+	res, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, res.StatusCode)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestClient_PropagatesTraceContextOncePerRequest(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	}()
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil, RetryPolicy{MaxAttempts: 2}, NewBreakerSet(DefaultBreakerSettings))
+	transport.sleep = func(*http.Request, time.Duration) error { return nil }
+	ctx, span := otel.Tracer("test").Start(context.Background(), "nudm-ueau DeleteAuth")
+	defer span.End()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, server.URL+"/nudm-ueau/v1/imsi-001010000000001/auth-events/1", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	res, err := newClient(transport).Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	res.Body.Close()
+
+	if len(traceparents) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(traceparents))
+	}
+	if traceparents[0] == "" || traceparents[0] != traceparents[1] {
+		t.Errorf("expected every attempt to carry the same traceparent, got %q", traceparents)
+	}
+	if !strings.Contains(traceparents[0], span.SpanContext().TraceID().String()) {
+		t.Errorf("expected the caller trace in %q", traceparents[0])
+	}
+}
+
+func TestTransport_CircuitBreakerOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, breakers := newTestClient(RetryPolicy{MaxAttempts: 1}, BreakerSettings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	host := strings.TrimPrefix(server.URL, "http://")
+	now := time.Now()
+	breakers.Get(host).now = func() time.Time { return now }
+
+	for range 2 {
+		res, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		res.Body.Close()
+	}
+	if state := breakers.Get(host).State(); state != StateOpen {
+		t.Fatalf("expected breaker to be open, got %s", state)
+	}
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected open breaker to short-circuit the request, got %d calls", calls.Load())
+	}
+
+	healthy.Store(true)
+	now = now.Add(time.Minute)
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected half-open probe to succeed, got %v", err)
+	}
+	res.Body.Close()
+	if state := breakers.Get(host).State(); state != StateClosed {
+		t.Errorf("expected breaker to be closed after successful probe, got %s", state)
+	}
+}
+
+func TestTransport_CircuitBreakerCountsOneFailurePerRequest(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, breakers := newTestClient(RetryPolicy{MaxAttempts: 3}, BreakerSettings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+	if state := breakers.Get(host).State(); state != StateClosed {
+		t.Fatalf("expected the retries of one request to keep the breaker closed, got %s", state)
+	}
+
+	res, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	res.Body.Close()
+	if state := breakers.Get(host).State(); state != StateOpen {
+		t.Errorf("expected the second failed request to open the breaker, got %s", state)
+	}
+}
+
+func TestTransport_DialsWithInstalledTLSClientConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("expected HTTP/2, got %s", r.Proto)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client, _ := newTestClient(RetryPolicy{MaxAttempts: 1}, DefaultBreakerSettings)
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected the test server certificate to be untrusted by default")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	SetTLSClientConfig(func() *tls.Config {
+		return &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+	})
+	defer SetTLSClientConfig(func() *tls.Config {
+		return &tls.Config{MinVersion: tls.VersionTLS12}
+	})
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected request to succeed with the installed TLS configuration: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, res.StatusCode)
+	}
+}
+
+func TestBackoff_GrowsExponentiallyUpToMaximum(t *testing.T) {
+	backoff := NewBackoff(time.Second, 5*time.Second, 2)
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := backoff.Next(); got != want {
+			t.Errorf("step %d: expected %v, got %v", i, want, got)
+		}
+	}
+	backoff.Reset()
+	if got := backoff.Next(); got != time.Second {
+		t.Errorf("expected backoff to reset to %v, got %v", time.Second, got)
+	}
+}
+
+func TestSbiServiceName(t *testing.T) {
+	testCases := map[string]string{
+		"/nudm-ueau/v1/suci-0-001-01-0-0-0-1/security-information/generate-auth-data": "nudm-ueau",
+		"/nnrf-nfm/v1/nf-instances/ausf-1":                                            "nnrf-nfm",
+		"/":                                                                           "unknown",
+		"":                                                                            "unknown",
+	}
+	for path, expected := range testCases {
+		if got := sbiServiceName(path); got != expected {
+			t.Errorf("sbiServiceName(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
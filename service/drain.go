@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/omec-project/openapi/v2/utils"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+// newAuthenticationPaths start a new authentication; they are rejected while draining,
+// whereas the confirmations of the authentications already started are still served
+var newAuthenticationPaths = map[string]bool{
+	"/nausf-auth/v1/ue-authentications":    true,
+	"/nausf-auth/v1/prose-authentications": true,
+	"/nausf-auth/v1/rg-authentications":    true,
+}
+
+// drainer counts the in-flight SBI requests and, once draining, rejects new authentications
+type drainer struct {
+	draining atomic.Bool
+	inFlight atomic.Int64
+}
+
+func (d *drainer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d.inFlight.Add(1)
+		defer d.inFlight.Add(-1)
+		if d.draining.Load() && c.Request.Method == http.MethodPost && newAuthenticationPaths[c.FullPath()] {
+			problemDetails := utils.ProblemDetails("AUSF is shutting down", http.StatusServiceUnavailable, "")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, problemDetails)
+			return
+		}
+		c.Next()
+	}
+}
+
+// startDraining makes the new authentications fail with 503 Service Unavailable
+func (d *drainer) startDraining() {
+	d.draining.Store(true)
+}
+
+// wait returns once no request is in flight, or with an error when ctx is done first.
+// The authentications waiting for their confirmation are not waited for: an AMF may
+// never confirm them, and they would hold every shutdown up to the timeout.
+func (d *drainer) wait(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		inFlight := d.inFlight.Load()
+		if inFlight == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d requests in flight: %w", inFlight, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
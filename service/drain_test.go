@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the draining of the AUSF SBI server
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/omec-project/ausf/nfregistration"
+)
+
+/*
+ * Drain Unit Tests
+ */
+
+func TestDrainer_RejectsNewAuthenticationsAndWaitsForInFlightRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	drain := &drainer{}
+	router := gin.New()
+	router.Use(drain.Middleware())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	router.POST("/nausf-auth/v1/ue-authentications", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.PUT("/nausf-auth/v1/ue-authentications/:authCtxId/5g-aka-confirmation", func(c *gin.Context) {
+		if c.Param("authCtxId") == "slow" {
+			close(started)
+			<-release
+		}
+		c.Status(http.StatusOK)
+	})
+	serve := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost, "/nausf-auth/v1/ue-authentications"); code != http.StatusCreated {
+		t.Fatalf("expected authentications to be served before draining, got %d", code)
+	}
+
+	slowDone := make(chan int)
+	go func() {
+		slowDone <- serve(http.MethodPut, "/nausf-auth/v1/ue-authentications/slow/5g-aka-confirmation")
+	}()
+	<-started
+	drain.startDraining()
+
+	if code := serve(http.MethodPost, "/nausf-auth/v1/ue-authentications"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected new authentications to be rejected while draining, got %d", code)
+	}
+	if code := serve(http.MethodPut, "/nausf-auth/v1/ue-authentications/fast/5g-aka-confirmation"); code != http.StatusOK {
+		t.Errorf("expected confirmations to be served while draining, got %d", code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := drain.wait(ctx); err == nil {
+		t.Error("expected the drain to time out while a request is in flight")
+	}
+
+	close(release)
+	if code := <-slowDone; code != http.StatusOK {
+		t.Errorf("expected the in-flight confirmation to complete, got %d", code)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := drain.wait(ctx); err != nil {
+		t.Errorf("expected the drain to complete: %v", err)
+	}
+}
+
+func TestDrainServer_StopsTheRegistrationBeforeSettingTheAusfUndiscoverable(t *testing.T) {
+	originalSetUndiscoverable := nfregistration.SetUndiscoverable
+	defer func() { nfregistration.SetUndiscoverable = originalSetUndiscoverable }()
+	var calls []string
+	nfregistration.SetUndiscoverable = func() error {
+		calls = append(calls, "undiscoverable")
+		return nil
+	}
+	stopRegistration := func() { calls = append(calls, "stop registration") }
+
+	ausf := &AUSF{}
+	ausf.drainServer(&http.Server{}, &drainer{}, stopRegistration, time.Second)
+
+	if !reflect.DeepEqual(calls, []string{"stop registration", "undiscoverable"}) {
+		t.Errorf("expected the registration to be stopped before the AUSF is set undiscoverable, got %v", calls)
+	}
+}
@@ -10,7 +10,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -18,14 +21,23 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/omec-project/ausf/admin"
+	"github.com/omec-project/ausf/audit"
 	"github.com/omec-project/ausf/callback"
 	"github.com/omec-project/ausf/consumer"
 	ausfContext "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/filewatch"
+	"github.com/omec-project/ausf/health"
+	"github.com/omec-project/ausf/load"
 	"github.com/omec-project/ausf/logger"
 	"github.com/omec-project/ausf/metrics"
 	"github.com/omec-project/ausf/nfregistration"
 	"github.com/omec-project/ausf/polling"
+	"github.com/omec-project/ausf/producer"
+	"github.com/omec-project/ausf/resilience"
+	"github.com/omec-project/ausf/tlsconfig"
+	"github.com/omec-project/ausf/tracing"
 	"github.com/omec-project/ausf/ueauthentication"
 	openapiLogger "github.com/omec-project/openapi/v2/logger"
 	"github.com/omec-project/openapi/v2/models"
@@ -46,11 +58,22 @@ type (
 
 var config Config
 
+var sbiTLS *tlsconfig.Manager
+
+// shutdownTracing flushes the spans not exported yet
+var shutdownTracing = func(context.Context) error { return nil }
+
+// udmCheckInterval is the period of the UDM discovery readiness check
+const udmCheckInterval = 30 * time.Second
+
 var ausfCLi = []cli.Flag{
 	&cli.StringFlag{
-		Name:     "cfg",
-		Usage:    "ausf config file",
-		Required: true,
+		Name:  "cfg",
+		Usage: "ausf config file, required unless a command is given",
+	},
+	&cli.BoolFlag{
+		Name:  "validate-config",
+		Usage: "validate the config file and exit",
 	},
 }
 
@@ -79,13 +102,72 @@ func (ausf *AUSF) Initialize(c *cli.Command) error {
 		return err
 	}
 
+	if err := factory.AusfConfig.Validate(); err != nil {
+		return err
+	}
+
+	if logging := factory.AusfConfig.Configuration.Logging; logging != nil {
+		if err := logger.Configure(logging.Encoding, logging.OutputPaths); err != nil {
+			return fmt.Errorf("configure logging: %w", err)
+		}
+	}
+	if err := audit.Init(factory.AusfConfig.Configuration.Audit); err != nil {
+		return err
+	}
+
 	factory.AusfConfig.CfgLocation = absPath
 	ausfContext.Init()
+	resilience.Init(factory.AusfConfig.Configuration.Resilience)
+	load.Init(factory.AusfConfig.Configuration.NfProfile)
+	consumer.InitDiscoveryCache(factory.AusfConfig.Configuration.DiscoveryCache)
+	if err := initSbiTLS(); err != nil {
+		return err
+	}
+	return producer.InitAuthEventDelivery(factory.AusfConfig.Configuration.AuthEventDelivery)
+}
+
+// ValidateConfig checks the config file given with --cfg without starting the AUSF
+func (ausf *AUSF) ValidateConfig(c *cli.Command) error {
+	absPath, err := filepath.Abs(c.String("cfg"))
+	if err != nil {
+		return err
+	}
+	cfg, err := factory.ReadConfig(absPath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	logger.CfgLog.Infof("configuration %s is valid", absPath)
+	return nil
+}
+
+// initSbiTLS loads the SBI certificates and makes the outbound clients present them
+func initSbiTLS() error {
+	self := ausfContext.GetSelf()
+	var tlsCfg *factory.TLS
+	if sbi := factory.AusfConfig.Configuration.Sbi; sbi != nil {
+		tlsCfg = sbi.TLS
+	}
+	opts, err := tlsconfig.OptionsFromConfig(tlsCfg, self.PEM, self.Key, self.UriScheme == models.URISCHEME_HTTPS)
+	if err != nil {
+		return err
+	}
+	manager, err := tlsconfig.NewManager(opts)
+	if err != nil {
+		return err
+	}
+	sbiTLS = manager
+	resilience.SetTLSClientConfig(manager.ClientConfig)
 	return nil
 }
 
 func (ausf *AUSF) setLogLevel() {
-	cfgLogger := factory.AusfConfig.Logger
+	applyLogLevel(factory.AusfConfig.Logger)
+}
+
+func applyLogLevel(cfgLogger *utilLogger.Logger) {
 	if cfgLogger == nil {
 		logger.InitLog.Warnln("AUSF config without log level setting")
 		return
@@ -95,68 +177,226 @@ func (ausf *AUSF) setLogLevel() {
 	utilLogger.ApplyLogSetting("OpenApi", cfgLogger.OpenApi, openapiLogger.OpenapiLog, openapiLogger.SetLogLevel)
 }
 
-func (ausf *AUSF) Start() {
+// Start runs the AUSF until it receives SIGINT or SIGTERM, then shuts it down gracefully.
+// It returns an error when the SBI server cannot be started or fails.
+func (ausf *AUSF) Start() error {
 	logger.InitLog.Infoln("server started")
 
+	self := ausfContext.GetSelf()
+	if shutdown, err := tracing.Init(factory.AusfConfig.Configuration.Tracing, self.NfId); err != nil {
+		logger.InitLog.Errorf("tracing disabled: %v", err)
+	} else {
+		shutdownTracing = shutdown
+	}
+
+	drain := &drainer{}
 	router := utilLogger.NewGinWithZap(logger.GinLog)
+	router.Use(tracing.Middleware(), logger.RequestMiddleware(), metrics.InboundRequestMiddleware(), load.Middleware(),
+		drain.Middleware())
 	ueauthentication.AddService(router)
 	callback.AddService(router)
 
-	go metrics.InitMetrics()
+	sbi := factory.AusfConfig.Configuration.Sbi
+	serverScheme := sbi.Scheme
+	if serverScheme != "http" && serverScheme != "https" {
+		return fmt.Errorf("invalid SBI server scheme %q", serverScheme)
+	}
+	sslLog := filepath.Dir(factory.AusfConfig.CfgLocation) + "/sslkey.log"
+	addrs := self.GetBindingAddresses()
+	server, err := http2_util.NewServer(addrs[0], sslLog, router)
+	if server == nil {
+		return fmt.Errorf("initialize HTTP server: %w", err)
+	}
+	if err != nil {
+		logger.InitLog.Warnf("initialize HTTP server: %v", err)
+	}
+	if serverScheme == "https" {
+		server.TLSConfig = sbiTLS.ServerConfig(server.TLSConfig)
+	}
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		listener, err := net.Listen(listenNetwork(addr), addr)
+		if err != nil {
+			for _, l := range listeners {
+				err = errors.Join(err, l.Close())
+			}
+			return fmt.Errorf("listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, listener)
+	}
 
-	self := ausfContext.GetSelf()
-	addr := fmt.Sprintf("%s:%d", self.BindingIPv4, self.SBIPort)
+	metrics.SetContextSources(ausfContext.UeContextCount, ausfContext.PendingAuthContextCount)
+	metrics.SetHealthChecks(health.Live, health.Ready)
 
 	if self.EnableNrfCaching {
 		logger.InitLog.Infoln("enable NRF caching feature")
 		nrfCache.InitNrfCaching(self.NrfCacheEvictionInterval*time.Second, consumer.SendNfDiscoveryToNrfCacheQuery)
 	}
 
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalChannel)
+
 	plmnConfigChan := make(chan []models.PlmnId, 1)
+	servingNetworksChan := make(chan []string, 1)
+	plmnAuthPoliciesChan := make(chan []factory.PlmnAuthPolicy, 1)
+	ausfInfoChan := make(chan *factory.AusfInfo, 1)
+	policyChannels := polling.PolicyChannels{
+		ServingNetworks:  servingNetworksChan,
+		PlmnAuthPolicies: plmnAuthPoliciesChan,
+		AusfInfo:         ausfInfoChan,
+	}
 	ctx, cancelServices := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(9)
 	go func() {
 		defer wg.Done()
-		polling.StartPollingService(ctx, factory.AusfConfig.Configuration.WebuiUri, plmnConfigChan)
+		defer health.Track("config_polling")()
+		polling.StartPollingService(ctx, factory.AusfConfig.Configuration.WebuiUri, factory.AusfConfig.Configuration.ConfigUpdates,
+			plmnConfigChan, policyChannels)
 	}()
+	// the registration is stopped on its own before the drain, so that it cannot register
+	// the AUSF again once it is undiscoverable
+	registrationCtx, cancelRegistration := context.WithCancel(ctx)
+	registrationDone := make(chan struct{})
+	stopRegistration := func() {
+		cancelRegistration()
+		<-registrationDone
+	}
 	go func() {
 		defer wg.Done()
-		nfregistration.StartNfRegistrationService(ctx, plmnConfigChan)
+		defer close(registrationDone)
+		defer health.Track("nrf_registration")()
+		nfregistration.StartNfRegistrationService(registrationCtx, plmnConfigChan, ausfInfoChan)
 	}()
-
-	signalChannel := make(chan os.Signal, 1)
-	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-signalChannel
-		ausf.Terminate(cancelServices, &wg)
-		os.Exit(0)
+		defer wg.Done()
+		defer health.Track("serving_network_policy")()
+		ausfContext.WatchAllowedServingNetworks(ctx, servingNetworksChan)
+	}()
+	go func() {
+		defer wg.Done()
+		defer health.Track("auth_policy")()
+		producer.StartAuthPolicyService(ctx, plmnAuthPoliciesChan)
+	}()
+	go func() {
+		defer wg.Done()
+		defer health.Track("auth_event_delivery")()
+		producer.StartAuthEventDelivery(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		defer health.Track("certificate_watch")()
+		filewatch.Watch(ctx, sbiTLS.ReloadInterval(), sbiTLS.Files(), func() {
+			if err := sbiTLS.Reload(); err != nil {
+				logger.InitLog.Errorf("SBI certificate reload failed, keeping the current certificates: %v", err)
+			}
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		defer health.Track("config_watch")()
+		filewatch.Watch(ctx, configWatchInterval, []string{factory.AusfConfig.CfgLocation}, ausf.reloadConfig)
+	}()
+	go func() {
+		defer wg.Done()
+		defer health.Track("reload_signal_handler")()
+		ausf.handleReloadSignal(ctx)
 	}()
+	go func() {
+		defer wg.Done()
+		health.Poll(ctx, udmCheckInterval, health.CheckUdmDiscovery, producer.CheckUdmResolvable)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer health.Track("metrics_server")()
+		if err := metrics.Start(ctx, factory.AusfConfig.Configuration.Metrics); err != nil {
+			logger.InitLog.Errorf("could not serve metrics: %v", err)
+		}
+	}()
+	if adminCfg := factory.AusfConfig.Configuration.Admin; adminCfg != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer health.Track("admin_api")()
+			if err := admin.Start(ctx, adminCfg); err != nil {
+				logger.AdminLog.Errorf("admin API failed: %v", err)
+			}
+		}()
+	}
 
-	sslLog := filepath.Dir(factory.AusfConfig.CfgLocation) + "/sslkey.log"
-	server, err := http2_util.NewServer(addr, sslLog, router)
-	if server == nil {
-		logger.InitLog.Errorf("initialize HTTP server failed: %v", err)
-		return
+	serveErr := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		logger.InitLog.Infof("SBI server listening on %s", listener.Addr())
+		go func() {
+			defer health.Track("sbi_server " + listener.Addr().String())()
+			if serverScheme == "https" {
+				serveErr <- server.ServeTLS(listener, "", "")
+			} else {
+				serveErr <- server.Serve(listener)
+			}
+		}()
 	}
 
-	if err != nil {
-		logger.InitLog.Warnf("initialize HTTP server: %v", err)
+	select {
+	case sig := <-signalChannel:
+		logger.InitLog.Infof("received %v, shutting down", sig)
+		drainTimeout := factory.DEFAULT_SBI_DRAIN_TIMEOUT
+		if sbi.DrainTimeout > 0 {
+			drainTimeout = sbi.DrainTimeout
+		}
+		ausf.drainServer(server, drain, stopRegistration, time.Duration(drainTimeout)*time.Second)
+		ausf.Terminate(cancelServices, &wg)
+		return nil
+	case err := <-serveErr:
+		if closeErr := server.Close(); closeErr != nil {
+			logger.InitLog.Warnf("could not close the SBI server: %v", closeErr)
+		}
+		ausf.Terminate(cancelServices, &wg)
+		return fmt.Errorf("SBI server failed: %w", err)
 	}
+}
 
-	serverScheme := factory.AusfConfig.Configuration.Sbi.Scheme
-	switch serverScheme {
-	case "http":
-		err = server.ListenAndServe()
-	case "https":
-		err = server.ListenAndServeTLS(self.PEM, self.Key)
-	default:
-		logger.InitLog.Fatalf("HTTP server setup failed: invalid server scheme %+v", serverScheme)
-		return
+// drainServer stops the NRF registration, sets the AUSF undiscoverable in the NRF, rejects
+// new authentications and gives the in-flight requests up to timeout to complete before the
+// SBI server is closed
+func (ausf *AUSF) drainServer(server *http.Server, drain *drainer, stopRegistration func(), timeout time.Duration) {
+	stopRegistration()
+	if err := nfregistration.SetUndiscoverable(); err != nil {
+		logger.InitLog.Warnf("could not set the AUSF undiscoverable in NRF: %v", err)
+	}
+	drain.startDraining()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	logger.InitLog.Infof("draining SBI requests for up to %v", timeout)
+	if err := drain.wait(ctx); err != nil {
+		logger.InitLog.Warnf("drain timed out: %v", err)
 	}
+	if err := server.Shutdown(ctx); err != nil {
+		logger.InitLog.Warnf("closing the remaining SBI connections: %v", err)
+		if err := server.Close(); err != nil {
+			logger.InitLog.Warnf("could not close the SBI server: %v", err)
+		}
+	}
+	logger.InitLog.Infoln("SBI server stopped")
+}
 
+// listenNetwork pins the listener to the IP family of the binding address, so that
+// an IPv4 and an IPv6 wildcard address can be bound on the same port.
+func listenNetwork(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		logger.InitLog.Fatalf("HTTP server setup failed: %+v", err)
+		return "tcp"
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "tcp"
+	case ip.To4() != nil:
+		return "tcp4"
+	default:
+		return "tcp6"
 	}
 }
 
@@ -165,5 +405,13 @@ func (ausf *AUSF) Terminate(cancelServices context.CancelFunc, wg *sync.WaitGrou
 	cancelServices()
 	nfregistration.DeregisterNF()
 	wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.InitLog.Warnf("could not flush traces: %v", err)
+	}
+	if err := audit.Close(); err != nil {
+		logger.InitLog.Warnf("could not close the audit log: %v", err)
+	}
 	logger.InitLog.Infoln("AUSF terminated")
 }
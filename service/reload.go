@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"github.com/omec-project/ausf/nfregistration"
+	"github.com/omec-project/ausf/polling"
+)
+
+const configWatchInterval = 5 * time.Second
+
+var reloadMu sync.Mutex
+
+// handleReloadSignal reloads the configuration on SIGHUP until the context is cancelled
+func (ausf *AUSF) handleReloadSignal(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			logger.CfgLog.Infoln("received SIGHUP")
+			ausf.reloadConfig()
+		}
+	}
+}
+
+// reloadConfig reads the configuration file again and applies the fields that can change at
+// runtime: log levels, NRF URIs and webui URI. They are kept by the context and the polling
+// service, so the configuration read at startup is left untouched. An invalid file is refused
+// and the running configuration is kept. Changes to other fields are only applied on restart.
+func (ausf *AUSF) reloadConfig() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	location := factory.AusfConfig.CfgLocation
+	logger.CfgLog.Infof("reloading configuration from %s", location)
+	newConfig, err := factory.ReadConfig(location)
+	if err == nil {
+		err = newConfig.Validate()
+	}
+	if err != nil {
+		logger.CfgLog.Errorf("invalid configuration, keeping the running one: %v", err)
+		return
+	}
+
+	updated := newConfig.Configuration
+	warnRestartRequired(factory.AusfConfig.Configuration, updated)
+
+	applyLogLevel(newConfig.Logger)
+
+	self := ausfContext.GetSelf()
+	if current := self.GetNrfUris(); !slices.Equal(current, updated.GetNrfUris()) {
+		logger.CfgLog.Infof("NRF URIs changed from %v to %v", current, updated.GetNrfUris())
+		nfregistration.DeregisterNF()
+		self.ApplyReloadableConfig(updated)
+		nfregistration.TriggerReRegistration()
+	}
+
+	if current := polling.WebuiUri(); current != updated.WebuiUri {
+		logger.CfgLog.Infof("webuiUri changed from %s to %s", current, updated.WebuiUri)
+		polling.SetWebuiUri(updated.WebuiUri)
+	}
+	logger.CfgLog.Infoln("configuration reloaded")
+}
+
+func warnRestartRequired(current, updated *factory.Configuration) {
+	fields := []struct {
+		name           string
+		current, value any
+	}{
+		{"sbi", current.Sbi, updated.Sbi},
+		{"serviceNameList", current.ServiceNameList, updated.ServiceNameList},
+		{"groupId", current.GroupId, updated.GroupId},
+		{"enableNrfCaching", current.EnableNrfCaching, updated.EnableNrfCaching},
+		{"nrfCacheEvictionInterval", current.NrfCacheEvictionInterval, updated.NrfCacheEvictionInterval},
+		{"resilience", current.Resilience, updated.Resilience},
+		{"authEventDelivery", current.AuthEventDelivery, updated.AuthEventDelivery},
+		{"admin", current.Admin, updated.Admin},
+		{"metrics", current.Metrics, updated.Metrics},
+		{"tracing", current.Tracing, updated.Tracing},
+		{"logging", current.Logging, updated.Logging},
+		{"audit", current.Audit, updated.Audit},
+		{"nfProfile", current.NfProfile, updated.NfProfile},
+		{"ausfInfo", current.AusfInfo, updated.AusfInfo},
+		{"configUpdates", current.ConfigUpdates, updated.ConfigUpdates},
+		{"discoveryCache", current.DiscoveryCache, updated.DiscoveryCache},
+	}
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.current, field.value) {
+			logger.CfgLog.Warnf("change of %s requires a restart and was not applied", field.name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the reload of the AUSF configuration
+ */
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ausfContext "github.com/omec-project/ausf/context"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/polling"
+)
+
+const reloadedConfigForTest = `configuration:
+  nrfUri: http://nrf:8081
+  webuiUri: http://webui-2:5001
+  enableNrfCaching: true
+  sbi:
+    port: 29509
+    scheme: http
+  serviceNameList:
+    - nausf-auth
+info:
+  version: 1.0.0
+`
+
+/*
+ * Reload Unit Tests
+ */
+
+func TestReloadConfig_AppliesTheReloadableFieldsWithoutReplacingTheConfiguration(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "ausfcfg.yaml")
+	if err := os.WriteFile(location, []byte(reloadedConfigForTest), 0o600); err != nil {
+		t.Fatalf("failed to write configuration: %v", err)
+	}
+	original := factory.AusfConfig
+	running := &factory.Configuration{NrfUri: "http://nrf:8081", WebuiUri: "http://webui:5001"}
+	factory.AusfConfig = factory.Config{Configuration: running, CfgLocation: location}
+	self := ausfContext.GetSelf()
+	self.ApplyReloadableConfig(running)
+	self.EnableNrfCaching = false
+	polling.SetWebuiUri(running.WebuiUri)
+	t.Cleanup(func() { factory.AusfConfig = original })
+
+	(&AUSF{}).reloadConfig()
+
+	if factory.AusfConfig.Configuration != running || running.WebuiUri != "http://webui:5001" || running.EnableNrfCaching {
+		t.Errorf("expected the configuration read at startup to be left untouched, got %+v", factory.AusfConfig.Configuration)
+	}
+	if webuiUri := polling.WebuiUri(); webuiUri != "http://webui-2:5001" {
+		t.Errorf("expected the reloaded webuiUri to be polled, got %s", webuiUri)
+	}
+	if self.IsNrfCachingEnabled() {
+		t.Error("expected the change of enableNrfCaching to require a restart")
+	}
+}
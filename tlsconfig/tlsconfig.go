@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Tlsconfig package builds the TLS configuration of the SBI server and of the
+ * outbound SBI clients from certificate files that may change at runtime.
+ */
+
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+)
+
+const DefaultReloadInterval = 10 * time.Second
+
+// Options lists the certificate files used on the SBI. Empty paths disable the matching feature.
+type Options struct {
+	CertFile       string // server certificate, only used when the SBI is served over https
+	KeyFile        string
+	ClientCertFile string // certificate presented to other NFs
+	ClientKeyFile  string
+	CAFile         string // CA bundle used to verify peers, enables mutual TLS
+	MinVersion     uint16
+	ReloadInterval time.Duration
+}
+
+// ParseVersion converts a configured TLS version ("1.2" or "1.3") to its crypto/tls value
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q, expected 1.2 or 1.3", version)
+	}
+}
+
+// OptionsFromConfig builds the options from the sbi.tls section. certFile and keyFile are
+// the server certificate files resolved by the AUSF context; they are also presented to
+// other NFs when mutual TLS is enabled and no dedicated client certificate is configured.
+func OptionsFromConfig(cfg *factory.TLS, certFile, keyFile string, serveTLS bool) (Options, error) {
+	opts := Options{
+		MinVersion:     tls.VersionTLS12,
+		ReloadInterval: DefaultReloadInterval,
+	}
+	if serveTLS {
+		opts.CertFile = certFile
+		opts.KeyFile = keyFile
+	}
+	if cfg == nil {
+		return opts, nil
+	}
+	version, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return opts, err
+	}
+	opts.MinVersion = version
+	if cfg.ReloadInterval > 0 {
+		opts.ReloadInterval = time.Duration(cfg.ReloadInterval) * time.Second
+	}
+	opts.CAFile = cfg.CA
+	if (cfg.ClientPEM == "") != (cfg.ClientKey == "") {
+		return opts, fmt.Errorf("clientPem and clientKey must be configured together")
+	}
+	opts.ClientCertFile = cfg.ClientPEM
+	opts.ClientKeyFile = cfg.ClientKey
+	if opts.ClientCertFile == "" && opts.CAFile != "" {
+		opts.ClientCertFile = certFile
+		opts.ClientKeyFile = keyFile
+	}
+	return opts, nil
+}
+
+// Manager holds the certificates loaded from the configured files. The TLS configurations
+// it returns always use the latest successfully loaded certificates, so Reload takes
+// effect on the next handshake without restarting the server or rebuilding the clients.
+type Manager struct {
+	opts Options
+
+	mu         sync.RWMutex
+	serverCert *tls.Certificate
+	clientCert *tls.Certificate
+	caPool     *x509.CertPool
+}
+
+func NewManager(opts Options) (*Manager, error) {
+	m := &Manager{opts: opts}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MutualTLS reports whether peers are required to present a certificate signed by the CA bundle
+func (m *Manager) MutualTLS() bool {
+	return m.opts.CAFile != ""
+}
+
+func (m *Manager) ReloadInterval() time.Duration {
+	return m.opts.ReloadInterval
+}
+
+// Files returns the configured certificate files
+func (m *Manager) Files() []string {
+	var files []string
+	for _, file := range []string{m.opts.CertFile, m.opts.KeyFile, m.opts.ClientCertFile, m.opts.ClientKeyFile, m.opts.CAFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Reload reads the certificate files again. On error the previously loaded certificates are kept.
+func (m *Manager) Reload() error {
+	var (
+		serverCert, clientCert *tls.Certificate
+		caPool                 *x509.CertPool
+	)
+	if m.opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(m.opts.CertFile, m.opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load server certificate: %w", err)
+		}
+		serverCert = &cert
+	}
+	if m.opts.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(m.opts.ClientCertFile, m.opts.ClientKeyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		clientCert = &cert
+	}
+	if m.opts.CAFile != "" {
+		pem, err := os.ReadFile(m.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("read CA bundle: %w", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in CA bundle %s", m.opts.CAFile)
+		}
+	}
+
+	m.mu.Lock()
+	m.serverCert = serverCert
+	m.clientCert = clientCert
+	m.caPool = caPool
+	m.mu.Unlock()
+	logger.InitLog.Infof("SBI certificates loaded (mutual TLS: %t)", m.MutualTLS())
+	return nil
+}
+
+// ServerConfig returns the configuration of the SBI server. The key log writer of base, if any, is kept.
+func (m *Manager) ServerConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{MinVersion: m.opts.MinVersion}
+	if base != nil {
+		cfg.KeyLogWriter = base.KeyLogWriter
+	}
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if m.serverCert == nil {
+			return nil, fmt.Errorf("no server certificate loaded")
+		}
+		handshakeCfg := &tls.Config{
+			MinVersion:   m.opts.MinVersion,
+			KeyLogWriter: cfg.KeyLogWriter,
+			NextProtos:   []string{"h2", "http/1.1"},
+			Certificates: []tls.Certificate{*m.serverCert},
+		}
+		if m.caPool != nil {
+			handshakeCfg.ClientAuth = tls.RequireAndVerifyClientCert
+			handshakeCfg.ClientCAs = m.caPool
+		}
+		return handshakeCfg, nil
+	}
+	return cfg
+}
+
+// ClientConfig returns the configuration for a new outbound connection. Peers are verified
+// against the CA bundle when configured, otherwise against the system roots.
+func (m *Manager) ClientConfig() *tls.Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg := &tls.Config{
+		MinVersion: m.opts.MinVersion,
+		RootCAs:    m.caPool,
+	}
+	if m.clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*m.clientCert}
+	}
+	return cfg
+}
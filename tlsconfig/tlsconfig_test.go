@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ * SBI TLS Unit Tests
+ *
+ */
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omec-project/ausf/factory"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) writeBundle(t *testing.T, path string) {
+	t.Helper()
+	writePEM(t, path, "CERTIFICATE", ca.cert.Raw)
+}
+
+// issue writes a certificate signed by the CA and its key to dir/name.pem and dir/name.key
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDer)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func newManagerForTest(t *testing.T, opts Options) *Manager {
+	t.Helper()
+	if opts.MinVersion == 0 {
+		opts.MinVersion = tls.VersionTLS12
+	}
+	m, err := NewManager(opts)
+	if err != nil {
+		t.Fatalf("failed to create TLS manager: %v", err)
+	}
+	return m
+}
+
+func startServer(t *testing.T, m *Manager) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = m.ServerConfig(nil)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(client *Manager, url string) (*http.Response, error) {
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: client.ClientConfig()}}
+	res, err := httpClient.Get(url)
+	if err == nil {
+		res.Body.Close()
+	}
+	return res, err
+}
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		expected uint16
+		wantErr  bool
+	}{
+		{version: "", expected: tls.VersionTLS12},
+		{version: "1.2", expected: tls.VersionTLS12},
+		{version: "1.3", expected: tls.VersionTLS13},
+		{version: "1.1", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			got, err := ParseVersion(tc.version)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for version %q", tc.version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %x, got %x", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestOptionsFromConfig_ClientCertificateDefaultsToServerCertificate(t *testing.T) {
+	opts, err := OptionsFromConfig(&factory.TLS{CA: "ca.pem", MinVersion: "1.3"}, "ausf.pem", "ausf.key", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ClientCertFile != "ausf.pem" || opts.ClientKeyFile != "ausf.key" {
+		t.Errorf("expected the server certificate to be used as client certificate, got %s/%s", opts.ClientCertFile, opts.ClientKeyFile)
+	}
+	if opts.MinVersion != tls.VersionTLS13 {
+		t.Errorf("expected TLS 1.3 minimum version, got %x", opts.MinVersion)
+	}
+
+	if _, err := OptionsFromConfig(&factory.TLS{ClientPEM: "client.pem"}, "ausf.pem", "ausf.key", true); err == nil {
+		t.Error("expected an error when clientPem is configured without clientKey")
+	}
+}
+
+func TestMutualTLS_RequiresTrustedClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca.writeBundle(t, caFile)
+	serverCert, serverKey := ca.issue(t, dir, "ausf", 2)
+	clientCert, clientKey := ca.issue(t, dir, "amf", 3)
+
+	server := startServer(t, newManagerForTest(t, Options{CertFile: serverCert, KeyFile: serverKey, CAFile: caFile}))
+
+	trusted := newManagerForTest(t, Options{ClientCertFile: clientCert, ClientKeyFile: clientKey, CAFile: caFile})
+	res, err := get(trusted, server.URL)
+	if err != nil {
+		t.Fatalf("expected mutual TLS request to succeed: %v", err)
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, res.StatusCode)
+	}
+
+	anonymous := newManagerForTest(t, Options{CAFile: caFile})
+	if _, err := get(anonymous, server.URL); err == nil {
+		t.Error("expected request without client certificate to be rejected")
+	}
+
+	otherCA := newTestCA(t)
+	untrustedCert, untrustedKey := otherCA.issue(t, dir, "rogue", 4)
+	untrusted := newManagerForTest(t, Options{ClientCertFile: untrustedCert, ClientKeyFile: untrustedKey, CAFile: caFile})
+	if _, err := get(untrusted, server.URL); err == nil {
+		t.Error("expected request with a certificate from an unknown CA to be rejected")
+	}
+}
+
+func TestReload_ServesNewCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca.writeBundle(t, caFile)
+	serverCert, serverKey := ca.issue(t, dir, "ausf", 2)
+	serverManager := newManagerForTest(t, Options{CertFile: serverCert, KeyFile: serverKey})
+	server := startServer(t, serverManager)
+	client := newManagerForTest(t, Options{CAFile: caFile})
+
+	servedSerial := func() int64 {
+		t.Helper()
+		cfg := client.ClientConfig()
+		cfg.ServerName = "127.0.0.1"
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), cfg)
+		if err != nil {
+			t.Fatalf("TLS handshake failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if serial := servedSerial(); serial != 2 {
+		t.Fatalf("expected serial 2, got %d", serial)
+	}
+
+	ca.issue(t, dir, "ausf", 5)
+	if err := serverManager.Reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if serial := servedSerial(); serial != 5 {
+		t.Errorf("expected reloaded certificate with serial 5, got %d", serial)
+	}
+
+	if err := os.WriteFile(serverCert, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to corrupt certificate: %v", err)
+	}
+	if err := serverManager.Reload(); err == nil {
+		t.Error("expected reload of an invalid certificate to fail")
+	}
+	if serial := servedSerial(); serial != 5 {
+		t.Errorf("expected previous certificate to be kept, got serial %d", serial)
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+/*
+ * Tracing package sets up OpenTelemetry tracing: spans for the inbound SBI requests,
+ * the UDM and NRF calls, and W3C trace context propagation on the outbound clients.
+ */
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/omec-project/ausf/factory"
+	"github.com/omec-project/ausf/logger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName        = "ausf"
+	instrumentationLib = "github.com/omec-project/ausf"
+	defaultTracesPath  = "/v1/traces"
+)
+
+func init() {
+	// trace context is propagated even when no exporter is configured
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Init installs the tracer provider exporting to the configured OTLP/HTTP endpoint and returns
+// the function flushing and stopping it. Without configuration spans are not recorded.
+func Init(cfg *factory.Tracing, nfInstanceID string) (shutdown func(context.Context) error, err error) {
+	if cfg == nil || cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing endpoint: %w", err)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = defaultTracesPath
+	}
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint.String()))
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+	sampleRatio := cfg.SampleRatio
+	if sampleRatio == 0 {
+		sampleRatio = 1
+	}
+	provider := NewTracerProvider(sdktrace.NewBatchSpanProcessor(exporter), sampleRatio, nfInstanceID)
+	otel.SetTracerProvider(provider)
+	logger.InitLog.Infof("exporting traces to %s (sample ratio %v)", endpoint, sampleRatio)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider returns a tracer provider describing the AUSF instance. Sampling follows
+// the parent span, and new traces are sampled with sampleRatio.
+func NewTracerProvider(processor sdktrace.SpanProcessor, sampleRatio float64, nfInstanceID string) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+		semconv.ServiceInstanceID(nfInstanceID),
+	)
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+}
+
+// Middleware starts a server span for every inbound SBI request, continuing the trace of the caller
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// Transport wraps an outbound round tripper with client spans and trace context injection
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// Start starts a child span of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationLib).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2026 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+/*
+ *  Tests for the AUSF Tracing
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const callerTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func installTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	provider := NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1, "test-instance")
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Errorf("failed to shut down the tracer provider: %v", err)
+		}
+	})
+	return exporter
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found in %d exported spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+/*
+ * Tracing Unit Tests
+ */
+
+func TestMiddleware_ContinuesCallerTrace(t *testing.T) {
+	exporter := installTestProvider(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.POST("/nausf-auth/v1/ue-authentications", func(c *gin.Context) {
+		_, span := Start(c.Request.Context(), "nudm-ueau GenerateAuthData")
+		span.End()
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/nausf-auth/v1/ue-authentications", nil)
+	req.Header.Set("traceparent", callerTraceparent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "POST /nausf-auth/v1/ue-authentications")
+	child := findSpan(t, spans, "nudm-ueau GenerateAuthData")
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("expected a server span, got %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the caller trace to be continued, got trace %s", got)
+	}
+	if got := server.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected the caller span as parent, got %s", got)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("expected the UDM span to be a child of the server span")
+	}
+}
+
+func TestTransport_InjectsTraceparent(t *testing.T) {
+	exporter := installTestProvider(t)
+	var received string
+	udm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer udm.Close()
+
+	ctx, parent := Start(context.Background(), "nudm-ueau ConfirmAuth")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, udm.URL+"/nudm-ueau/v1/imsi-001010000000001/auth-events", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	res, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	res.Body.Close()
+	parent.End()
+
+	client := findSpan(t, exporter.GetSpans(), "HTTP POST")
+	if client.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected the client span to be a child of the calling span")
+	}
+	expected := "00-" + client.SpanContext.TraceID().String() + "-" + client.SpanContext.SpanID().String() + "-01"
+	if received != expected {
+		t.Errorf("expected traceparent %q, got %q", expected, received)
+	}
+}
+
+func TestEnd_RecordsError(t *testing.T) {
+	exporter := installTestProvider(t)
+
+	_, span := Start(context.Background(), "nnrf-nfm RegisterNFInstance")
+	End(span, errors.New("no response from server"))
+
+	stub := findSpan(t, exporter.GetSpans(), "nnrf-nfm RegisterNFInstance")
+	if stub.Status.Code != codes.Error || stub.Status.Description != "no response from server" {
+		t.Errorf("expected an error status, got %+v", stub.Status)
+	}
+	if len(stub.Events) != 1 || stub.Events[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded as an event, got %+v", stub.Events)
+	}
+}
+
+func TestInit_WithoutEndpointKeepsNoopProvider(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	shutdown, err := Init(nil, "test-instance")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("expected the tracer provider to be left untouched")
+	}
+}
@@ -32,17 +32,19 @@ import (
 // Delete /ue-authentications/:authCtxId/5g-aka-confirmation
 // Deletes the authentication result in the UDM
 func HTTPDelete5gAkaAuthenticationResult(c *gin.Context) {
-	logger.Auth5gAkaComfirmLog.Infoln("Handle Delete /ue-authentications/:authCtxId/5g-aka-confirmation")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.Auth5gAkaComfirmLog)
+	log.Infoln("Handle Delete /ue-authentications/:authCtxId/5g-aka-confirmation")
 	req := httpwrapper.NewRequest(c.Request, nil)
 	req.Params["authCtxId"] = c.Param("authCtxId")
-	rsp := producer.HandleDelete5gAkaAuthenticationResultRequest(req)
+	rsp := producer.HandleDelete5gAkaAuthenticationResultRequest(ctx, req)
 	if rsp.Body == nil {
 		c.Status(rsp.Status)
 		return
 	}
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.Auth5gAkaComfirmLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
@@ -53,17 +55,19 @@ func HTTPDelete5gAkaAuthenticationResult(c *gin.Context) {
 // Delete /ue-authentications/:authCtxId/eap-session
 // Deletes the authentication result in the UDM
 func HTTPDeleteEapAuthenticationResult(c *gin.Context) {
-	logger.EapAuthComfirmLog.Infoln("Handle Delete /ue-authentications/:authCtxId/eap-session")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.EapAuthComfirmLog)
+	log.Infoln("Handle Delete /ue-authentications/:authCtxId/eap-session")
 	req := httpwrapper.NewRequest(c.Request, nil)
 	req.Params["authCtxId"] = c.Param("authCtxId")
-	rsp := producer.HandleDeleteEapAuthenticationResultRequest(req)
+	rsp := producer.HandleDeleteEapAuthenticationResultRequest(ctx, req)
 	if rsp.Body == nil {
 		c.Status(rsp.Status)
 		return
 	}
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.EapAuthComfirmLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
@@ -33,13 +33,15 @@ import (
 
 // Post /ue-authentications/:authCtxId/eap-session
 func HTTPEapAuthMethod(c *gin.Context) {
-	logger.EapAuthComfirmLog.Infoln("Handle Post /ue-authentications/:authCtxId/eap-session")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.EapAuthComfirmLog)
+	log.Infoln("Handle Post /ue-authentications/:authCtxId/eap-session")
 	var eapSessionReq models.EapSession
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
 		problemDetail := utils.ProblemDetailsSystemFailure(err.Error())
-		logger.EapAuthComfirmLog.Errorf(getRequestBodyErr, err)
+		log.Errorf(getRequestBodyErr, err)
 		c.JSON(http.StatusInternalServerError, problemDetail)
 		return
 	}
@@ -48,7 +50,7 @@ func HTTPEapAuthMethod(c *gin.Context) {
 	if err != nil {
 		problemDetail := requestBodyLog + err.Error()
 		rsp := utils.ProblemDetailsMalformedRequestSyntax(problemDetail)
-		logger.EapAuthComfirmLog.Errorln(problemDetail)
+		log.Errorln(problemDetail)
 		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
@@ -56,11 +58,11 @@ func HTTPEapAuthMethod(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, eapSessionReq)
 	req.Params["authCtxId"] = c.Param("authCtxId")
 
-	rsp := producer.HandleEapAuthComfirmRequest(req)
+	rsp := producer.HandleEapAuthComfirmRequest(ctx, req)
 
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.EapAuthComfirmLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
@@ -91,13 +93,15 @@ func HTTPRgAuthenticationsPost(c *gin.Context) {
 
 // Put /ue-authentications/:authCtxId/5g-aka-confirmation
 func HTTPUeAuthenticationsAuthCtxId5gAkaConfirmationPut(c *gin.Context) {
-	logger.Auth5gAkaComfirmLog.Infoln("Handle Put /ue-authentications/:authCtxId/5g-aka-confirmation")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.Auth5gAkaComfirmLog)
+	log.Infoln("Handle Put /ue-authentications/:authCtxId/5g-aka-confirmation")
 	var confirmationData models.ConfirmationData
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
 		problemDetail := utils.ProblemDetailsSystemFailure(err.Error())
-		logger.Auth5gAkaComfirmLog.Errorf(getRequestBodyErr, err)
+		log.Errorf(getRequestBodyErr, err)
 		c.JSON(http.StatusInternalServerError, problemDetail)
 		return
 	}
@@ -106,7 +110,7 @@ func HTTPUeAuthenticationsAuthCtxId5gAkaConfirmationPut(c *gin.Context) {
 	if err != nil {
 		problemDetail := requestBodyLog + err.Error()
 		rsp := utils.ProblemDetailsMalformedRequestSyntax(problemDetail)
-		logger.Auth5gAkaComfirmLog.Errorln(problemDetail)
+		log.Errorln(problemDetail)
 		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
@@ -114,11 +118,11 @@ func HTTPUeAuthenticationsAuthCtxId5gAkaConfirmationPut(c *gin.Context) {
 	req := httpwrapper.NewRequest(c.Request, confirmationData)
 	req.Params["authCtxId"] = c.Param("authCtxId")
 
-	rsp := producer.HandleAuth5gAkaComfirmRequest(req)
+	rsp := producer.HandleAuth5gAkaComfirmRequest(ctx, req)
 
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.Auth5gAkaComfirmLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
@@ -128,13 +132,15 @@ func HTTPUeAuthenticationsAuthCtxId5gAkaConfirmationPut(c *gin.Context) {
 
 // Post /ue-authentications/deregister
 func HTTPUeAuthenticationsDeregisterPost(c *gin.Context) {
-	logger.UeAuthPostLog.Infoln("Handle Post /ue-authentications/deregister")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.UeAuthPostLog)
+	log.Infoln("Handle Post /ue-authentications/deregister")
 	var deregistrationInfo models.DeregistrationInfo
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
 		problemDetail := utils.ProblemDetailsSystemFailure(err.Error())
-		logger.UeAuthPostLog.Errorf(getRequestBodyErr, err)
+		log.Errorf(getRequestBodyErr, err)
 		c.JSON(http.StatusInternalServerError, problemDetail)
 		return
 	}
@@ -143,13 +149,13 @@ func HTTPUeAuthenticationsDeregisterPost(c *gin.Context) {
 	if err != nil {
 		problemDetail := requestBodyLog + err.Error()
 		rsp := utils.ProblemDetailsMalformedRequestSyntax(problemDetail)
-		logger.UeAuthPostLog.Errorln(problemDetail)
+		log.Errorln(problemDetail)
 		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
 	req := httpwrapper.NewRequest(c.Request, deregistrationInfo)
-	rsp := producer.HandleUeAuthenticationsDeregisterRequest(req)
+	rsp := producer.HandleUeAuthenticationsDeregisterRequest(ctx, req)
 	if rsp.Body == nil {
 		c.Status(rsp.Status)
 		return
@@ -157,7 +163,7 @@ func HTTPUeAuthenticationsDeregisterPost(c *gin.Context) {
 
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.UeAuthPostLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
@@ -167,13 +173,15 @@ func HTTPUeAuthenticationsDeregisterPost(c *gin.Context) {
 
 // Post /ue-authentications
 func HTTPUeAuthenticationsPost(c *gin.Context) {
-	logger.UeAuthPostLog.Infoln("Handle Post /ue-authentications")
+	ctx := requestContext(c)
+	log := logger.FromContext(ctx, logger.UeAuthPostLog)
+	log.Infoln("Handle Post /ue-authentications")
 	var authInfo models.AuthenticationInfo
 
 	requestBody, err := c.GetRawData()
 	if err != nil {
 		problemDetail := utils.ProblemDetailsSystemFailure(err.Error())
-		logger.UeAuthPostLog.Errorf(getRequestBodyErr, err)
+		log.Errorf(getRequestBodyErr, err)
 		c.JSON(http.StatusInternalServerError, problemDetail)
 		return
 	}
@@ -182,21 +190,21 @@ func HTTPUeAuthenticationsPost(c *gin.Context) {
 	if err != nil {
 		problemDetail := requestBodyLog + err.Error()
 		rsp := utils.ProblemDetailsMalformedRequestSyntax(problemDetail)
-		logger.UeAuthPostLog.Errorln(problemDetail)
+		log.Errorln(problemDetail)
 		c.JSON(http.StatusBadRequest, rsp)
 		return
 	}
 
 	req := httpwrapper.NewRequest(c.Request, authInfo)
 
-	rsp := producer.HandleUeAuthPostRequest(req)
+	rsp := producer.HandleUeAuthPostRequest(ctx, req)
 
 	for key, value := range rsp.Header {
 		c.Header(key, value[0])
 	}
 	responseBody, err := openapi.SetBody(rsp.Body, applicationJSON)
 	if err != nil {
-		logger.UeAuthPostLog.Errorln(err)
+		log.Errorln(err)
 		problemDetails := utils.ProblemDetailsSystemFailure(err.Error())
 		c.JSON(http.StatusInternalServerError, problemDetails)
 	} else {
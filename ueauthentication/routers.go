@@ -19,11 +19,13 @@ Generated by: OpenAPI Generator (https://openapi-generator.tech)
 package ueauthentication
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	ausfContext "github.com/omec-project/ausf/context"
 	"github.com/omec-project/ausf/logger"
 	"github.com/omec-project/openapi/v2/utils"
 	utilLogger "github.com/omec-project/util/logger"
@@ -90,6 +92,16 @@ func AddService(engine *gin.Engine) *gin.RouterGroup {
 	return group
 }
 
+// requestContext returns the context of the request, scoping its logs to the masked
+// authCtxId of the path when there is one
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if authCtxID := c.Param("authCtxId"); authCtxID != "" {
+		ctx = logger.WithFields(ctx, "authCtxId", ausfContext.MaskSupi(authCtxID))
+	}
+	return ctx
+}
+
 // Default handler for not yet implemented routes
 func DefaultHandleFunc(c *gin.Context) {
 	writeNotImplementedProblem(c, "This API route is not implemented")